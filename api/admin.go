@@ -0,0 +1,63 @@
+package api
+
+import (
+	"encoding/json"
+	"fydeos/config"
+	"fydeos/db"
+	"fydeos/jobs"
+	"net/http"
+	"strconv"
+)
+
+// BackupDatabase 立即备份数据库，返回备份文件路径
+func BackupDatabase(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	path, err := jobs.NewBackuper(store(r), config.Cfg.Backup).RunOnce()
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	json.NewEncoder(w).Encode(map[string]string{"path": path})
+}
+
+// ImportData 导入数据文件格式（{user_profile, todos}）的请求体。
+// dedup=true时跳过与已有待办事项重复的项，dedup_keys指定判断重复的字段，未指定时使用IMPORT_DEDUP和IMPORT_DEDUP_KEYS
+func ImportData(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	opts := db.DefaultImportOptions()
+	query := r.URL.Query()
+	if v := query.Get("dedup"); v != "" {
+		dedup, err := strconv.ParseBool(v)
+		if err != nil {
+			http.Error(w, "dedup must be true or false", http.StatusBadRequest)
+			return
+		}
+		opts.Dedup = dedup
+	}
+	keys, err := db.ParseImportKeys(query.Get("dedup_keys"))
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	if len(keys) == 0 {
+		http.Error(w, "dedup_keys must not be empty", http.StatusBadRequest)
+		return
+	}
+	opts.Keys = keys
+
+	var data db.DataStructure
+	if err := json.NewDecoder(r.Body).Decode(&data); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	result, err := store(r).ImportData(data, opts)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	json.NewEncoder(w).Encode(result)
+}
@@ -0,0 +1,21 @@
+package api
+
+import (
+	"encoding/json"
+	"fydeos/db"
+	"net/http"
+	"time"
+)
+
+// GetAgeDistribution 返回未完成待办事项按创建时长的分布以及最早创建的一项，用于发现被搁置的任务
+func GetAgeDistribution(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	todos, err := store(r).GetAllTodos()
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	json.NewEncoder(w).Encode(db.ComputeAgeDistribution(todos, time.Now()))
+}
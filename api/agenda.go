@@ -0,0 +1,151 @@
+package api
+
+import (
+	"fmt"
+	"fydeos/config"
+	"fydeos/db"
+	"net/http"
+	"sort"
+	"strings"
+	"time"
+)
+
+// AgendaItem 日程中的一项：排期任务为排期时间段，定时到期的任务为截止时刻，全天任务没有时间
+type AgendaItem struct {
+	Kind  string     `json:"kind"` // scheduled, due
+	Start *time.Time `json:"start"`
+	End   *time.Time `json:"end"`
+	Todo  db.Todo    `json:"todo"`
+}
+
+// DayAgenda 某天的可打印日程
+type DayAgenda struct {
+	Date    string       `json:"date"`
+	AllDay  []AgendaItem `json:"all_day"`
+	Timed   []AgendaItem `json:"timed"`
+	Overdue []db.Todo    `json:"overdue"` // 当天开始前已过期、延续到当天的任务
+}
+
+// BuildDayAgenda 整理day所在日期（按day的时区）的未完成任务：与当天重叠的排期、当天到期的任务按时间排序，
+// 全天任务单列；当天零点前已过期（含宽限期）的任务作为延续项列出
+func BuildDayAgenda(todos []db.Todo, day time.Time, grace time.Duration) DayAgenda {
+	loc := day.Location()
+	dayStart := time.Date(day.Year(), day.Month(), day.Day(), 0, 0, 0, 0, loc)
+	dayEnd := dayStart.AddDate(0, 0, 1)
+
+	agenda := DayAgenda{
+		Date:    dayStart.Format("2006-01-02"),
+		AllDay:  []AgendaItem{},
+		Timed:   []AgendaItem{},
+		Overdue: []db.Todo{},
+	}
+	for _, todo := range todos {
+		if todo.Status == "completed" {
+			continue
+		}
+
+		if start, end, ok := todo.Window(); ok && todo.ScheduledStart != nil {
+			// 没有预计耗时的排期是一个时间点，落在当天即可
+			if start.Before(dayEnd) && (end.After(dayStart) || !start.Before(dayStart)) {
+				start, end := start.In(loc), end.In(loc)
+				agenda.Timed = append(agenda.Timed, AgendaItem{Kind: "scheduled", Start: &start, End: &end, Todo: todo})
+				continue
+			}
+		}
+
+		if todo.DueDate == nil {
+			continue
+		}
+		due := todo.DueDate.In(loc)
+		switch {
+		case db.IsOverdue(todo, dayStart, grace):
+			agenda.Overdue = append(agenda.Overdue, todo)
+		case !due.Before(dayStart) && due.Before(dayEnd) && todo.AllDay:
+			agenda.AllDay = append(agenda.AllDay, AgendaItem{Kind: "due", Todo: todo})
+		case !due.Before(dayStart) && due.Before(dayEnd):
+			agenda.Timed = append(agenda.Timed, AgendaItem{Kind: "due", Start: &due, Todo: todo})
+		}
+	}
+
+	sort.SliceStable(agenda.Timed, func(i, j int) bool {
+		return agenda.Timed[i].Start.Before(*agenda.Timed[j].Start)
+	})
+	db.SortTodos(agenda.Overdue, db.SortDueFirst)
+	return agenda
+}
+
+// Markdown 将日程渲染为便于打印的Markdown
+func (a DayAgenda) Markdown() string {
+	var b strings.Builder
+
+	fmt.Fprintf(&b, "# 日程 %s\n\n", a.Date)
+
+	fmt.Fprintf(&b, "## 全天\n\n")
+	if len(a.AllDay) == 0 {
+		b.WriteString("无\n\n")
+	} else {
+		for _, item := range a.AllDay {
+			fmt.Fprintf(&b, "- [ ] %s（%s）\n", escapeCell(item.Todo.Title), item.Todo.Priority)
+		}
+		b.WriteString("\n")
+	}
+
+	fmt.Fprintf(&b, "## 时间安排\n\n")
+	if len(a.Timed) == 0 {
+		b.WriteString("无\n\n")
+	} else {
+		b.WriteString("| 时间 | 任务 | 优先级 | 类型 |\n|---|---|---|---|\n")
+		for _, item := range a.Timed {
+			slot := item.Start.Format("15:04")
+			if item.End != nil && item.End.After(*item.Start) {
+				slot += "-" + item.End.Format("15:04")
+			}
+			kind := "排期"
+			if item.Kind == "due" {
+				kind = "到期"
+			}
+			fmt.Fprintf(&b, "| %s | %s | %s | %s |\n", slot, escapeCell(item.Todo.Title), item.Todo.Priority, kind)
+		}
+		b.WriteString("\n")
+	}
+
+	fmt.Fprintf(&b, "## 延续的过期任务\n\n")
+	if len(a.Overdue) == 0 {
+		b.WriteString("无\n")
+	} else {
+		for _, todo := range a.Overdue {
+			fmt.Fprintf(&b, "- [ ] %s（%s，截止 %s）\n", escapeCell(todo.Title), todo.Priority, todo.DueDate.Format("2006-01-02"))
+		}
+	}
+	return b.String()
+}
+
+// GetDayAgenda 返回date（默认今天）的日程；?format=md或Accept为text/markdown时返回可打印的Markdown
+func GetDayAgenda(w http.ResponseWriter, r *http.Request) {
+	_, loc, err := requestProfile(r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	day := time.Now().In(loc)
+	if v := r.URL.Query().Get("date"); v != "" {
+		if day, err = time.ParseInLocation("2006-01-02", v, loc); err != nil {
+			http.Error(w, "date must be YYYY-MM-DD", http.StatusBadRequest)
+			return
+		}
+	}
+
+	todos, err := store(r).GetAllTodos()
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	agenda := BuildDayAgenda(todos, day, config.Cfg.Overdue.Grace)
+	if wantsMarkdown(r) {
+		w.Header().Set("Content-Type", "text/markdown; charset=utf-8")
+		w.Write([]byte(agenda.Markdown()))
+		return
+	}
+	writeNegotiated(w, r, agenda)
+}
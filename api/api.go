@@ -2,23 +2,196 @@ package api
 
 import (
 	"encoding/json"
+	"errors"
+	"fmt"
+	"fydeos/config"
 	"fydeos/db"
 	"github.com/gorilla/mux"
+	"math"
 	"net/http"
+	"net/url"
 	"strconv"
+	"strings"
 	"time"
 )
 
+// store 返回在请求上下文中执行的数据库实例，每条SQL语句记录为请求span的子span
+func store(r *http.Request) *db.SQLiteDatabase {
+	return db.DB.WithContext(r.Context())
+}
+
 func GetTodos(w http.ResponseWriter, r *http.Request) {
 	w.Header().Set("Content-Type", "application/json")
 
-	todos, err := db.DB.GetAllTodos()
+	query := r.URL.Query()
+	starred, _ := strconv.ParseBool(query.Get("starred"))
+	overdue, _ := strconv.ParseBool(query.Get("overdue"))
+	stale, _ := strconv.ParseBool(query.Get("stale"))
+	source := query.Get("source")
+	externalSystem, externalID := query.Get("external_system"), query.Get("external_id")
+	tag := query.Get("tag")
+	durationRange, err := parseDurationRange(query)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	page, err := parsePage(query)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	sortBy := query.Get("sort")
+	var weights db.ScoreWeights
+	var strategy db.SortStrategy
+	switch sortBy {
+	case "":
+	case "score":
+		weights, err = parseScoreWeights(query)
+	default:
+		strategy, err = db.ParseSortStrategy(sortBy)
+	}
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	var extraFields []string
+	if sortBy == "score" {
+		extraFields = []string{"score"}
+	}
+	fields, err := db.ParseFields(query.Get("fields"), extraFields...)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	filter := db.TodoFilter{
+		Statuses:   queryValues(query, "status"),
+		Priorities: queryValues(query, "priority"),
+		Categories: queryValues(query, "category"),
+		Starred:    starred,
+		Overdue:    overdue,
+		Stale:      stale,
+	}
+
+	// 只有SQL筛选且使用默认排序时在数据库中分页，否则在内存中筛选、排序后再截取当前页
+	inMemory := source != "" || externalSystem != "" || externalID != "" || tag != "" || !durationRange.IsEmpty() || sortBy != ""
+	if !inMemory {
+		todos, total, err := store(r).QueryTodosPaged(filter, page.PerPage, page.offset())
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		if todos == nil {
+			todos = []db.Todo{}
+		}
+		w.Header().Set(TotalCountHeader, strconv.Itoa(total))
+		writeProjected(w, r, todos, fields)
+		return
+	}
+
+	todos, err := store(r).QueryTodos(filter)
 	if err != nil {
 		http.Error(w, err.Error(), http.StatusInternalServerError)
 		return
 	}
 
-	json.NewEncoder(w).Encode(todos)
+	todos = db.FilterBySource(todos, source)
+	todos = db.FilterByExternalRef(todos, externalSystem, externalID)
+	todos = db.FilterByTag(todos, tag)
+	todos = db.FilterByDuration(todos, durationRange)
+	w.Header().Set(TotalCountHeader, strconv.Itoa(len(todos)))
+	switch {
+	case sortBy == "score":
+		scored := db.ScoreTodos(todos, time.Now(), weights)
+		start, end := page.bounds(len(scored))
+		writeProjected(w, r, scored[start:end], fields)
+		return
+	case strategy != "":
+		db.SortTodos(todos, strategy)
+	}
+	start, end := page.bounds(len(todos))
+	writeProjected(w, r, todos[start:end], fields)
+}
+
+// queryValues 返回查询参数key的所有非空取值，如status=pending&status=in_progress
+func queryValues(query url.Values, key string) []string {
+	var values []string
+	for _, v := range query[key] {
+		if v = strings.TrimSpace(v); v != "" {
+			values = append(values, v)
+		}
+	}
+	return values
+}
+
+// GetTodo 返回单个待办事项，ID不是整数时返回400，不存在时返回404
+func GetTodo(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.Atoi(mux.Vars(r)["id"])
+	if err != nil {
+		http.Error(w, "Invalid ID", http.StatusBadRequest)
+		return
+	}
+	fields, err := db.ParseFields(r.URL.Query().Get("fields"))
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	todo, err := store(r).GetTodoByID(id)
+	var notFound *db.ErrTodoNotFound
+	if errors.As(err, &notFound) {
+		http.Error(w, err.Error(), http.StatusNotFound)
+		return
+	}
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	writeProjected(w, r, todo, fields)
+}
+
+// parseScoreWeights 读取w_priority、w_due、w_stale权重，未指定的使用默认值
+func parseScoreWeights(query url.Values) (db.ScoreWeights, error) {
+	weights := db.DefaultScoreWeights()
+	for _, p := range []struct {
+		key    string
+		target *float64
+	}{
+		{"w_priority", &weights.Priority},
+		{"w_due", &weights.Due},
+		{"w_stale", &weights.Stale},
+	} {
+		v := query.Get(p.key)
+		if v == "" {
+			continue
+		}
+		f, err := strconv.ParseFloat(v, 64)
+		if err != nil || f < 0 || math.IsInf(f, 0) || math.IsNaN(f) {
+			return weights, fmt.Errorf("%s must be a non-negative number", p.key)
+		}
+		*p.target = f
+	}
+	return weights, nil
+}
+
+// parseDurationRange 解析min_minutes、max_minutes和include_unestimated参数
+func parseDurationRange(query url.Values) (db.DurationRange, error) {
+	var bounds [2]int
+	for i, key := range []string{"min_minutes", "max_minutes"} {
+		v := query.Get(key)
+		if v == "" {
+			continue
+		}
+		n, err := strconv.Atoi(v)
+		if err != nil {
+			return db.DurationRange{}, fmt.Errorf("%s must be a non-negative integer", key)
+		}
+		bounds[i] = n
+	}
+	includeUnestimated, _ := strconv.ParseBool(query.Get("include_unestimated"))
+	return db.NewDurationRange(bounds[0], bounds[1], includeUnestimated)
 }
 
 func CreateTodo(w http.ResponseWriter, r *http.Request) {
@@ -31,7 +204,30 @@ func CreateTodo(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	// 设置默认值
+	applyCreateDefaults(&todo)
+	if result := db.ValidateTodo(todo, time.Now()); !result.Valid {
+		writeValidationError(w, result)
+		return
+	}
+	todo.CreatedDate = time.Now()
+	todo.LastUpdated = time.Now()
+	todo.Source = db.SourceAPI
+
+	err = store(r).CreateTodo(&todo)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	json.NewEncoder(w).Encode(todo)
+}
+
+// applyCreateDefaults 为创建时未指定的字段设置默认值，并整理标题和描述；只有标题的任务放入收件箱
+func applyCreateDefaults(todo *db.Todo) {
+	db.NormalizeTodo(todo)
+	if db.IsQuickCapture(*todo) {
+		todo.Category = db.InboxCategory()
+	}
 	if todo.Status == "" {
 		todo.Status = "pending"
 	}
@@ -41,15 +237,26 @@ func CreateTodo(w http.ResponseWriter, r *http.Request) {
 	if todo.Category == "" {
 		todo.Category = "personal"
 	}
-	todo.CreatedDate = time.Now()
-	todo.LastUpdated = time.Now()
+}
 
-	if err := db.DB.CreateTodo(&todo); err != nil {
-		http.Error(w, err.Error(), http.StatusInternalServerError)
+// writeValidationError 以400返回校验结果
+func writeValidationError(w http.ResponseWriter, result db.ValidationResult) {
+	w.WriteHeader(http.StatusBadRequest)
+	json.NewEncoder(w).Encode(result)
+}
+
+// ValidateTodo 按创建时的默认值和校验规则检查请求体，返回错误和警告，不做保存
+func ValidateTodo(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	var todo db.Todo
+	if err := json.NewDecoder(r.Body).Decode(&todo); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
 		return
 	}
 
-	json.NewEncoder(w).Encode(todo)
+	applyCreateDefaults(&todo)
+	json.NewEncoder(w).Encode(db.ValidateTodo(todo, time.Now()))
 }
 
 func UpdateTodo(w http.ResponseWriter, r *http.Request) {
@@ -68,27 +275,65 @@ func UpdateTodo(w http.ResponseWriter, r *http.Request) {
 		http.Error(w, err.Error(), http.StatusBadRequest)
 		return
 	}
+	updatedTodo.ID = id
+	db.NormalizeTodo(&updatedTodo)
+	if result := db.ValidateTodo(updatedTodo, time.Now()); !result.Valid {
+		writeValidationError(w, result)
+		return
+	}
 
 	// 获取现有todo
-	todo, err := db.DB.GetTodoByID(id)
+	todo, err := store(r).GetTodoByID(id)
 	if err != nil {
 		http.Error(w, "Todo not found", http.StatusNotFound)
 		return
 	}
 
-	// 更新字段
-	updatedTodo.ID = id
-	updatedTodo.CreatedDate = todo.CreatedDate
+	// 带If-Match时只在版本未变时更新，否则返回最新版本供客户端合并
+	if !checkIfMatch(w, r, todo) {
+		return
+	}
+
+	saveUpdatedTodo(w, r, todo, &updatedTodo)
+}
+
+// saveUpdatedTodo 保存PUT/PATCH的更新结果并写出响应，保留创建日期
+func saveUpdatedTodo(w http.ResponseWriter, r *http.Request, existing, updatedTodo *db.Todo) {
+	updatedTodo.CreatedDate = existing.CreatedDate
 	updatedTodo.LastUpdated = time.Now()
+	updatedTodo.Source = db.SourceAPI
 
-	if err := db.DB.UpdateTodo(&updatedTodo); err != nil {
+	err := store(r).UpdateTodo(updatedTodo)
+	var incomplete *db.ErrIncompleteSubtasks
+	if errors.As(err, &incomplete) {
+		http.Error(w, err.Error(), http.StatusConflict)
+		return
+	}
+	if err != nil {
 		http.Error(w, err.Error(), http.StatusInternalServerError)
 		return
 	}
 
+	if warning := store(r).SubtaskWarning(updatedTodo); warning != "" {
+		w.Header().Set(ResultWarningHeader, warning)
+	}
+	w.Header().Set("ETag", etag(updatedTodo.Version()))
 	json.NewEncoder(w).Encode(updatedTodo)
 }
 
+// checkIfMatch 带If-Match且与当前版本不一致时返回409及最新版本，返回false表示已写出响应
+func checkIfMatch(w http.ResponseWriter, r *http.Request, todo *db.Todo) bool {
+	if ifMatch := r.Header.Get("If-Match"); ifMatch != "" && !matchesVersion(ifMatch, todo.Version()) {
+		w.WriteHeader(http.StatusConflict)
+		json.NewEncoder(w).Encode(TodoConflict{
+			Error:   "todo was modified by another client",
+			Current: currentVersion(todo),
+		})
+		return false
+	}
+	return true
+}
+
 func DeleteTodo(w http.ResponseWriter, r *http.Request) {
 	w.Header().Set("Content-Type", "application/json")
 
@@ -99,7 +344,29 @@ func DeleteTodo(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	if err := db.DB.DeleteTodo(id); err != nil {
+	mode, err := db.ParseDeleteMode(r.URL.Query().Get("mode"))
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	if dryRun, _ := strconv.ParseBool(r.URL.Query().Get("dry_run")); dryRun {
+		preview, err := store(r).PreviewDeleteTodo(id, mode)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusNotFound)
+			return
+		}
+		json.NewEncoder(w).Encode(preview)
+		return
+	}
+
+	err = store(r).DeleteTodo(id, mode)
+	var hasSubtasks *db.ErrHasSubtasks
+	if errors.As(err, &hasSubtasks) {
+		http.Error(w, err.Error(), http.StatusConflict)
+		return
+	}
+	if err != nil {
 		http.Error(w, "Todo not found", http.StatusNotFound)
 		return
 	}
@@ -107,24 +374,127 @@ func DeleteTodo(w http.ResponseWriter, r *http.Request) {
 	json.NewEncoder(w).Encode(map[string]bool{"success": true})
 }
 
+// GetDelegatedTodos 返回等待他人完成的待办事项，可用waiting_on筛选
+func GetDelegatedTodos(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	todos, err := store(r).GetDelegatedTodos(r.URL.Query().Get("waiting_on"))
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	warnIfLarge(w, len(todos))
+	json.NewEncoder(w).Encode(todos)
+}
+
+// GetIncompleteMetadata 返回未完成且缺少截止日期、预计耗时等元数据的待办事项，
+// 可用fields（逗号分隔）指定检查哪些字段，默认使用INCOMPLETE_METADATA_FIELDS
+func GetIncompleteMetadata(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	fields, err := db.ParseMetadataFields(r.URL.Query().Get("fields"))
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	todos, err := store(r).GetAllTodos()
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	result := db.FindIncompleteMetadata(todos, fields)
+	warnIfLarge(w, len(result))
+	json.NewEncoder(w).Encode(result)
+}
+
+// GetInbox 返回收件箱类别中待整理的未完成待办事项
+func GetInbox(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	todos, err := store(r).GetAllTodos()
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	inbox := db.FilterInbox(todos)
+	warnIfLarge(w, len(inbox))
+	json.NewEncoder(w).Encode(inbox)
+}
+
+// GetDateIssues 返回依赖截止日期或排期时间、但相应时间缺失或无效的未完成待办事项，每项附带date_issues
+func GetDateIssues(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	todos, err := store(r).GetAllTodos()
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	result := db.FindDateIssues(todos)
+	warnIfLarge(w, len(result))
+	json.NewEncoder(w).Encode(result)
+}
+
+// NeedsAttention 未完成且截止日期落在其优先级对应窗口内（含已过期）的任务需要关注
+func NeedsAttention(todo db.Todo, now time.Time, windows map[string]time.Duration) bool {
+	if todo.Status == "completed" || todo.DueDate == nil {
+		return false
+	}
+	window, ok := windows[todo.Priority]
+	if !ok || window <= 0 {
+		return false
+	}
+	return todo.DueDate.Before(now.Add(window))
+}
+
 // MCP AI Functions
 func AiAnalyzeTasks(w http.ResponseWriter, r *http.Request) {
 	w.Header().Set("Content-Type", "application/json")
 
-	todos, err := db.DB.GetAllTodos()
+	analysisType := r.URL.Query().Get("analysis_type")
+	if analysisType != "" && analysisType != "overview" && analysisType != "sla" && analysisType != "velocity" {
+		http.Error(w, "analysis_type must be overview, sla or velocity", http.StatusBadRequest)
+		return
+	}
+
+	profile, loc, err := requestProfile(r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	todos, err := store(r).GetAllTodos()
 	if err != nil {
 		http.Error(w, err.Error(), http.StatusInternalServerError)
 		return
 	}
 
+	if analysisType == "sla" {
+		analyzeSLA(w, todos, profile.WorkSchedule, time.Now().In(loc))
+		return
+	}
+	if analysisType == "velocity" {
+		analyzeVelocity(w, r, todos, time.Now().In(loc))
+		return
+	}
+
 	// AI Analysis Logic
-	now := time.Now()
+	now := time.Now().In(loc)
 	var urgentTasks []db.Todo
 	var overdueTasks []db.Todo
 	var staleTasks []db.Todo
 	var todayTasks []db.Todo
+	needsAttention := []db.Todo{}
 
 	for _, todo := range todos {
+		if NeedsAttention(todo, now, config.Cfg.Attention.Windows) {
+			needsAttention = append(needsAttention, todo)
+		}
+
 		// Check for urgent tasks
 		if todo.Priority == "urgent" || todo.Priority == "high" {
 			if todo.DueDate != nil && todo.DueDate.Before(now.AddDate(0, 0, 2)) {
@@ -133,7 +503,7 @@ func AiAnalyzeTasks(w http.ResponseWriter, r *http.Request) {
 		}
 
 		// Check for overdue tasks
-		if todo.DueDate != nil && todo.DueDate.Before(now) && todo.Status != "completed" {
+		if db.IsOverdue(todo, now, config.Cfg.Overdue.Grace) {
 			overdueTasks = append(overdueTasks, todo)
 		}
 
@@ -149,11 +519,12 @@ func AiAnalyzeTasks(w http.ResponseWriter, r *http.Request) {
 	}
 
 	analysis := map[string]interface{}{
-		"total_tasks":   len(todos),
-		"urgent_tasks":  urgentTasks,
-		"overdue_tasks": overdueTasks,
-		"stale_tasks":   staleTasks,
-		"today_tasks":   todayTasks,
+		"total_tasks":     len(todos),
+		"urgent_tasks":    urgentTasks,
+		"overdue_tasks":   overdueTasks,
+		"stale_tasks":     staleTasks,
+		"today_tasks":     todayTasks,
+		"needs_attention": needsAttention,
 		"recommendations": []string{
 			"优先处理紧急任务",
 			"检查并更新过期任务",
@@ -165,70 +536,129 @@ func AiAnalyzeTasks(w http.ResponseWriter, r *http.Request) {
 	json.NewEncoder(w).Encode(analysis)
 }
 
-func AiOptimizeSchedule(w http.ResponseWriter, r *http.Request) {
-	w.Header().Set("Content-Type", "application/json")
-
-	todos, err := db.DB.GetAllTodos()
+// analyzeSLA 返回违反或即将违反优先级SLA的任务，SLA按用户工作时间计算
+func analyzeSLA(w http.ResponseWriter, todos []db.Todo, schedule db.WorkSchedule, now time.Time) {
+	report, err := db.EvaluateSLA(todos, config.Cfg.SLA.Windows, config.Cfg.SLA.AtRiskPercent, schedule, now)
 	if err != nil {
 		http.Error(w, err.Error(), http.StatusInternalServerError)
 		return
 	}
 
-	// Get high priority pending tasks
-	var priorityTasks []db.Todo
-	for _, todo := range todos {
-		if (todo.Status == "pending" || todo.Status == "in_progress") &&
-			(todo.Priority == "urgent" || todo.Priority == "high") {
-			priorityTasks = append(priorityTasks, todo)
+	json.NewEncoder(w).Encode(report)
+}
+
+// analyzeVelocity 返回最近weeks周（默认8）每周完成的工作量点数
+func analyzeVelocity(w http.ResponseWriter, r *http.Request, todos []db.Todo, now time.Time) {
+	weeks := 8
+	if v := r.URL.Query().Get("weeks"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil {
+			http.Error(w, "weeks must be an integer", http.StatusBadRequest)
+			return
 		}
+		weeks = n
 	}
 
-	// Sort by priority and due date
-	priorityOrder := map[string]int{
-		"urgent": 1,
-		"high":   2,
-		"medium": 3,
-		"low":    4,
+	velocity, err := db.ComputeVelocity(todos, now, weeks)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
 	}
+	json.NewEncoder(w).Encode(velocity)
+}
 
-	for i := 0; i < len(priorityTasks)-1; i++ {
-		for j := i + 1; j < len(priorityTasks); j++ {
-			pi := priorityOrder[priorityTasks[i].Priority]
-			pj := priorityOrder[priorityTasks[j].Priority]
+func GetUserProfile(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
 
-			if pi > pj || (pi == pj && priorityTasks[i].DueDate != nil && priorityTasks[j].DueDate != nil &&
-				priorityTasks[i].DueDate.After(*priorityTasks[j].DueDate)) {
-				priorityTasks[i], priorityTasks[j] = priorityTasks[j], priorityTasks[i]
-			}
-		}
+	profile, err := store(r).GetUserProfile()
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
 	}
 
-	// Limit to top 10
-	if len(priorityTasks) > 10 {
-		priorityTasks = priorityTasks[:10]
+	writeNegotiated(w, r, profile)
+}
+
+// GetFieldHistory 返回待办事项某个字段的取值变化时间线
+func GetFieldHistory(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	vars := mux.Vars(r)
+	id, err := strconv.Atoi(vars["id"])
+	if err != nil {
+		http.Error(w, "Invalid ID", http.StatusBadRequest)
+		return
 	}
 
-	schedule := map[string]interface{}{
-		"optimized_tasks": priorityTasks,
-		"schedule_advice": []string{
-			"上午处理紧急任务，精力最充沛",
-			"将相似任务归类处理，提高效率",
-			"复杂任务之间安排休息时间",
-			"预留缓冲时间应对突发情况",
-		},
+	history, err := store(r).GetFieldHistory(id, vars["field"])
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusNotFound)
+		return
 	}
 
-	json.NewEncoder(w).Encode(schedule)
+	json.NewEncoder(w).Encode(history)
 }
 
-func GetUserProfile(w http.ResponseWriter, r *http.Request) {
+// PatchTodo 部分更新待办事项：只修改请求体中出现的字段（JSON字段名），显式传null清空可为空的字段。
+// 只有is_starred时直接切换星标，不做整体校验
+func PatchTodo(w http.ResponseWriter, r *http.Request) {
 	w.Header().Set("Content-Type", "application/json")
 
-	profile, err := db.DB.GetUserProfile()
+	id, err := strconv.Atoi(mux.Vars(r)["id"])
+	if err != nil {
+		http.Error(w, "Invalid ID", http.StatusBadRequest)
+		return
+	}
+
+	var patch map[string]json.RawMessage
+	if err := json.NewDecoder(r.Body).Decode(&patch); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	if len(patch) == 0 {
+		http.Error(w, "at least one field is required", http.StatusBadRequest)
+		return
+	}
+
+	if raw, ok := patch["is_starred"]; ok && len(patch) == 1 && r.Header.Get("If-Match") == "" {
+		var starred bool
+		if err := json.Unmarshal(raw, &starred); err != nil {
+			http.Error(w, "is_starred must be a boolean", http.StatusBadRequest)
+			return
+		}
+		todo, err := store(r).SetStarred(id, starred, db.SourceAPI)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusNotFound)
+			return
+		}
+		json.NewEncoder(w).Encode(todo)
+		return
+	}
+
+	todo, err := store(r).GetTodoByID(id)
+	var notFound *db.ErrTodoNotFound
+	if errors.As(err, &notFound) {
+		http.Error(w, err.Error(), http.StatusNotFound)
+		return
+	}
 	if err != nil {
 		http.Error(w, err.Error(), http.StatusInternalServerError)
 		return
 	}
+	if !checkIfMatch(w, r, todo) {
+		return
+	}
+
+	updatedTodo := *todo
+	if err := db.ApplyPatch(&updatedTodo, patch); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	db.NormalizeTodo(&updatedTodo)
+	if result := db.ValidateTodo(updatedTodo, time.Now()); !result.Valid {
+		writeValidationError(w, result)
+		return
+	}
 
-	json.NewEncoder(w).Encode(profile)
+	saveUpdatedTodo(w, r, todo, &updatedTodo)
 }
@@ -0,0 +1,104 @@
+package api
+
+import (
+	"encoding/json"
+	"errors"
+	"fydeos/db"
+	"net/http"
+	"strings"
+)
+
+// RecategorizeRequest 批量修改类别的请求体
+// 使用 from/to 重命名整个类别，或使用 ids/category 修改指定待办事项
+type RecategorizeRequest struct {
+	From     string `json:"from"`
+	To       string `json:"to"`
+	IDs      []int  `json:"ids"`
+	Category string `json:"category"`
+}
+
+func RecategorizeTodos(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	var req RecategorizeRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	req.From = strings.TrimSpace(req.From)
+	req.To = strings.TrimSpace(req.To)
+	req.Category = strings.TrimSpace(req.Category)
+
+	var changed int64
+	var err error
+	switch {
+	case len(req.IDs) > 0:
+		if req.Category == "" {
+			http.Error(w, "category is required when ids are given", http.StatusBadRequest)
+			return
+		}
+		changed, err = store(r).RecategorizeByIDs(req.IDs, req.Category, db.SourceAPI)
+	case req.From != "" || req.To != "":
+		if req.From == "" || req.To == "" {
+			http.Error(w, "both from and to are required", http.StatusBadRequest)
+			return
+		}
+		changed, err = store(r).RenameCategory(req.From, req.To, db.SourceAPI)
+	default:
+		http.Error(w, "either {from, to} or {ids, category} is required", http.StatusBadRequest)
+		return
+	}
+
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	json.NewEncoder(w).Encode(map[string]int64{"changed": changed})
+}
+
+// RenameCategoryRequest 重命名类别的请求体，Merge为true时允许合并到已存在的类别
+type RenameCategoryRequest struct {
+	From  string `json:"from"`
+	To    string `json:"to"`
+	Merge bool   `json:"merge"`
+}
+
+// RenameCategory 在一个事务中重命名类别；to已存在且未指定merge时返回409，from不存在时返回404
+func RenameCategory(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	var req RenameCategoryRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	req.From = strings.TrimSpace(req.From)
+	req.To = strings.TrimSpace(req.To)
+	if req.From == "" || req.To == "" {
+		http.Error(w, "both from and to are required", http.StatusBadRequest)
+		return
+	}
+	if req.From == req.To {
+		http.Error(w, "from and to must differ", http.StatusBadRequest)
+		return
+	}
+
+	changed, err := store(r).RenameCategoryStrict(req.From, req.To, req.Merge, db.SourceAPI)
+	var exists *db.ErrCategoryExists
+	switch {
+	case errors.As(err, &exists):
+		http.Error(w, err.Error(), http.StatusConflict)
+		return
+	case errors.Is(err, db.ErrCategoryNotFound):
+		http.Error(w, err.Error(), http.StatusNotFound)
+		return
+	case err != nil:
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	json.NewEncoder(w).Encode(map[string]int64{"changed": changed})
+}
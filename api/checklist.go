@@ -0,0 +1,100 @@
+package api
+
+import (
+	"encoding/json"
+	"fydeos/db"
+	"github.com/gorilla/mux"
+	"net/http"
+	"strconv"
+	"strings"
+)
+
+// ChecklistRequest 清单操作的请求体
+// POST使用text添加清单项；PATCH使用index定位清单项，done为空时切换完成状态
+type ChecklistRequest struct {
+	Text  string `json:"text"`
+	Index *int   `json:"index"`
+	Done  *bool  `json:"done"`
+}
+
+func AddChecklistItem(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	id, err := strconv.Atoi(mux.Vars(r)["id"])
+	if err != nil {
+		http.Error(w, "Invalid ID", http.StatusBadRequest)
+		return
+	}
+
+	var req ChecklistRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	text := strings.TrimSpace(req.Text)
+	if text == "" {
+		http.Error(w, "text is required", http.StatusBadRequest)
+		return
+	}
+
+	todo, err := store(r).AddChecklistItem(id, text, db.SourceAPI)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusNotFound)
+		return
+	}
+
+	json.NewEncoder(w).Encode(todo)
+}
+
+func ToggleChecklistItem(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	id, err := strconv.Atoi(mux.Vars(r)["id"])
+	if err != nil {
+		http.Error(w, "Invalid ID", http.StatusBadRequest)
+		return
+	}
+
+	var req ChecklistRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	if req.Index == nil {
+		http.Error(w, "index is required", http.StatusBadRequest)
+		return
+	}
+
+	todo, err := store(r).SetChecklistItemDone(id, *req.Index, req.Done, db.SourceAPI)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusNotFound)
+		return
+	}
+
+	json.NewEncoder(w).Encode(todo)
+}
+
+func RemoveChecklistItem(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	id, err := strconv.Atoi(mux.Vars(r)["id"])
+	if err != nil {
+		http.Error(w, "Invalid ID", http.StatusBadRequest)
+		return
+	}
+
+	index, err := strconv.Atoi(r.URL.Query().Get("index"))
+	if err != nil {
+		http.Error(w, "index is required", http.StatusBadRequest)
+		return
+	}
+
+	todo, err := store(r).RemoveChecklistItem(id, index, db.SourceAPI)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusNotFound)
+		return
+	}
+
+	json.NewEncoder(w).Encode(todo)
+}
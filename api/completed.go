@@ -0,0 +1,35 @@
+package api
+
+import (
+	"encoding/json"
+	"fydeos/db"
+	"net/http"
+	"time"
+)
+
+// GetCompletedTodos 返回完成时间在from/to范围内的待办事项及预计耗时合计
+func GetCompletedTodos(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	_, loc, err := requestProfile(r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	query := r.URL.Query()
+	from, to, err := db.ParseDateRange(query.Get("from"), query.Get("to"), time.Now().In(loc))
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	todos, err := store(r).GetCompletedTodos(from, to)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	warnIfLarge(w, len(todos))
+	json.NewEncoder(w).Encode(db.SummarizeCompleted(todos, from, to))
+}
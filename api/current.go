@@ -0,0 +1,65 @@
+package api
+
+import (
+	"encoding/json"
+	"errors"
+	"fydeos/db"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/gorilla/mux"
+)
+
+// TodoVersion 服务器上最新保存的待办事项及其版本号
+type TodoVersion struct {
+	Todo    *db.Todo `json:"todo"`
+	Version string   `json:"version"`
+}
+
+// TodoConflict If-Match与当前版本不一致时返回的409响应体，Current为用于合并的最新版本
+type TodoConflict struct {
+	Error   string      `json:"error"`
+	Current TodoVersion `json:"current"`
+}
+
+func currentVersion(todo *db.Todo) TodoVersion {
+	return TodoVersion{Todo: todo, Version: todo.Version()}
+}
+
+// etag 将版本号格式化为ETag
+func etag(version string) string {
+	return `"` + version + `"`
+}
+
+// matchesVersion 判断If-Match（可带引号，*匹配任意版本）是否为version
+func matchesVersion(ifMatch, version string) bool {
+	ifMatch = strings.TrimSpace(ifMatch)
+	return ifMatch == "*" || strings.Trim(ifMatch, `"`) == version
+}
+
+// GetCurrentTodo 返回待办事项的最新版本，供更新冲突（409）后合并使用
+func GetCurrentTodo(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	id, err := strconv.Atoi(mux.Vars(r)["id"])
+	if err != nil {
+		http.Error(w, "Invalid ID", http.StatusBadRequest)
+		return
+	}
+
+	todo, err := store(r).GetTodoByID(id)
+	var notFound *db.ErrTodoNotFound
+	if errors.As(err, &notFound) {
+		http.Error(w, err.Error(), http.StatusNotFound)
+		return
+	}
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	current := currentVersion(todo)
+	w.Header().Set("ETag", etag(current.Version))
+	json.NewEncoder(w).Encode(current)
+}
@@ -0,0 +1,67 @@
+package api
+
+import (
+	"encoding/json"
+	"errors"
+	"fydeos/db"
+	"github.com/gorilla/mux"
+	"net/http"
+	"strconv"
+)
+
+// DependenciesRequest 设置依赖的请求体
+type DependenciesRequest struct {
+	DependsOn []int `json:"depends_on"`
+}
+
+// GetDependencies 返回待办事项直接依赖的待办事项ID
+func GetDependencies(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	id, err := strconv.Atoi(mux.Vars(r)["id"])
+	if err != nil {
+		http.Error(w, "Invalid ID", http.StatusBadRequest)
+		return
+	}
+
+	deps, err := store(r).GetDependencies(id)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	json.NewEncoder(w).Encode(DependenciesRequest{DependsOn: deps})
+}
+
+// SetDependencies 替换待办事项的依赖，依赖不合法时返回400且不做修改
+func SetDependencies(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	id, err := strconv.Atoi(mux.Vars(r)["id"])
+	if err != nil {
+		http.Error(w, "Invalid ID", http.StatusBadRequest)
+		return
+	}
+
+	var req DependenciesRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	err = store(r).SetDependencies(id, req.DependsOn)
+	var invalid *db.ErrInvalidDependency
+	if errors.As(err, &invalid) {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusNotFound)
+		return
+	}
+
+	if req.DependsOn == nil {
+		req.DependsOn = []int{}
+	}
+	json.NewEncoder(w).Encode(req)
+}
@@ -0,0 +1,27 @@
+package api
+
+import (
+	"encoding/json"
+	"fydeos/db"
+	"net/http"
+)
+
+// DiffTodos 将请求体中的导出快照（与数据文件格式相同）与当前待办事项比较，
+// 返回新增、删除和字段级修改，用于排查同步问题
+func DiffTodos(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	var snapshot db.DataStructure
+	if err := json.NewDecoder(r.Body).Decode(&snapshot); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	todos, err := store(r).GetAllTodos()
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	json.NewEncoder(w).Encode(db.DiffTodos(snapshot.Todos, todos))
+}
@@ -0,0 +1,98 @@
+package api
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"fydeos/db"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// exportColumns CSV导出的列，与JSON字段名一致
+var exportColumns = []string{"id", "title", "description", "priority", "status", "category", "due_date", "estimated_duration", "tags", "created_date", "completed_at"}
+
+func formatExportTime(t *time.Time) string {
+	if t == nil {
+		return ""
+	}
+	return t.Format(time.RFC3339)
+}
+
+func exportRow(todo db.Todo) []string {
+	return []string{
+		strconv.Itoa(todo.ID),
+		todo.Title,
+		todo.Description,
+		todo.Priority,
+		todo.Status,
+		todo.Category,
+		formatExportTime(todo.DueDate),
+		todo.EstimatedDuration,
+		strings.Join(todo.Tags, ","),
+		formatExportTime(&todo.CreatedDate),
+		formatExportTime(todo.CompletedAt),
+	}
+}
+
+// ExportTodos 按筛选条件导出待办事项，format为json（默认）或csv，逐项写出。
+// 支持status、priority、category（可重复）、tag和截止日期范围due_from/due_to
+func ExportTodos(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+	format := query.Get("format")
+	if format == "" {
+		format = "json"
+	}
+	if format != "json" && format != "csv" {
+		http.Error(w, "format must be json or csv", http.StatusBadRequest)
+		return
+	}
+
+	_, loc, err := requestProfile(r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	dueFrom, dueTo, err := db.ParseDueBounds(query.Get("due_from"), query.Get("due_to"), loc)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	todos, err := store(r).QueryTodos(db.TodoFilter{
+		Statuses:   queryValues(query, "status"),
+		Priorities: queryValues(query, "priority"),
+		Categories: queryValues(query, "category"),
+		DueFrom:    dueFrom,
+		DueTo:      dueTo,
+	})
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	todos = db.FilterByTag(todos, query.Get("tag"))
+
+	w.Header().Set("Content-Disposition", `attachment; filename="todos.`+format+`"`)
+	if format == "csv" {
+		w.Header().Set("Content-Type", "text/csv; charset=utf-8")
+		cw := csv.NewWriter(w)
+		cw.Write(exportColumns)
+		for _, todo := range todos {
+			cw.Write(exportRow(todo))
+		}
+		cw.Flush()
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	enc := json.NewEncoder(w)
+	w.Write([]byte("["))
+	for i, todo := range todos {
+		if i > 0 {
+			w.Write([]byte(","))
+		}
+		enc.Encode(todo)
+	}
+	w.Write([]byte("]\n"))
+}
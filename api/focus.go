@@ -0,0 +1,61 @@
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+)
+
+// FocusRequest 设置当前焦点的请求体
+type FocusRequest struct {
+	ID int `json:"id"`
+}
+
+// GetFocus 返回用户当前专注的待办事项，没有焦点时todo为null
+func GetFocus(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	focus, err := store(r).GetFocus()
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	json.NewEncoder(w).Encode(focus)
+}
+
+// SetFocus 将未完成的待办事项设为当前焦点，任务完成或删除时焦点自动清除
+func SetFocus(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	var req FocusRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	if req.ID <= 0 {
+		http.Error(w, "id is required", http.StatusBadRequest)
+		return
+	}
+
+	if _, err := store(r).GetTodoByID(req.ID); err != nil {
+		http.Error(w, "Todo not found", http.StatusNotFound)
+		return
+	}
+	focus, err := store(r).SetFocus(req.ID)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	json.NewEncoder(w).Encode(focus)
+}
+
+// ClearFocus 清除当前焦点
+func ClearFocus(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	err := store(r).ClearFocus()
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	json.NewEncoder(w).Encode(map[string]bool{"success": true})
+}
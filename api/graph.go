@@ -0,0 +1,25 @@
+package api
+
+import (
+	"encoding/json"
+	"fydeos/db"
+	"net/http"
+)
+
+// GetTodoGraph 以节点和边的形式返回待办事项的依赖和父子关系，用于绘制关系图，并标注其中的环
+func GetTodoGraph(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	todos, err := store(r).GetAllTodos()
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	deps, err := store(r).GetAllDependencies()
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	json.NewEncoder(w).Encode(db.BuildGraph(todos, deps))
+}
@@ -0,0 +1,24 @@
+package api
+
+import (
+	"encoding/json"
+	"fydeos/mcp"
+	"net/http"
+)
+
+// Healthz 返回服务健康状态；MCP SSE服务器未启动不影响REST服务，只在sse字段中体现
+func Healthz(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"status": "ok",
+		"sse":    mcp.Status(),
+	})
+}
+
+// GetMCPUsage 返回各MCP工具自服务启动以来的调用统计
+func GetMCPUsage(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	json.NewEncoder(w).Encode(mcp.Usage())
+}
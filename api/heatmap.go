@@ -0,0 +1,26 @@
+package api
+
+import (
+	"encoding/json"
+	"fydeos/db"
+	"net/http"
+)
+
+// GetCompletionHeatmap 返回按星期几和小时（用户时区）统计的完成次数，7x24矩阵
+func GetCompletionHeatmap(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	todos, err := store(r).GetAllTodos()
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	_, loc, err := requestProfile(r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	json.NewEncoder(w).Encode(db.BuildCompletionHeatmap(todos, loc))
+}
@@ -0,0 +1,68 @@
+package api
+
+import (
+	"fmt"
+	"fydeos/config"
+	"net/http"
+	"net/url"
+	"strconv"
+)
+
+// ResultWarningHeader 列表结果较大时提示客户端的响应头，结果本身不会被截断
+const ResultWarningHeader = "X-Result-Warning"
+
+// TotalCountHeader 分页列表中符合条件的总数
+const TotalCountHeader = "X-Total-Count"
+
+const (
+	defaultPerPage = 50
+	maxPerPage     = 500
+)
+
+// pageParams 分页参数
+type pageParams struct {
+	Page    int
+	PerPage int
+}
+
+func (p pageParams) offset() int { return (p.Page - 1) * p.PerPage }
+
+// parsePage 解析page和per_page参数，未传时默认为第1页、每页50条
+func parsePage(query url.Values) (pageParams, error) {
+	p := pageParams{Page: 1, PerPage: defaultPerPage}
+	if v := query.Get("page"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil || n < 1 {
+			return p, fmt.Errorf("page must be a positive integer")
+		}
+		p.Page = n
+	}
+	if v := query.Get("per_page"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil || n < 1 || n > maxPerPage {
+			return p, fmt.Errorf("per_page must be an integer between 1 and %d", maxPerPage)
+		}
+		p.PerPage = n
+	}
+	return p, nil
+}
+
+// bounds 返回长度为n的结果中当前页的起止下标
+func (p pageParams) bounds(n int) (int, int) {
+	start := p.offset()
+	if start > n {
+		start = n
+	}
+	end := start + p.PerPage
+	if end > n {
+		end = n
+	}
+	return start, end
+}
+
+// warnIfLarge 列表结果数量超过配置阈值时设置ResultWarningHeader，需在写入响应体前调用
+func warnIfLarge(w http.ResponseWriter, n int) {
+	if threshold := config.Cfg.List.WarnThreshold; n > threshold {
+		w.Header().Set(ResultWarningHeader, fmt.Sprintf("result contains %d items (threshold %d); use page/per_page or narrow the query with filters", n, threshold))
+	}
+}
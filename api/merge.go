@@ -0,0 +1,37 @@
+package api
+
+import (
+	"encoding/json"
+	"errors"
+	"fydeos/db"
+	"net/http"
+)
+
+// MergeRequest 合并待办事项的请求体
+type MergeRequest struct {
+	PrimaryID   int `json:"primary_id"`
+	SecondaryID int `json:"secondary_id"`
+}
+
+// MergeTodos 将secondary_id合并到primary_id并删除前者，返回合并后的待办事项
+func MergeTodos(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	var req MergeRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	todo, err := store(r).MergeTodos(req.PrimaryID, req.SecondaryID, db.SourceAPI)
+	if errors.Is(err, db.ErrMergeSelf) {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusNotFound)
+		return
+	}
+
+	json.NewEncoder(w).Encode(todo)
+}
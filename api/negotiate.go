@@ -0,0 +1,71 @@
+package api
+
+import (
+	"encoding/json"
+	"fydeos/db"
+	"net/http"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// wantsYAML 请求是否要求YAML：?format=yaml，或Accept中包含YAML类型
+func wantsYAML(r *http.Request) bool {
+	if format := r.URL.Query().Get("format"); format != "" {
+		return format == "yaml"
+	}
+	accept := r.Header.Get("Accept")
+	for _, t := range []string{"application/yaml", "application/x-yaml", "text/yaml"} {
+		if strings.Contains(accept, t) {
+			return true
+		}
+	}
+	return false
+}
+
+// wantsMarkdown 请求是否要求Markdown：?format=md，或Accept中包含text/markdown
+func wantsMarkdown(r *http.Request) bool {
+	if format := r.URL.Query().Get("format"); format != "" {
+		return format == "md"
+	}
+	return strings.Contains(r.Header.Get("Accept"), "text/markdown")
+}
+
+// writeNegotiated 按请求选择JSON（默认）或YAML输出v。
+// YAML由JSON结果转换而来，字段名和时间格式与JSON保持一致
+func writeNegotiated(w http.ResponseWriter, r *http.Request, v interface{}) {
+	if !wantsYAML(r) {
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(v)
+		return
+	}
+
+	data, err := json.Marshal(v)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	var generic interface{}
+	if err := json.Unmarshal(data, &generic); err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	out, err := yaml.Marshal(generic)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/yaml")
+	w.Write(out)
+}
+
+// writeProjected 只保留fields中的字段后按writeNegotiated输出，fields为空时输出完整结果
+func writeProjected(w http.ResponseWriter, r *http.Request, v interface{}, fields []string) {
+	projected, err := db.ProjectFields(v, fields)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	writeNegotiated(w, r, projected)
+}
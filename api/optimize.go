@@ -0,0 +1,57 @@
+package api
+
+import (
+	"encoding/json"
+	"fydeos/config"
+	"fydeos/db"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+func AiOptimizeSchedule(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	limit := config.Cfg.Optimize.Limit
+	if v := r.URL.Query().Get("limit"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil || n <= 0 {
+			http.Error(w, "limit must be a positive integer", http.StatusBadRequest)
+			return
+		}
+		limit = n
+	}
+
+	strategy, err := db.ParseSortStrategy(r.URL.Query().Get("sort"))
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	_, loc, err := requestProfile(r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	todos, err := store(r).GetAllTodos()
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	result := db.OptimizeSchedule(todos, time.Now().In(loc), limit, config.Cfg.Overdue.Grace, strategy)
+	schedule := map[string]interface{}{
+		"optimized_tasks": result.Selected,
+		"excluded_tasks":  result.Excluded,
+		"limit":           result.Limit,
+		"schedule_advice": []string{
+			"上午处理紧急任务，精力最充沛",
+			"将相似任务归类处理，提高效率",
+			"复杂任务之间安排休息时间",
+			"预留缓冲时间应对突发情况",
+		},
+	}
+
+	json.NewEncoder(w).Encode(schedule)
+}
@@ -0,0 +1,39 @@
+package api
+
+import (
+	"encoding/json"
+	"fydeos/db"
+	"net/http"
+	"strconv"
+)
+
+// 默认返回的被推迟任务数量
+const defaultProcrastinatedLimit = 10
+
+// GetProcrastinated 返回截止日期或排期被推后次数最多的未完成待办事项（?limit=，默认10）
+func GetProcrastinated(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	limit := defaultProcrastinatedLimit
+	if v := r.URL.Query().Get("limit"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil || n <= 0 {
+			http.Error(w, "limit must be a positive integer", http.StatusBadRequest)
+			return
+		}
+		limit = n
+	}
+
+	todos, err := store(r).GetAllTodos()
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	counts, err := store(r).GetPostponeCounts()
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	json.NewEncoder(w).Encode(db.RankProcrastinated(todos, counts, limit))
+}
@@ -0,0 +1,50 @@
+package api
+
+import (
+	"encoding/json"
+	"github.com/gorilla/mux"
+	"net/http"
+	"strconv"
+)
+
+// GetProjects 返回项目列表，include_archived=true时包含已归档的项目
+func GetProjects(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	includeArchived, _ := strconv.ParseBool(r.URL.Query().Get("include_archived"))
+	projects, err := store(r).GetProjects(includeArchived)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	json.NewEncoder(w).Encode(projects)
+}
+
+// ArchiveProject 手动归档项目
+func ArchiveProject(w http.ResponseWriter, r *http.Request) {
+	setProjectArchived(w, r, true)
+}
+
+// RestoreProject 恢复已归档的项目，恢复后不再被自动归档
+func RestoreProject(w http.ResponseWriter, r *http.Request) {
+	setProjectArchived(w, r, false)
+}
+
+func setProjectArchived(w http.ResponseWriter, r *http.Request, archived bool) {
+	w.Header().Set("Content-Type", "application/json")
+
+	id, err := strconv.Atoi(mux.Vars(r)["id"])
+	if err != nil {
+		http.Error(w, "Invalid ID", http.StatusBadRequest)
+		return
+	}
+
+	project, err := store(r).SetProjectArchived(id, archived)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusNotFound)
+		return
+	}
+
+	json.NewEncoder(w).Encode(project)
+}
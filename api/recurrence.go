@@ -0,0 +1,114 @@
+package api
+
+import (
+	"encoding/json"
+	"errors"
+	"fydeos/db"
+	"github.com/gorilla/mux"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+// maxOccurrences 单次请求最多计算的发生次数
+const maxOccurrences = 100
+
+// GetOccurrences 返回重复待办事项接下来count次（默认5次）的发生时间，不做持久化
+func GetOccurrences(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	id, err := strconv.Atoi(mux.Vars(r)["id"])
+	if err != nil {
+		http.Error(w, "Invalid ID", http.StatusBadRequest)
+		return
+	}
+
+	count := 5
+	if v := r.URL.Query().Get("count"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil || n <= 0 || n > maxOccurrences {
+			http.Error(w, "count must be an integer between 1 and 100", http.StatusBadRequest)
+			return
+		}
+		count = n
+	}
+
+	todo, err := store(r).GetTodoByID(id)
+	if err != nil {
+		http.Error(w, "Todo not found", http.StatusNotFound)
+		return
+	}
+
+	_, loc, err := requestProfile(r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	occurrences, err := db.Occurrences(*todo, time.Now().In(loc), count)
+	if errors.Is(err, db.ErrNotRecurring) {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"id":          todo.ID,
+		"recurrence":  todo.Recurrence,
+		"occurrences": occurrences,
+	})
+}
+
+// RecurrencePreviewRequest 预览重复规则的请求体
+type RecurrencePreviewRequest struct {
+	Rule   string `json:"rule"`
+	Anchor string `json:"anchor"` // RFC3339，或YYYY-MM-DD（用户时区零点）
+	Count  int    `json:"count"`
+}
+
+// PreviewRecurrence 按规则计算anchor之后的count次（默认5次）发生时间，不做保存
+func PreviewRecurrence(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	var req RecurrencePreviewRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	if req.Count == 0 {
+		req.Count = 5
+	}
+	if req.Count < 0 || req.Count > maxOccurrences {
+		http.Error(w, "count must be an integer between 1 and 100", http.StatusBadRequest)
+		return
+	}
+
+	_, loc, err := requestProfile(r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	anchor, err := time.ParseInLocation("2006-01-02", req.Anchor, loc)
+	if err != nil {
+		if anchor, err = time.Parse(time.RFC3339, req.Anchor); err != nil {
+			http.Error(w, "anchor must be RFC3339 or YYYY-MM-DD", http.StatusBadRequest)
+			return
+		}
+		anchor = anchor.In(loc)
+	}
+
+	occurrences, err := db.PreviewRecurrence(req.Rule, anchor, req.Count)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"rule":        req.Rule,
+		"anchor":      anchor,
+		"occurrences": occurrences,
+	})
+}
@@ -0,0 +1,233 @@
+package api
+
+import (
+	"encoding/json"
+	"fmt"
+	"fydeos/config"
+	"fydeos/db"
+	"net/http"
+	"sort"
+	"strings"
+	"time"
+)
+
+// ReportOptions 报告生成选项
+type ReportOptions struct {
+	Now        time.Time
+	StaleAfter time.Duration   // 超过该时长未更新视为陈旧
+	TrendDays  int             // 完成趋势统计的天数
+	Grace      time.Duration   // 过期判定的宽限期
+	Sort       db.SortStrategy // 任务列表的排序策略，为空时按优先级优先
+}
+
+// ReportTask 报告中列出的任务摘要
+type ReportTask struct {
+	ID       int        `json:"id"`
+	Title    string     `json:"title"`
+	Priority string     `json:"priority"`
+	DueDate  *time.Time `json:"due_date,omitempty"`
+	Updated  time.Time  `json:"last_updated"`
+}
+
+// WorkloadEntry 某类别下未完成任务的数量、预计耗时和工作量点数
+type WorkloadEntry struct {
+	Category       string  `json:"category"`
+	Open           int     `json:"open"`
+	EstimatedHours float64 `json:"estimated_hours"`
+	EffortPoints   int     `json:"effort_points"`
+}
+
+// TrendPoint 某天完成的任务数
+type TrendPoint struct {
+	Date      string `json:"date"`
+	Completed int    `json:"completed"`
+}
+
+// Report 任务分析报告
+type Report struct {
+	GeneratedAt     time.Time       `json:"generated_at"`
+	Total           int             `json:"total"`
+	Open            int             `json:"open"`
+	ByPriority      map[string]int  `json:"by_priority"`
+	Overdue         []ReportTask    `json:"overdue"`
+	Stale           []ReportTask    `json:"stale"`
+	Workload        []WorkloadEntry `json:"workload"`
+	CompletionTrend []TrendPoint    `json:"completion_trend"`
+}
+
+// BuildReport 汇总优先级分布、过期、陈旧、工作量和完成趋势
+func BuildReport(todos []db.Todo, opts ReportOptions) Report {
+	if opts.Now.IsZero() {
+		opts.Now = time.Now()
+	}
+	if opts.StaleAfter <= 0 {
+		opts.StaleAfter = 30 * 24 * time.Hour
+	}
+	if opts.TrendDays <= 0 {
+		opts.TrendDays = 7
+	}
+
+	report := Report{
+		GeneratedAt: opts.Now,
+		Total:       len(todos),
+		ByPriority:  map[string]int{},
+		Overdue:     []ReportTask{},
+		Stale:       []ReportTask{},
+		Workload:    []WorkloadEntry{},
+	}
+
+	today := time.Date(opts.Now.Year(), opts.Now.Month(), opts.Now.Day(), 0, 0, 0, 0, opts.Now.Location())
+	trendStart := today.AddDate(0, 0, -(opts.TrendDays - 1))
+	trend := map[string]int{}
+	workload := map[string]*WorkloadEntry{}
+
+	sorted := append([]db.Todo(nil), todos...)
+	db.SortTodos(sorted, opts.Sort)
+
+	for _, todo := range sorted {
+		if todo.Status == "completed" {
+			if todo.CompletedAt != nil && !todo.CompletedAt.In(opts.Now.Location()).Before(trendStart) {
+				trend[todo.CompletedAt.In(opts.Now.Location()).Format("2006-01-02")]++
+			}
+			continue
+		}
+
+		report.Open++
+		report.ByPriority[todo.Priority]++
+
+		task := ReportTask{ID: todo.ID, Title: todo.Title, Priority: todo.Priority, DueDate: todo.DueDate, Updated: todo.LastUpdated}
+		if db.IsOverdue(todo, opts.Now, opts.Grace) {
+			report.Overdue = append(report.Overdue, task)
+		}
+		if opts.Now.Sub(todo.LastUpdated) > opts.StaleAfter {
+			report.Stale = append(report.Stale, task)
+		}
+
+		entry, ok := workload[todo.Category]
+		if !ok {
+			entry = &WorkloadEntry{Category: todo.Category}
+			workload[todo.Category] = entry
+		}
+		entry.Open++
+		entry.EstimatedHours += db.ParseEstimatedDuration(todo.EstimatedDuration).Hours()
+		entry.EffortPoints += todo.EffortPoints
+	}
+
+	for _, entry := range workload {
+		report.Workload = append(report.Workload, *entry)
+	}
+	sort.Slice(report.Workload, func(i, j int) bool {
+		return report.Workload[i].Category < report.Workload[j].Category
+	})
+
+	for day := trendStart; !day.After(today); day = day.AddDate(0, 0, 1) {
+		date := day.Format("2006-01-02")
+		report.CompletionTrend = append(report.CompletionTrend, TrendPoint{Date: date, Completed: trend[date]})
+	}
+
+	return report
+}
+
+// Markdown 将报告渲染为可阅读的Markdown
+func (r Report) Markdown() string {
+	var b strings.Builder
+
+	fmt.Fprintf(&b, "# 任务分析报告\n\n")
+	fmt.Fprintf(&b, "生成时间：%s\n\n", r.GeneratedAt.Format("2006-01-02 15:04"))
+	fmt.Fprintf(&b, "共 %d 项任务，其中未完成 %d 项。\n\n", r.Total, r.Open)
+
+	fmt.Fprintf(&b, "## 优先级分布\n\n")
+	fmt.Fprintf(&b, "| 优先级 | 未完成数量 |\n|---|---|\n")
+	for _, p := range []string{"urgent", "high", "medium", "low"} {
+		fmt.Fprintf(&b, "| %s | %d |\n", p, r.ByPriority[p])
+	}
+	b.WriteString("\n")
+
+	fmt.Fprintf(&b, "## 过期任务\n\n")
+	writeTaskTable(&b, r.Overdue)
+
+	fmt.Fprintf(&b, "## 陈旧任务\n\n")
+	writeTaskTable(&b, r.Stale)
+
+	fmt.Fprintf(&b, "## 工作量\n\n")
+	if len(r.Workload) == 0 {
+		b.WriteString("无\n\n")
+	} else {
+		fmt.Fprintf(&b, "| 类别 | 未完成数量 | 预计耗时（小时） | 点数 |\n|---|---|---|---|\n")
+		for _, w := range r.Workload {
+			fmt.Fprintf(&b, "| %s | %d | %.1f | %d |\n", escapeCell(w.Category), w.Open, w.EstimatedHours, w.EffortPoints)
+		}
+		b.WriteString("\n")
+	}
+
+	fmt.Fprintf(&b, "## 完成趋势\n\n")
+	fmt.Fprintf(&b, "| 日期 | 完成数量 |\n|---|---|\n")
+	for _, p := range r.CompletionTrend {
+		fmt.Fprintf(&b, "| %s | %d |\n", p.Date, p.Completed)
+	}
+
+	return b.String()
+}
+
+func writeTaskTable(b *strings.Builder, tasks []ReportTask) {
+	if len(tasks) == 0 {
+		b.WriteString("无\n\n")
+		return
+	}
+	b.WriteString("| ID | 标题 | 优先级 | 截止日期 | 最后更新 |\n|---|---|---|---|---|\n")
+	for _, t := range tasks {
+		due := "-"
+		if t.DueDate != nil {
+			due = t.DueDate.Format("2006-01-02")
+		}
+		fmt.Fprintf(b, "| %d | %s | %s | %s | %s |\n", t.ID, escapeCell(t.Title), t.Priority, due, t.Updated.Format("2006-01-02"))
+	}
+	b.WriteString("\n")
+}
+
+func escapeCell(s string) string {
+	return strings.NewReplacer("|", `\|`, "\n", " ").Replace(s)
+}
+
+// GetAnalyticsReport 以Markdown（默认）或JSON格式下载任务分析报告
+func GetAnalyticsReport(w http.ResponseWriter, r *http.Request) {
+	format := r.URL.Query().Get("format")
+	if format == "" {
+		format = "md"
+	}
+	if format != "md" && format != "json" {
+		http.Error(w, "format must be md or json", http.StatusBadRequest)
+		return
+	}
+
+	strategy, err := db.ParseSortStrategy(r.URL.Query().Get("sort"))
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	_, loc, err := requestProfile(r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	todos, err := store(r).GetAllTodos()
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	report := BuildReport(todos, ReportOptions{Now: time.Now().In(loc), Grace: config.Cfg.Overdue.Grace, Sort: strategy})
+	filename := "report-" + report.GeneratedAt.Format("2006-01-02") + "." + format
+	w.Header().Set("Content-Disposition", `attachment; filename="`+filename+`"`)
+
+	if format == "json" {
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(report)
+		return
+	}
+
+	w.Header().Set("Content-Type", "text/markdown; charset=utf-8")
+	w.Write([]byte(report.Markdown()))
+}
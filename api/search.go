@@ -0,0 +1,51 @@
+package api
+
+import (
+	"encoding/json"
+	"fydeos/config"
+	"net/http"
+	"strconv"
+	"strings"
+)
+
+// SearchTodos 按关键词搜索待办事项，fuzzy=true时容忍拼写错误
+func SearchTodos(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	query := r.URL.Query()
+	q := strings.TrimSpace(query.Get("q"))
+	if q == "" {
+		http.Error(w, "q is required", http.StatusBadRequest)
+		return
+	}
+
+	fuzzy, _ := strconv.ParseBool(query.Get("fuzzy"))
+	if !fuzzy {
+		todos, err := store(r).SearchTodos(q)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		warnIfLarge(w, len(todos))
+		json.NewEncoder(w).Encode(todos)
+		return
+	}
+
+	maxDistance := config.Cfg.Search.MaxDistance
+	if v := query.Get("max_distance"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil || n < 0 {
+			http.Error(w, "max_distance must be a non-negative integer", http.StatusBadRequest)
+			return
+		}
+		maxDistance = n
+	}
+
+	results, err := store(r).FuzzySearchTodos(q, maxDistance)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	warnIfLarge(w, len(results))
+	json.NewEncoder(w).Encode(results)
+}
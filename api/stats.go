@@ -0,0 +1,27 @@
+package api
+
+import (
+	"encoding/json"
+	"fydeos/config"
+	"net/http"
+	"time"
+)
+
+// GetStats 返回账户级别的待办事项汇总统计
+func GetStats(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	_, loc, err := requestProfile(r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	stats, err := store(r).GetAccountStats(time.Now().In(loc), config.Cfg.Overdue.Grace)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	json.NewEncoder(w).Encode(stats)
+}
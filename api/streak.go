@@ -0,0 +1,27 @@
+package api
+
+import (
+	"encoding/json"
+	"fydeos/db"
+	"net/http"
+	"time"
+)
+
+// GetCompletionStreak 返回按用户时区计算的当前和最长连续完成天数
+func GetCompletionStreak(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	todos, err := store(r).GetAllTodos()
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	_, loc, err := requestProfile(r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	json.NewEncoder(w).Encode(db.ComputeCompletionStreak(todos, time.Now(), loc))
+}
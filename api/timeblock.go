@@ -0,0 +1,55 @@
+package api
+
+import (
+	"encoding/json"
+	"errors"
+	"fydeos/db"
+	"github.com/gorilla/mux"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+// ScheduleRequest 时间块排期的请求体
+type ScheduleRequest struct {
+	Start time.Time `json:"start"`
+	Force bool      `json:"force"`
+}
+
+// ScheduleTodo 将待办事项排期到指定开始时间，与其他排期冲突时返回409及冲突列表
+func ScheduleTodo(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	id, err := strconv.Atoi(mux.Vars(r)["id"])
+	if err != nil {
+		http.Error(w, "Invalid ID", http.StatusBadRequest)
+		return
+	}
+
+	var req ScheduleRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	if req.Start.IsZero() {
+		http.Error(w, "start is required", http.StatusBadRequest)
+		return
+	}
+
+	todo, err := store(r).ScheduleTodo(id, req.Start, req.Force, db.SourceAPI)
+	var conflict *db.ErrScheduleConflict
+	if errors.As(err, &conflict) {
+		w.WriteHeader(http.StatusConflict)
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"error":     conflict.Error(),
+			"conflicts": conflict.Conflicts,
+		})
+		return
+	}
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusNotFound)
+		return
+	}
+
+	json.NewEncoder(w).Encode(todo)
+}
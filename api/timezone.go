@@ -0,0 +1,36 @@
+package api
+
+import (
+	"fmt"
+	"fydeos/db"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// TimezoneHeader 请求级时区覆盖的请求头，与查询参数tz等价
+const TimezoneHeader = "X-Timezone"
+
+// resolveLocation 确定请求使用的时区，优先级为：请求参数tz或X-Timezone请求头 >
+// 用户资料时区 > SERVER_TIMEZONE > UTC。请求显式指定的时区无效时返回错误
+func resolveLocation(r *http.Request, profile *db.UserProfile) (*time.Location, error) {
+	tz := strings.TrimSpace(r.URL.Query().Get("tz"))
+	if tz == "" {
+		tz = strings.TrimSpace(r.Header.Get(TimezoneHeader))
+	}
+	if tz != "" {
+		loc, err := time.LoadLocation(tz)
+		if err != nil {
+			return nil, fmt.Errorf("invalid timezone %q", tz)
+		}
+		return loc, nil
+	}
+	return profile.Location(), nil
+}
+
+// requestProfile 读取用户资料（读取失败时使用默认工作时间）并确定请求使用的时区
+func requestProfile(r *http.Request) (*db.UserProfile, *time.Location, error) {
+	profile := store(r).LoadProfileOrDefault()
+	loc, err := resolveLocation(r, profile)
+	return profile, loc, err
+}
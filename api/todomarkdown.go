@@ -0,0 +1,158 @@
+package api
+
+import (
+	"fmt"
+	"fydeos/db"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gorilla/mux"
+)
+
+// TodoBundle 导出单个待办事项时一并渲染的关联数据
+type TodoBundle struct {
+	Subtasks  []db.Todo
+	DependsOn []int
+	// Location 日期显示使用的时区，为空时使用UTC
+	Location *time.Location
+}
+
+// RenderTodoMarkdown 将待办事项及其关联数据渲染为Markdown
+func RenderTodoMarkdown(todo db.Todo, bundle TodoBundle) string {
+	loc := bundle.Location
+	if loc == nil {
+		loc = time.UTC
+	}
+	formatTime := func(t time.Time) string {
+		return t.In(loc).Format("2006-01-02 15:04")
+	}
+
+	var b strings.Builder
+	fmt.Fprintf(&b, "# %s\n\n", strings.ReplaceAll(todo.Title, "\n", " "))
+
+	rows := [][2]string{
+		{"ID", strconv.Itoa(todo.ID)},
+		{"状态", todo.Status},
+		{"优先级", todo.Priority},
+		{"类别", todo.Category},
+	}
+	if todo.DueDate != nil {
+		due := formatTime(*todo.DueDate)
+		if todo.AllDay {
+			due = todo.DueDate.In(loc).Format("2006-01-02")
+		}
+		rows = append(rows, [2]string{"截止日期", due})
+	}
+	if todo.ScheduledStart != nil && todo.ScheduledEnd != nil {
+		rows = append(rows, [2]string{"排期", formatTime(*todo.ScheduledStart) + " - " + formatTime(*todo.ScheduledEnd)})
+	}
+	rows = append(rows,
+		[2]string{"预计耗时", todo.EstimatedDuration},
+		[2]string{"精力", todo.EnergyLevel},
+	)
+	if todo.EffortPoints > 0 {
+		rows = append(rows, [2]string{"点数", strconv.Itoa(todo.EffortPoints)})
+	}
+	if tags := append(append([]string{}, todo.Tags...), todo.AutoTags...); len(tags) > 0 {
+		rows = append(rows, [2]string{"标签", strings.Join(tags, ", ")})
+	}
+	rows = append(rows,
+		[2]string{"委派给", todo.WaitingOn},
+		[2]string{"重复", string(todo.Recurrence)},
+	)
+	if todo.ParentID != nil {
+		rows = append(rows, [2]string{"父任务", "#" + strconv.Itoa(*todo.ParentID)})
+	}
+	if todo.ExternalRef != nil {
+		ref := todo.ExternalRef.System + " " + todo.ExternalRef.ID
+		if todo.ExternalRef.URL != "" {
+			ref = fmt.Sprintf("[%s](%s)", ref, todo.ExternalRef.URL)
+		}
+		rows = append(rows, [2]string{"外部链接", ref})
+	}
+	rows = append(rows,
+		[2]string{"创建时间", formatTime(todo.CreatedDate)},
+		[2]string{"最后更新", formatTime(todo.LastUpdated)},
+	)
+	if todo.CompletedAt != nil {
+		rows = append(rows, [2]string{"完成时间", formatTime(*todo.CompletedAt)})
+	}
+
+	b.WriteString("| 字段 | 值 |\n|---|---|\n")
+	for _, row := range rows {
+		if row[1] == "" {
+			continue
+		}
+		fmt.Fprintf(&b, "| %s | %s |\n", row[0], escapeCell(row[1]))
+	}
+
+	if todo.Description != "" {
+		fmt.Fprintf(&b, "\n## 描述\n\n%s\n", todo.Description)
+	}
+
+	if len(todo.Checklist) > 0 {
+		fmt.Fprintf(&b, "\n## 清单\n\n")
+		for _, item := range todo.Checklist {
+			fmt.Fprintf(&b, "- %s %s\n", checkbox(item.Done), strings.ReplaceAll(item.Text, "\n", " "))
+		}
+	}
+
+	if len(bundle.Subtasks) > 0 {
+		fmt.Fprintf(&b, "\n## 子任务\n\n")
+		for _, sub := range bundle.Subtasks {
+			fmt.Fprintf(&b, "- %s %s（#%d，%s）\n", checkbox(sub.Status == "completed"), strings.ReplaceAll(sub.Title, "\n", " "), sub.ID, sub.Priority)
+		}
+	}
+
+	if len(bundle.DependsOn) > 0 {
+		ids := make([]string, len(bundle.DependsOn))
+		for i, id := range bundle.DependsOn {
+			ids[i] = "#" + strconv.Itoa(id)
+		}
+		fmt.Fprintf(&b, "\n## 依赖\n\n%s\n", strings.Join(ids, ", "))
+	}
+
+	return b.String()
+}
+
+func checkbox(done bool) string {
+	if done {
+		return "[x]"
+	}
+	return "[ ]"
+}
+
+// GetTodoMarkdown 以Markdown格式导出单个待办事项
+func GetTodoMarkdown(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.Atoi(mux.Vars(r)["id"])
+	if err != nil {
+		http.Error(w, "Invalid ID", http.StatusBadRequest)
+		return
+	}
+	_, loc, err := requestProfile(r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	todo, err := store(r).GetTodoByID(id)
+	if err != nil {
+		http.Error(w, "Todo not found", http.StatusNotFound)
+		return
+	}
+
+	bundle := TodoBundle{Location: loc}
+	if bundle.Subtasks, err = store(r).GetSubtasks(id); err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	if bundle.DependsOn, err = store(r).GetDependencies(id); err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "text/markdown; charset=utf-8")
+	w.Write([]byte(RenderTodoMarkdown(*todo, bundle)))
+}
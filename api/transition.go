@@ -0,0 +1,62 @@
+package api
+
+import (
+	"encoding/json"
+	"fydeos/db"
+	"net/http"
+)
+
+// BulkTransitionRequest 批量状态转换的请求体
+type BulkTransitionRequest struct {
+	IDs      []int  `json:"ids"`
+	ToStatus string `json:"to_status"`
+}
+
+// BulkTransition 批量转换状态，返回每个ID的转换结果
+func BulkTransition(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	var req BulkTransitionRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	if len(req.IDs) == 0 || req.ToStatus == "" {
+		http.Error(w, "ids and to_status are required", http.StatusBadRequest)
+		return
+	}
+
+	json.NewEncoder(w).Encode(store(r).BulkTransition(req.IDs, req.ToStatus, db.SourceAPI))
+}
+
+// CompleteRequest 按条件批量完成的请求体
+type CompleteRequest struct {
+	db.CompleteFilter
+	Confirm bool `json:"confirm"`
+}
+
+// CompleteByFilter 将满足条件的未完成待办事项全部标记为completed，返回数量和ID；需要confirm为true
+func CompleteByFilter(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	var req CompleteRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	if req.CompleteFilter.IsEmpty() {
+		http.Error(w, "at least one of status, priority, category or tag is required", http.StatusBadRequest)
+		return
+	}
+	if !req.Confirm {
+		http.Error(w, "confirm must be true to complete matching todos", http.StatusBadRequest)
+		return
+	}
+
+	result, err := store(r).CompleteByFilter(req.CompleteFilter, db.SourceAPI)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	json.NewEncoder(w).Encode(result)
+}
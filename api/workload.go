@@ -0,0 +1,43 @@
+package api
+
+import (
+	"encoding/json"
+	"fydeos/config"
+	"fydeos/db"
+	"net/http"
+	"time"
+)
+
+// GetWorkload 返回from到to（默认从今天起7天）每天到期任务的预计耗时与可用工作时间，标记超负荷的日期
+func GetWorkload(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	profile, loc, err := requestProfile(r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	query := r.URL.Query()
+	from, to, err := db.ParseDayRange(query.Get("from"), query.Get("to"), time.Now().In(loc), 7)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	todos, err := store(r).GetAllTodos()
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	holidays := map[string]bool{}
+	for _, day := range config.Cfg.Holidays {
+		holidays[day] = true
+	}
+	workload, err := db.ComputeDailyWorkload(todos, profile.WorkSchedule, holidays, from, to)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	json.NewEncoder(w).Encode(workload)
+}
@@ -0,0 +1,35 @@
+package api
+
+import (
+	"encoding/json"
+	"fydeos/db"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+// GetCurrentWork 返回当前工作时段（按用户的工作时间和时区）内排期的待办事项；
+// 不在工作时间内时返回空列表，any_time=true时仍返回当天工作时段内的排期
+func GetCurrentWork(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	anyTime, _ := strconv.ParseBool(r.URL.Query().Get("any_time"))
+
+	profile, loc, err := requestProfile(r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	todos, err := store(r).GetAllTodos()
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	work, err := db.FindCurrentWork(todos, profile.WorkSchedule, time.Now().In(loc), anyTime)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	json.NewEncoder(w).Encode(work)
+}
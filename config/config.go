@@ -0,0 +1,573 @@
+package config
+
+import (
+	"log"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Config 服务运行配置，启动时从环境变量加载
+type Config struct {
+	StaleNudge     StaleNudgeConfig
+	Digest         DigestConfig
+	Optimize       OptimizeConfig
+	Attention      AttentionConfig
+	Search         SearchConfig
+	DefaultDue     DefaultDueConfig
+	Overdue        OverdueConfig
+	Notify         NotifyConfig
+	MCP            MCPConfig
+	Backup         BackupConfig
+	Sort           SortConfig
+	List           ListConfig
+	ProjectArchive ProjectArchiveConfig
+	AutoClose      AutoCloseConfig
+	Recurrence     RecurrenceConfig
+	SLA            SLAConfig
+	Reminder       ReminderConfig
+	Limits         LimitsConfig
+	Flags          FlagsConfig
+	DB             DBConfig
+	Import         ImportConfig
+	Plan           PlanConfig
+	// Holidays 不安排任务的节假日（YYYY-MM-DD）
+	Holidays []string
+	// StarredFirst 列表中是否将星标任务置顶
+	StarredFirst bool
+	// AutoStatus 更新时未显式修改状态的，按清单进度自动设置状态
+	AutoStatus bool
+	// IncompleteMetadataFields 缺失时视为元数据不完整的字段
+	IncompleteMetadataFields []string
+	// AutoTags 按类别或项目自动添加标签的规则
+	AutoTags []AutoTagRule
+	// SubtaskCompletion 完成仍有未完成子任务的待办事项时的处理方式：block、complete、warn
+	SubtaskCompletion string
+	// InboxCategory 只有标题的快速收集任务默认放入的类别
+	InboxCategory string
+	// ServerLocation 请求和用户资料都未指定时区时使用的服务器时区
+	ServerLocation *time.Location
+	// DefaultEstimates 创建时未指定预计耗时的待办事项按优先级使用的默认值，未配置的优先级不设置
+	DefaultEstimates map[string]time.Duration
+}
+
+// AutoTagRule 类别或项目为Value的待办事项自动带上标签Tag
+type AutoTagRule struct {
+	Field string // category 或 project（项目ID）
+	Value string
+	Tag   string
+}
+
+// StaleNudgeConfig 陈旧任务自动提醒配置
+type StaleNudgeConfig struct {
+	Enabled   bool
+	Threshold time.Duration // 超过该时长未更新视为陈旧
+	Interval  time.Duration // 扫描间隔
+	Cooldown  time.Duration // 同一任务两次提醒的最小间隔
+	Action    string        // notify: 仅提醒; lower_priority: 提醒并降低优先级
+}
+
+// DigestConfig 每日议程推送配置
+type DigestConfig struct {
+	Enabled    bool
+	WebhookURL string        // 为空时仅写入日志
+	Time       string        // 本地推送时间HH:MM，为空时使用用户的上班时间
+	Interval   time.Duration // 检查间隔
+}
+
+// OptimizeConfig 日程优化配置
+type OptimizeConfig struct {
+	Limit int // 最多选出的任务数量
+}
+
+// AttentionConfig 各优先级的"需要关注"窗口：截止日期在窗口内的未完成任务需要关注
+// 未配置窗口的优先级不会被标记
+type AttentionConfig struct {
+	Windows map[string]time.Duration
+}
+
+// SearchConfig 搜索配置
+type SearchConfig struct {
+	MaxDistance int // 模糊搜索允许的最大编辑距离
+}
+
+// DefaultDueConfig 创建时未指定截止日期的待办事项的默认截止日期
+type DefaultDueConfig struct {
+	Mode string // off: 不设置; end_of_week: 本工作周最后一个工作日下班时; days: 当前时间加Days天
+	Days int
+}
+
+// OverdueConfig 过期判定配置
+type OverdueConfig struct {
+	Grace time.Duration // 超过截止日期该时长后才视为过期
+}
+
+// NotifyConfig 通知渠道配置，提醒、议程等通知通过所有启用的渠道投递
+type NotifyConfig struct {
+	Channels   []string // log, webhook, sse
+	WebhookURL string
+	// webhook渠道异步投递：最多同时进行的请求数、排队上限，以及队列满时丢弃（drop）还是阻塞（block）
+	WebhookWorkers   int
+	WebhookQueueSize int
+	WebhookOverflow  string
+}
+
+// MCPConfig MCP SSE服务器配置
+type MCPConfig struct {
+	Addr          string
+	PortFallback  int      // 端口被占用时依次尝试的后续端口数，0表示不尝试
+	DisabledTools []string // 禁用的工具名，不出现在工具列表中，调用时返回错误
+}
+
+// BackupConfig 数据库自动备份配置
+type BackupConfig struct {
+	Enabled   bool
+	Interval  time.Duration
+	Dir       string
+	Retention int // 保留的备份文件数量
+}
+
+// SortConfig 待办事项排序配置
+type SortConfig struct {
+	Strategy string // priority_first: 优先级优先; due_first: 截止日期优先
+}
+
+// ListConfig 列表接口配置
+type ListConfig struct {
+	WarnThreshold int // 结果数量超过该值时在响应头中提示客户端
+}
+
+// ProjectArchiveConfig 项目自动归档配置
+type ProjectArchiveConfig struct {
+	Enabled  bool
+	After    time.Duration // 所有待办事项完成且超过该时长未更新的项目会被归档
+	Interval time.Duration // 检查间隔
+}
+
+// SLAConfig 各优先级的SLA：从创建起在该工作时长内完成，未配置的优先级不检查
+type SLAConfig struct {
+	Windows       map[string]time.Duration
+	AtRiskPercent int // 已用去SLA的该百分比后标记为即将违反
+}
+
+// AutoCloseConfig 长期陈旧的低优先级任务自动关闭配置，关闭前先发送一次警告
+type AutoCloseConfig struct {
+	Enabled    bool
+	After      time.Duration // 超过该时长未更新才会被警告并关闭
+	Interval   time.Duration // 扫描间隔，警告后的下一次扫描才会关闭
+	Action     string        // complete: 标记为已完成; delete: 删除（子任务挂到其父任务下）
+	Priorities []string      // 参与自动关闭的优先级
+}
+
+// RecurrenceConfig 重复待办事项完成后生成下一次实例的配置
+type RecurrenceConfig struct {
+	Enabled  bool
+	Interval time.Duration // 扫描间隔
+	Lookback time.Duration // 只为该时长内完成的任务生成下一次实例，避免为历史上已结束的重复任务补建
+}
+
+// ReminderConfig 截止前提醒配置，提醒时间由各待办事项的remind_before_minutes设置
+type ReminderConfig struct {
+	Enabled  bool
+	Interval time.Duration // 扫描间隔
+	// DefaultOffsets 各优先级的默认提前量，有截止日期但未设置remind_before的待办事项使用
+	DefaultOffsets map[string]time.Duration
+}
+
+// ImportConfig 导入数据时的去重配置
+type ImportConfig struct {
+	Dedup     bool
+	DedupKeys []string // 判断重复的字段
+	// OnStart 启动时合并导入的数据文件路径或http(s)地址，为空时不导入
+	OnStart string
+}
+
+// DBConfig 数据库写操作遇到SQLITE_BUSY/SQLITE_LOCKED时的重试配置
+type DBConfig struct {
+	BusyAttempts int           // 最多尝试次数（含首次）
+	BusyBackoff  time.Duration // 首次重试前的等待时间，之后每次翻倍
+}
+
+// FlagsConfig 过期/陈旧冗余标记的刷新配置，陈旧阈值沿用STALE_NUDGE_THRESHOLD
+type FlagsConfig struct {
+	Interval time.Duration
+}
+
+// PlanConfig 按精力安排日计划的配置
+type PlanConfig struct {
+	DefaultDuration time.Duration // 没有预计耗时的任务按该时长安排
+	BreakAfter      time.Duration // 连续工作该时长后插入休息
+	BreakLength     time.Duration // 每次休息的时长
+}
+
+// LimitsConfig 文本字段长度上限（按字符计）
+type LimitsConfig struct {
+	MinTitle       int // 去掉首尾空白后的最小字符数
+	MaxTitle       int
+	MaxDescription int
+	Mode           string // reject: 超长时校验失败; truncate: 截断到上限
+}
+
+// 全局配置实例
+var Cfg = Default()
+
+// Default 返回默认配置
+func Default() *Config {
+	return &Config{
+		StaleNudge: StaleNudgeConfig{
+			Enabled:   false,
+			Threshold: 30 * 24 * time.Hour,
+			Interval:  time.Hour,
+			Cooldown:  7 * 24 * time.Hour,
+			Action:    "notify",
+		},
+		Digest: DigestConfig{
+			Enabled:  false,
+			Interval: time.Minute,
+		},
+		Optimize: OptimizeConfig{
+			Limit: 10,
+		},
+		Attention: AttentionConfig{
+			Windows: map[string]time.Duration{
+				"urgent": 24 * time.Hour,
+				"high":   3 * 24 * time.Hour,
+				"medium": 7 * 24 * time.Hour,
+			},
+		},
+		Search: SearchConfig{
+			MaxDistance: 2,
+		},
+		DefaultEstimates: map[string]time.Duration{},
+		DefaultDue: DefaultDueConfig{
+			Mode: "off",
+			Days: 7,
+		},
+		Overdue: OverdueConfig{
+			Grace: 0,
+		},
+		Notify: NotifyConfig{
+			Channels:         []string{"log"},
+			WebhookWorkers:   4,
+			WebhookQueueSize: 100,
+			WebhookOverflow:  "drop",
+		},
+		MCP: MCPConfig{
+			Addr:         "localhost:8082",
+			PortFallback: 0,
+		},
+		Backup: BackupConfig{
+			Enabled:   false,
+			Interval:  24 * time.Hour,
+			Dir:       "./backups",
+			Retention: 7,
+		},
+		Sort: SortConfig{
+			Strategy: "priority_first",
+		},
+		List: ListConfig{
+			WarnThreshold: 500,
+		},
+		ProjectArchive: ProjectArchiveConfig{
+			Enabled:  false,
+			After:    30 * 24 * time.Hour,
+			Interval: time.Hour,
+		},
+		Recurrence: RecurrenceConfig{
+			Enabled:  true,
+			Interval: time.Minute,
+			Lookback: 7 * 24 * time.Hour,
+		},
+		AutoClose: AutoCloseConfig{
+			Enabled:    false,
+			After:      90 * 24 * time.Hour,
+			Interval:   24 * time.Hour,
+			Action:     "complete",
+			Priorities: []string{"low"},
+		},
+		SLA: SLAConfig{
+			Windows: map[string]time.Duration{
+				"urgent": 4 * time.Hour,
+				"high":   16 * time.Hour,
+				"medium": 40 * time.Hour,
+			},
+			AtRiskPercent: 75,
+		},
+		Reminder: ReminderConfig{
+			Enabled:  false,
+			Interval: time.Minute,
+			DefaultOffsets: map[string]time.Duration{
+				"urgent": 24 * time.Hour,
+				"high":   2 * time.Hour,
+			},
+		},
+		Import: ImportConfig{
+			DedupKeys: []string{"title", "due_date"},
+		},
+		DB: DBConfig{
+			BusyAttempts: 3,
+			BusyBackoff:  50 * time.Millisecond,
+		},
+		Flags: FlagsConfig{
+			Interval: time.Minute,
+		},
+		Plan: PlanConfig{
+			DefaultDuration: 30 * time.Minute,
+			BreakAfter:      90 * time.Minute,
+			BreakLength:     15 * time.Minute,
+		},
+		Limits: LimitsConfig{
+			MinTitle:       1,
+			MaxTitle:       200,
+			MaxDescription: 5000,
+			Mode:           "reject",
+		},
+		StarredFirst:             true,
+		AutoStatus:               true,
+		IncompleteMetadataFields: []string{"due_date", "estimated_duration"},
+		SubtaskCompletion:        "warn",
+		InboxCategory:            "inbox",
+		ServerLocation:           time.UTC,
+	}
+}
+
+// Load 从环境变量加载配置并设置为全局配置
+func Load() *Config {
+	cfg := Default()
+
+	cfg.StaleNudge.Enabled = getBool("STALE_NUDGE_ENABLED", cfg.StaleNudge.Enabled)
+	cfg.StaleNudge.Threshold = getDuration("STALE_NUDGE_THRESHOLD", cfg.StaleNudge.Threshold)
+	cfg.StaleNudge.Interval = getDuration("STALE_NUDGE_INTERVAL", cfg.StaleNudge.Interval)
+	cfg.StaleNudge.Cooldown = getDuration("STALE_NUDGE_COOLDOWN", cfg.StaleNudge.Cooldown)
+	cfg.StaleNudge.Action = getEnum("STALE_NUDGE_ACTION", cfg.StaleNudge.Action, "notify", "lower_priority")
+
+	cfg.Digest.Enabled = getBool("DIGEST_ENABLED", cfg.Digest.Enabled)
+	cfg.Digest.WebhookURL = os.Getenv("DIGEST_WEBHOOK_URL")
+	cfg.Digest.Time = os.Getenv("DIGEST_TIME")
+	cfg.Digest.Interval = getDuration("DIGEST_INTERVAL", cfg.Digest.Interval)
+
+	cfg.Optimize.Limit = getInt("OPTIMIZE_SCHEDULE_LIMIT", cfg.Optimize.Limit)
+
+	for _, priority := range []string{"urgent", "high", "medium", "low"} {
+		key := "ATTENTION_WINDOW_" + strings.ToUpper(priority)
+		if w := getDuration(key, cfg.Attention.Windows[priority]); w > 0 {
+			cfg.Attention.Windows[priority] = w
+		}
+	}
+
+	cfg.Search.MaxDistance = getInt("SEARCH_FUZZY_MAX_DISTANCE", cfg.Search.MaxDistance)
+
+	cfg.DefaultDue.Mode = getEnum("DEFAULT_DUE_MODE", cfg.DefaultDue.Mode, "off", "end_of_week", "days")
+	cfg.DefaultDue.Days = getInt("DEFAULT_DUE_DAYS", cfg.DefaultDue.Days)
+	for _, priority := range []string{"urgent", "high", "medium", "low"} {
+		if d := getDuration("DEFAULT_ESTIMATE_"+strings.ToUpper(priority), 0); d > 0 {
+			cfg.DefaultEstimates[priority] = d
+		}
+	}
+
+	cfg.Overdue.Grace = getDuration("OVERDUE_GRACE", cfg.Overdue.Grace)
+
+	cfg.Notify.Channels = getList("NOTIFY_CHANNELS", cfg.Notify.Channels, "log", "webhook", "sse")
+	cfg.Notify.WebhookURL = os.Getenv("NOTIFY_WEBHOOK_URL")
+	cfg.Notify.WebhookWorkers = getInt("NOTIFY_WEBHOOK_WORKERS", cfg.Notify.WebhookWorkers)
+	cfg.Notify.WebhookQueueSize = getInt("NOTIFY_WEBHOOK_QUEUE_SIZE", cfg.Notify.WebhookQueueSize)
+	cfg.Notify.WebhookOverflow = getEnum("NOTIFY_WEBHOOK_OVERFLOW", cfg.Notify.WebhookOverflow, "drop", "block")
+
+	cfg.StarredFirst = getBool("STARRED_FIRST", cfg.StarredFirst)
+	cfg.AutoStatus = getBool("AUTO_STATUS_FROM_PROGRESS", cfg.AutoStatus)
+	cfg.IncompleteMetadataFields = getList("INCOMPLETE_METADATA_FIELDS", cfg.IncompleteMetadataFields, "due_date", "estimated_duration", "description")
+	cfg.List.WarnThreshold = getInt("LIST_WARN_THRESHOLD", cfg.List.WarnThreshold)
+	cfg.Sort.Strategy = getEnum("SORT_STRATEGY", cfg.Sort.Strategy, "priority_first", "due_first")
+
+	if v := os.Getenv("MCP_SSE_ADDR"); v != "" {
+		cfg.MCP.Addr = v
+	}
+	cfg.MCP.PortFallback = getInt("MCP_SSE_PORT_FALLBACK", cfg.MCP.PortFallback)
+	cfg.MCP.DisabledTools = getList("MCP_DISABLED_TOOLS", cfg.MCP.DisabledTools)
+
+	cfg.Backup.Enabled = getBool("BACKUP_ENABLED", cfg.Backup.Enabled)
+	cfg.Backup.Interval = getDuration("BACKUP_INTERVAL", cfg.Backup.Interval)
+	if v := os.Getenv("BACKUP_DIR"); v != "" {
+		cfg.Backup.Dir = v
+	}
+	cfg.Backup.Retention = getInt("BACKUP_RETENTION", cfg.Backup.Retention)
+
+	cfg.ProjectArchive.Enabled = getBool("PROJECT_ARCHIVE_ENABLED", cfg.ProjectArchive.Enabled)
+	cfg.ProjectArchive.After = getDuration("PROJECT_ARCHIVE_AFTER", cfg.ProjectArchive.After)
+	cfg.ProjectArchive.Interval = getDuration("PROJECT_ARCHIVE_INTERVAL", cfg.ProjectArchive.Interval)
+
+	cfg.Recurrence.Enabled = getBool("RECURRENCE_ENABLED", cfg.Recurrence.Enabled)
+	cfg.Recurrence.Interval = getDuration("RECURRENCE_INTERVAL", cfg.Recurrence.Interval)
+	cfg.Recurrence.Lookback = getDuration("RECURRENCE_LOOKBACK", cfg.Recurrence.Lookback)
+
+	cfg.AutoClose.Enabled = getBool("AUTO_CLOSE_ENABLED", cfg.AutoClose.Enabled)
+	cfg.AutoClose.After = getDuration("AUTO_CLOSE_AFTER", cfg.AutoClose.After)
+	cfg.AutoClose.Interval = getDuration("AUTO_CLOSE_INTERVAL", cfg.AutoClose.Interval)
+	cfg.AutoClose.Action = getEnum("AUTO_CLOSE_ACTION", cfg.AutoClose.Action, "complete", "delete")
+	cfg.AutoClose.Priorities = getList("AUTO_CLOSE_PRIORITIES", cfg.AutoClose.Priorities, "urgent", "high", "medium", "low")
+
+	for _, priority := range []string{"urgent", "high", "medium", "low"} {
+		key := "SLA_" + strings.ToUpper(priority)
+		if w := getDuration(key, cfg.SLA.Windows[priority]); w > 0 {
+			cfg.SLA.Windows[priority] = w
+		}
+	}
+	cfg.SLA.AtRiskPercent = getInt("SLA_AT_RISK_PERCENT", cfg.SLA.AtRiskPercent)
+	if cfg.SLA.AtRiskPercent > 100 {
+		cfg.SLA.AtRiskPercent = 100
+	}
+
+	for _, day := range getList("HOLIDAYS", nil) {
+		if _, err := time.Parse("2006-01-02", day); err != nil {
+			log.Printf("Warning: ignoring invalid HOLIDAYS value %q", day)
+			continue
+		}
+		cfg.Holidays = append(cfg.Holidays, day)
+	}
+
+	// 格式：category:work=office,project:3=website
+	for _, item := range getList("AUTO_TAGS", nil) {
+		match, tag, ok := strings.Cut(item, "=")
+		field, value, ok2 := strings.Cut(match, ":")
+		field, value, tag = strings.TrimSpace(field), strings.TrimSpace(value), strings.TrimSpace(tag)
+		if !ok || !ok2 || (field != "category" && field != "project") || value == "" || tag == "" {
+			log.Printf("Warning: ignoring invalid AUTO_TAGS value %q", item)
+			continue
+		}
+		cfg.AutoTags = append(cfg.AutoTags, AutoTagRule{Field: field, Value: value, Tag: strings.ToLower(tag)})
+	}
+
+	cfg.Import.Dedup = getBool("IMPORT_DEDUP", cfg.Import.Dedup)
+	cfg.Import.OnStart = strings.TrimSpace(os.Getenv("IMPORT_ON_START"))
+	cfg.Import.DedupKeys = getList("IMPORT_DEDUP_KEYS", cfg.Import.DedupKeys, "title", "due_date", "category", "description")
+
+	cfg.DB.BusyAttempts = getInt("DB_BUSY_ATTEMPTS", cfg.DB.BusyAttempts)
+	cfg.DB.BusyBackoff = getDuration("DB_BUSY_BACKOFF", cfg.DB.BusyBackoff)
+
+	cfg.Flags.Interval = getDuration("FLAG_REFRESH_INTERVAL", cfg.Flags.Interval)
+
+	cfg.Plan.DefaultDuration = getDuration("PLAN_DEFAULT_DURATION", cfg.Plan.DefaultDuration)
+	cfg.Plan.BreakAfter = getDuration("PLAN_BREAK_AFTER", cfg.Plan.BreakAfter)
+	cfg.Plan.BreakLength = getDuration("PLAN_BREAK_LENGTH", cfg.Plan.BreakLength)
+
+	cfg.Limits.MinTitle = getInt("MIN_TITLE_LENGTH", cfg.Limits.MinTitle)
+	cfg.Limits.MaxTitle = getInt("MAX_TITLE_LENGTH", cfg.Limits.MaxTitle)
+	cfg.Limits.MaxDescription = getInt("MAX_DESCRIPTION_LENGTH", cfg.Limits.MaxDescription)
+	cfg.Limits.Mode = getEnum("LENGTH_LIMIT_MODE", cfg.Limits.Mode, "reject", "truncate")
+
+	cfg.SubtaskCompletion = getEnum("SUBTASK_COMPLETION_MODE", cfg.SubtaskCompletion, "block", "complete", "warn")
+	if v := strings.TrimSpace(os.Getenv("INBOX_CATEGORY")); v != "" {
+		cfg.InboxCategory = v
+	}
+	cfg.ServerLocation = getLocation("SERVER_TIMEZONE", cfg.ServerLocation)
+
+	cfg.Reminder.Enabled = getBool("REMINDERS_ENABLED", cfg.Reminder.Enabled)
+	cfg.Reminder.Interval = getDuration("REMINDER_INTERVAL", cfg.Reminder.Interval)
+	for _, priority := range []string{"urgent", "high", "medium", "low"} {
+		key := "REMINDER_DEFAULT_" + strings.ToUpper(priority)
+		if v := strings.TrimSpace(os.Getenv(key)); v == "0" || v == "off" {
+			delete(cfg.Reminder.DefaultOffsets, priority)
+		} else if d := getDuration(key, cfg.Reminder.DefaultOffsets[priority]); d > 0 {
+			cfg.Reminder.DefaultOffsets[priority] = d
+		}
+	}
+
+	Cfg = cfg
+	return cfg
+}
+
+func getBool(key string, def bool) bool {
+	v := os.Getenv(key)
+	if v == "" {
+		return def
+	}
+	b, err := strconv.ParseBool(v)
+	if err != nil {
+		log.Printf("Warning: invalid %s=%q, using default %v", key, v, def)
+		return def
+	}
+	return b
+}
+
+func getInt(key string, def int) int {
+	v := os.Getenv(key)
+	if v == "" {
+		return def
+	}
+	n, err := strconv.Atoi(v)
+	if err != nil || n <= 0 {
+		log.Printf("Warning: invalid %s=%q, using default %d", key, v, def)
+		return def
+	}
+	return n
+}
+
+func getDuration(key string, def time.Duration) time.Duration {
+	v := os.Getenv(key)
+	if v == "" {
+		return def
+	}
+	d, err := time.ParseDuration(v)
+	if err != nil || d <= 0 {
+		log.Printf("Warning: invalid %s=%q, using default %v", key, v, def)
+		return def
+	}
+	return d
+}
+
+// getList 解析逗号分隔的列表；指定allowed时忽略不在其中的值
+func getList(key string, def []string, allowed ...string) []string {
+	v := strings.TrimSpace(os.Getenv(key))
+	if v == "" {
+		return def
+	}
+
+	var list []string
+	for _, item := range strings.Split(v, ",") {
+		item = strings.TrimSpace(item)
+		if item == "" {
+			continue
+		}
+		valid := len(allowed) == 0
+		for _, a := range allowed {
+			if item == a {
+				valid = true
+				break
+			}
+		}
+		if !valid {
+			log.Printf("Warning: ignoring invalid %s value %q", key, item)
+			continue
+		}
+		list = append(list, item)
+	}
+	return list
+}
+
+func getLocation(key string, def *time.Location) *time.Location {
+	v := strings.TrimSpace(os.Getenv(key))
+	if v == "" {
+		return def
+	}
+	loc, err := time.LoadLocation(v)
+	if err != nil {
+		log.Printf("Warning: invalid %s=%q, using default %v", key, v, def)
+		return def
+	}
+	return loc
+}
+
+func getEnum(key string, def string, allowed ...string) string {
+	v := strings.TrimSpace(os.Getenv(key))
+	if v == "" {
+		return def
+	}
+	for _, a := range allowed {
+		if v == a {
+			return v
+		}
+	}
+	log.Printf("Warning: invalid %s=%q, using default %q", key, v, def)
+	return def
+}
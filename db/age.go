@@ -0,0 +1,58 @@
+package db
+
+import "time"
+
+// AgeBucket 创建时长在某个区间内的未完成待办事项数量
+type AgeBucket struct {
+	Label string `json:"label"`
+	Count int    `json:"count"`
+}
+
+// AgeDistribution 未完成待办事项按创建时长的分布
+type AgeDistribution struct {
+	Total   int         `json:"total"`
+	Buckets []AgeBucket `json:"buckets"`
+	// 创建最早的未完成待办事项，没有未完成的待办事项时为空
+	Oldest *Todo `json:"oldest"`
+}
+
+// ageBuckets 各桶的上限（不含），最后一个桶没有上限
+var ageBuckets = []struct {
+	label string
+	max   time.Duration
+}{
+	{"<1d", 24 * time.Hour},
+	{"1-7d", 7 * 24 * time.Hour},
+	{"7-30d", 30 * 24 * time.Hour},
+	{"30-90d", 90 * 24 * time.Hour},
+	{">90d", 0},
+}
+
+// ComputeAgeDistribution 按创建至now的时长统计未完成待办事项的分布
+func ComputeAgeDistribution(todos []Todo, now time.Time) AgeDistribution {
+	dist := AgeDistribution{Buckets: make([]AgeBucket, len(ageBuckets))}
+	for i, b := range ageBuckets {
+		dist.Buckets[i].Label = b.label
+	}
+
+	for _, todo := range todos {
+		if todo.Status == "completed" {
+			continue
+		}
+		dist.Total++
+
+		age := now.Sub(todo.CreatedDate)
+		for i, b := range ageBuckets {
+			if b.max == 0 || age < b.max {
+				dist.Buckets[i].Count++
+				break
+			}
+		}
+
+		if dist.Oldest == nil || todo.CreatedDate.Before(dist.Oldest.CreatedDate) {
+			oldest := todo
+			dist.Oldest = &oldest
+		}
+	}
+	return dist
+}
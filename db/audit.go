@@ -0,0 +1,122 @@
+package db
+
+import (
+	"encoding/json"
+	"fmt"
+	"time"
+)
+
+// 审计日志表：每次更新待办事项时记录修改前后的快照
+const auditTable = `CREATE TABLE IF NOT EXISTS todo_audit (
+	id INTEGER PRIMARY KEY AUTOINCREMENT,
+	todo_id INTEGER NOT NULL,
+	changed_at TIMESTAMP NOT NULL,
+	before_json TEXT NOT NULL,
+	after_json TEXT NOT NULL
+);`
+
+// FieldChange 字段在某一时刻的取值
+type FieldChange struct {
+	ChangedAt time.Time   `json:"changed_at"`
+	Value     interface{} `json:"value"`
+}
+
+// recordAudit 写入一条修改前后的快照
+func (d *SQLiteDatabase) recordAudit(before, after *Todo, at time.Time) error {
+	beforeJSON, err := json.Marshal(before)
+	if err != nil {
+		return fmt.Errorf("failed to marshal audit snapshot: %v", err)
+	}
+	afterJSON, err := json.Marshal(after)
+	if err != nil {
+		return fmt.Errorf("failed to marshal audit snapshot: %v", err)
+	}
+
+	_, err = d.db.Exec(
+		"INSERT INTO todo_audit (todo_id, changed_at, before_json, after_json) VALUES (?, ?, ?, ?)",
+		after.ID, at, string(beforeJSON), string(afterJSON),
+	)
+	if err != nil {
+		return fmt.Errorf("failed to record audit: %v", err)
+	}
+	return nil
+}
+
+// auditEntry 审计日志中的一条快照
+type auditEntry struct {
+	ChangedAt time.Time
+	Before    map[string]interface{}
+	After     map[string]interface{}
+}
+
+// GetFieldHistory 从审计快照中提取待办事项某个字段（JSON字段名）的取值时间线。
+// 第一项为首次修改前的取值，之后只包含该字段实际发生变化的时刻
+func (d *SQLiteDatabase) GetFieldHistory(id int, field string) ([]FieldChange, error) {
+	todo, err := d.GetTodoByID(id)
+	if err != nil {
+		return nil, err
+	}
+	current, err := toFieldMap(todo)
+	if err != nil {
+		return nil, err
+	}
+	if _, ok := current[field]; !ok {
+		return nil, fmt.Errorf("unknown field %q", field)
+	}
+
+	rows, err := d.db.Query("SELECT changed_at, before_json, after_json FROM todo_audit WHERE todo_id = ? ORDER BY changed_at, id", id)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query audit log: %v", err)
+	}
+	defer rows.Close()
+
+	var entries []auditEntry
+	for rows.Next() {
+		var entry auditEntry
+		var before, after string
+		if err := rows.Scan(&entry.ChangedAt, &before, &after); err != nil {
+			return nil, fmt.Errorf("failed to scan audit entry: %v", err)
+		}
+		if err := json.Unmarshal([]byte(before), &entry.Before); err != nil {
+			return nil, fmt.Errorf("failed to parse audit snapshot: %v", err)
+		}
+		if err := json.Unmarshal([]byte(after), &entry.After); err != nil {
+			return nil, fmt.Errorf("failed to parse audit snapshot: %v", err)
+		}
+		entries = append(entries, entry)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating audit log: %v", err)
+	}
+
+	return fieldTimeline(entries, field, todo.CreatedDate, current[field]), nil
+}
+
+// fieldTimeline 根据审计快照生成字段取值时间线；没有审计记录时只返回当前值
+func fieldTimeline(entries []auditEntry, field string, created time.Time, current interface{}) []FieldChange {
+	if len(entries) == 0 {
+		return []FieldChange{{ChangedAt: created, Value: current}}
+	}
+
+	timeline := []FieldChange{{ChangedAt: created, Value: entries[0].Before[field]}}
+	for _, entry := range entries {
+		value := entry.After[field]
+		if fmt.Sprint(value) == fmt.Sprint(timeline[len(timeline)-1].Value) {
+			continue
+		}
+		timeline = append(timeline, FieldChange{ChangedAt: entry.ChangedAt, Value: value})
+	}
+	return timeline
+}
+
+func toFieldMap(todo *Todo) (map[string]interface{}, error) {
+	data, err := json.Marshal(todo)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal todo: %v", err)
+	}
+	fields := map[string]interface{}{}
+	if err := json.Unmarshal(data, &fields); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal todo: %v", err)
+	}
+	return fields, nil
+}
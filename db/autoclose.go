@@ -0,0 +1,76 @@
+package db
+
+import (
+	"fmt"
+	"strings"
+	"time"
+)
+
+// autoCloseScope 未完成、在staleBefore之前最后更新且优先级在priorities中的待办事项
+func autoCloseScope(staleBefore time.Time, priorities []string) (string, []interface{}) {
+	args := []interface{}{staleBefore}
+	placeholders := make([]string, len(priorities))
+	for i, p := range priorities {
+		placeholders[i] = "?"
+		args = append(args, p)
+	}
+	where := "status != 'completed' AND last_updated < ? AND priority IN (" + strings.Join(placeholders, ", ") + ")"
+	return where, args
+}
+
+// GetAutoCloseCandidates 获取尚未警告、即将被自动关闭的陈旧任务
+func (d *SQLiteDatabase) GetAutoCloseCandidates(staleBefore time.Time, priorities []string) ([]Todo, error) {
+	if len(priorities) == 0 {
+		return nil, nil
+	}
+	where, args := autoCloseScope(staleBefore, priorities)
+	return d.queryTodos(
+		"SELECT "+todoColumns+" FROM todos WHERE "+where+" AND auto_close_warned_at IS NULL ORDER BY last_updated",
+		args...,
+	)
+}
+
+// GetAutoCloseWarned 获取在warnedBefore之前已警告、此后仍未更新的陈旧任务
+func (d *SQLiteDatabase) GetAutoCloseWarned(staleBefore, warnedBefore time.Time, priorities []string) ([]Todo, error) {
+	if len(priorities) == 0 {
+		return nil, nil
+	}
+	where, args := autoCloseScope(staleBefore, priorities)
+	args = append(args, warnedBefore)
+	return d.queryTodos(
+		"SELECT "+todoColumns+" FROM todos WHERE "+where+" AND auto_close_warned_at IS NOT NULL AND auto_close_warned_at < ? ORDER BY last_updated",
+		args...,
+	)
+}
+
+// MarkAutoCloseWarned 记录自动关闭警告的发送时间
+// 不修改last_updated，避免警告本身重置任务的陈旧状态
+func (d *SQLiteDatabase) MarkAutoCloseWarned(id int, at time.Time) error {
+	result, err := d.db.Exec("UPDATE todos SET auto_close_warned_at = ? WHERE id = ?", at, id)
+	if err != nil {
+		return fmt.Errorf("failed to mark todo as auto-close warned: %v", err)
+	}
+
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("error checking affected rows: %v", err)
+	}
+
+	if affected == 0 {
+		return fmt.Errorf("todo with ID %d not found", id)
+	}
+
+	return nil
+}
+
+// ResetAutoCloseWarnings 清除警告后又被修改或已完成的待办事项的警告标记，
+// 它们需要重新陈旧并再次警告后才会被自动关闭
+func (d *SQLiteDatabase) ResetAutoCloseWarnings() error {
+	_, err := d.db.Exec(
+		"UPDATE todos SET auto_close_warned_at = NULL WHERE auto_close_warned_at IS NOT NULL AND (status = 'completed' OR last_updated > auto_close_warned_at)",
+	)
+	if err != nil {
+		return fmt.Errorf("failed to reset auto-close warnings: %v", err)
+	}
+	return nil
+}
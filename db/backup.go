@@ -0,0 +1,11 @@
+package db
+
+import "fmt"
+
+// BackupTo 使用VACUUM INTO将当前数据库在线备份到path，path必须不存在
+func (d *SQLiteDatabase) BackupTo(path string) error {
+	if _, err := d.db.Exec("VACUUM INTO ?", path); err != nil {
+		return fmt.Errorf("failed to back up database: %v", err)
+	}
+	return nil
+}
@@ -0,0 +1,108 @@
+package db
+
+import (
+	"fmt"
+	"sort"
+	"time"
+)
+
+// breakdownPhase 拆分任务时使用的阶段，weight为该阶段占预计耗时的相对比例，
+// rank越小越优先保留：请求的子任务较少时只保留rank靠前的阶段，并按阶段原有顺序排列
+type breakdownPhase struct {
+	name   string
+	weight int
+	rank   int
+}
+
+var breakdownPhases = []breakdownPhase{
+	{"Research", 1, 5},
+	{"Plan", 1, 2},
+	{"Prepare", 1, 6},
+	{"Implement", 4, 1},
+	{"Refine", 2, 7},
+	{"Test", 2, 4},
+	{"Review", 1, 3},
+	{"Wrap up", 1, 8},
+}
+
+const (
+	// MinBreakdownSubtasks/MaxBreakdownSubtasks break_down_task允许的子任务数量范围
+	MinBreakdownSubtasks = 2
+	MaxBreakdownSubtasks = 8
+	// DefaultBreakdownSubtasks 未指定数量时生成的子任务数
+	DefaultBreakdownSubtasks = 4
+)
+
+// BreakDownTodo 将parent拆分为count个按阶段命名的子任务（尚未保存）。子任务继承父任务的优先级和类别，
+// 父任务有预计耗时时按阶段比例分配，各子任务耗时（按分钟取整）之和等于父任务的预计耗时
+func BreakDownTodo(parent Todo, count int) ([]Todo, error) {
+	if count < MinBreakdownSubtasks || count > MaxBreakdownSubtasks {
+		return nil, fmt.Errorf("count must be between %d and %d", MinBreakdownSubtasks, MaxBreakdownSubtasks)
+	}
+
+	phases := make([]breakdownPhase, len(breakdownPhases))
+	copy(phases, breakdownPhases)
+	sort.SliceStable(phases, func(i, j int) bool { return phases[i].rank < phases[j].rank })
+	phases = phases[:count]
+	order := make(map[string]int, len(breakdownPhases))
+	for i, p := range breakdownPhases {
+		order[p.name] = i
+	}
+	sort.SliceStable(phases, func(i, j int) bool { return order[phases[i].name] < order[phases[j].name] })
+
+	weights := make([]int, count)
+	for i, p := range phases {
+		weights[i] = p.weight
+	}
+	total := int(ParseEstimatedDuration(parent.EstimatedDuration) / time.Minute)
+	if total > 0 && total < count {
+		return nil, fmt.Errorf("estimated duration %q is too short to split into %d subtasks", parent.EstimatedDuration, count)
+	}
+	minutes := splitMinutes(total, weights)
+
+	parentID := parent.ID
+	subtasks := make([]Todo, count)
+	for i, p := range phases {
+		subtasks[i] = Todo{
+			Title:    fmt.Sprintf("%s: %s", p.name, parent.Title),
+			Priority: parent.Priority,
+			Category: parent.Category,
+			Status:   "pending",
+			ParentID: &parentID,
+		}
+		if minutes != nil {
+			subtasks[i].EstimatedDuration = formatEstimate(time.Duration(minutes[i]) * time.Minute)
+		}
+	}
+	return subtasks, nil
+}
+
+// splitMinutes 按weights比例分配total分钟，使用最大余数法保证总和等于total；total不大于0时返回nil
+func splitMinutes(total int, weights []int) []int {
+	if total <= 0 {
+		return nil
+	}
+	sum := 0
+	for _, w := range weights {
+		sum += w
+	}
+
+	parts := make([]int, len(weights))
+	remainders := make([]int, len(weights))
+	assigned := 0
+	for i, w := range weights {
+		parts[i] = total * w / sum
+		remainders[i] = total * w % sum
+		assigned += parts[i]
+	}
+
+	idx := make([]int, len(weights))
+	for i := range idx {
+		idx[i] = i
+	}
+	sort.SliceStable(idx, func(a, b int) bool { return remainders[idx[a]] > remainders[idx[b]] })
+	for _, i := range idx[:total-assigned] {
+		parts[i]++
+	}
+	return parts
+}
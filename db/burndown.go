@@ -0,0 +1,57 @@
+package db
+
+import "time"
+
+// BurndownPoint 某一天结束时剩余的预计工作量
+type BurndownPoint struct {
+	Date             string `json:"date"`
+	RemainingMinutes int    `json:"remaining_minutes"`
+	RemainingTasks   int    `json:"remaining_tasks"`
+}
+
+// Burndown 燃尽图数据
+type Burndown struct {
+	From        time.Time       `json:"from"`
+	To          time.Time       `json:"to"`
+	Points      []BurndownPoint `json:"points"`
+	Unestimated int             `json:"unestimated"` // 预计耗时无法解析、按0计入的任务数
+}
+
+// ComputeBurndown 按天计算[from, to)内每天结束时（最后一天为to）尚未完成的任务数和预计耗时合计。
+// 任务从创建时起计入，完成后移出
+func ComputeBurndown(todos []Todo, from, to time.Time) Burndown {
+	result := Burndown{From: from, To: to, Points: []BurndownPoint{}}
+	for _, todo := range todos {
+		if ParseEstimatedDuration(todo.EstimatedDuration) == 0 {
+			result.Unestimated++
+		}
+	}
+
+	day := time.Date(from.Year(), from.Month(), from.Day(), 0, 0, 0, 0, from.Location())
+	for day.Before(to) {
+		cutoff := day.AddDate(0, 0, 1)
+		if cutoff.After(to) {
+			cutoff = to
+		}
+
+		point := BurndownPoint{Date: day.Format("2006-01-02")}
+		for _, todo := range todos {
+			if !todo.CreatedDate.Before(cutoff) || doneBy(todo, cutoff) {
+				continue
+			}
+			point.RemainingTasks++
+			point.RemainingMinutes += int(ParseEstimatedDuration(todo.EstimatedDuration).Minutes())
+		}
+		result.Points = append(result.Points, point)
+		day = day.AddDate(0, 0, 1)
+	}
+	return result
+}
+
+// doneBy 任务是否在t之前已完成
+func doneBy(todo Todo, t time.Time) bool {
+	if todo.Status != "completed" {
+		return false
+	}
+	return completedTime(todo).Before(t)
+}
@@ -0,0 +1,119 @@
+package db
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// ErrCategoryNotFound 要重命名的类别下没有待办事项
+var ErrCategoryNotFound = errors.New("category not found")
+
+// ErrCategoryExists 重命名的目标类别已存在且未指定合并
+type ErrCategoryExists struct {
+	Name  string
+	Count int // 目标类别下已有的待办事项数量
+}
+
+func (e *ErrCategoryExists) Error() string {
+	return fmt.Sprintf("category %q already exists with %d todos; set merge to combine them", e.Name, e.Count)
+}
+
+// RecategorizeByIDs 将指定ID的待办事项批量改为category，返回实际修改的数量
+func (d *SQLiteDatabase) RecategorizeByIDs(ids []int, category, source string) (int64, error) {
+	if len(ids) == 0 {
+		return 0, nil
+	}
+
+	placeholders := make([]string, len(ids))
+	args := []interface{}{category, time.Now(), source}
+	for i, id := range ids {
+		placeholders[i] = "?"
+		args = append(args, id)
+	}
+
+	result, err := d.db.Exec(
+		"UPDATE todos SET category = ?, last_updated = ?, source = ? WHERE id IN ("+strings.Join(placeholders, ", ")+")",
+		args...,
+	)
+	if err != nil {
+		return 0, fmt.Errorf("failed to recategorize todos: %v", err)
+	}
+
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return 0, fmt.Errorf("error checking affected rows: %v", err)
+	}
+	return affected, nil
+}
+
+// RenameCategory 将所有属于from类别的待办事项改为to，返回实际修改的数量
+func (d *SQLiteDatabase) RenameCategory(from, to, source string) (int64, error) {
+	result, err := d.db.Exec(
+		"UPDATE todos SET category = ?, last_updated = ?, source = ? WHERE category = ?",
+		to, time.Now(), source, from,
+	)
+	if err != nil {
+		return 0, fmt.Errorf("failed to rename category: %v", err)
+	}
+
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return 0, fmt.Errorf("error checking affected rows: %v", err)
+	}
+	return affected, nil
+}
+
+// RenameCategoryStrict 在一个事务中将from类别的所有待办事项改为to，返回修改的数量。
+// from下没有待办事项时返回ErrCategoryNotFound；to已存在时返回ErrCategoryExists，merge为true时合并到to
+func (d *SQLiteDatabase) RenameCategoryStrict(from, to string, merge bool, source string) (int64, error) {
+	var changed int64
+	// 整个事务失败时已回滚，busy时可以安全重试
+	err := withRetry(func() error {
+		var err error
+		changed, err = d.renameCategoryStrict(from, to, merge, source)
+		return err
+	})
+	return changed, err
+}
+
+func (d *SQLiteDatabase) renameCategoryStrict(from, to string, merge bool, source string) (int64, error) {
+	tx, err := d.db.Begin()
+	if err != nil {
+		return 0, fmt.Errorf("failed to begin transaction: %v", err)
+	}
+
+	var existing int
+	if err := tx.QueryRow("SELECT COUNT(*) FROM todos WHERE category = ?", to).Scan(&existing); err != nil {
+		tx.Rollback()
+		return 0, fmt.Errorf("failed to check category: %v", err)
+	}
+	if existing > 0 && !merge {
+		tx.Rollback()
+		return 0, &ErrCategoryExists{Name: to, Count: existing}
+	}
+
+	result, err := tx.Exec(
+		"UPDATE todos SET category = ?, last_updated = ?, source = ? WHERE category = ?",
+		to, time.Now(), source, from,
+	)
+	if err != nil {
+		tx.Rollback()
+		return 0, fmt.Errorf("failed to rename category: %v", err)
+	}
+	affected, err := result.RowsAffected()
+	if err != nil {
+		tx.Rollback()
+		return 0, fmt.Errorf("error checking affected rows: %v", err)
+	}
+	if affected == 0 {
+		tx.Rollback()
+		return 0, ErrCategoryNotFound
+	}
+
+	if err := tx.Commit(); err != nil {
+		return 0, fmt.Errorf("failed to commit transaction: %v", err)
+	}
+	return affected, nil
+}
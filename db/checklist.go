@@ -0,0 +1,98 @@
+package db
+
+import (
+	"database/sql/driver"
+	"encoding/json"
+	"fmt"
+)
+
+// ChecklistItem 待办事项内的清单项
+type ChecklistItem struct {
+	Text string `json:"text"`
+	Done bool   `json:"done"`
+}
+
+// Checklist 以JSON形式存储在todos.checklist列中
+type Checklist []ChecklistItem
+
+// Value 实现driver.Valuer，空清单存为NULL
+func (c Checklist) Value() (driver.Value, error) {
+	if len(c) == 0 {
+		return nil, nil
+	}
+	data, err := json.Marshal(c)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal checklist: %v", err)
+	}
+	return string(data), nil
+}
+
+// Progress 返回已完成清单项的比例，没有清单项时为0
+func (c Checklist) Progress() float64 {
+	if len(c) == 0 {
+		return 0
+	}
+	done := 0
+	for _, item := range c {
+		if item.Done {
+			done++
+		}
+	}
+	return float64(done) / float64(len(c))
+}
+
+// AddChecklistItem 在待办事项清单末尾添加一项
+func (d *SQLiteDatabase) AddChecklistItem(id int, text, source string) (*Todo, error) {
+	if text == "" {
+		return nil, fmt.Errorf("checklist item text is required")
+	}
+	return d.modifyChecklist(id, source, func(c Checklist) (Checklist, error) {
+		return append(c, ChecklistItem{Text: text}), nil
+	})
+}
+
+// SetChecklistItemDone 设置第index项（从0开始）的完成状态，done为nil时切换当前状态
+func (d *SQLiteDatabase) SetChecklistItemDone(id, index int, done *bool, source string) (*Todo, error) {
+	return d.modifyChecklist(id, source, func(c Checklist) (Checklist, error) {
+		if index < 0 || index >= len(c) {
+			return nil, fmt.Errorf("checklist item %d not found", index)
+		}
+		if done != nil {
+			c[index].Done = *done
+		} else {
+			c[index].Done = !c[index].Done
+		}
+		return c, nil
+	})
+}
+
+// RemoveChecklistItem 删除第index项（从0开始）
+func (d *SQLiteDatabase) RemoveChecklistItem(id, index int, source string) (*Todo, error) {
+	return d.modifyChecklist(id, source, func(c Checklist) (Checklist, error) {
+		if index < 0 || index >= len(c) {
+			return nil, fmt.Errorf("checklist item %d not found", index)
+		}
+		return append(c[:index], c[index+1:]...), nil
+	})
+}
+
+// modifyChecklist 读取待办事项清单，经fn修改后写回
+func (d *SQLiteDatabase) modifyChecklist(id int, source string, fn func(Checklist) (Checklist, error)) (*Todo, error) {
+	todo, err := d.GetTodoByID(id)
+	if err != nil {
+		return nil, err
+	}
+
+	checklist, err := fn(todo.Checklist)
+	if err != nil {
+		return nil, err
+	}
+
+	// 通过UpdateTodo保存，以便按进度自动更新状态并记录审计
+	todo.Checklist = checklist
+	todo.Source = source
+	if err := d.UpdateTodo(todo); err != nil {
+		return nil, fmt.Errorf("failed to update checklist: %v", err)
+	}
+	return todo, nil
+}
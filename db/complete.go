@@ -0,0 +1,111 @@
+package db
+
+import (
+	"fmt"
+	"log"
+	"time"
+)
+
+// CompleteFilter 批量完成的筛选条件，为空的条件不参与筛选，至少需要指定一个
+type CompleteFilter struct {
+	Status   string `json:"status"`
+	Priority string `json:"priority"`
+	Category string `json:"category"`
+	Tag      string `json:"tag"` // 手动或自动标签
+}
+
+// IsEmpty 是否没有指定任何条件
+func (f CompleteFilter) IsEmpty() bool {
+	return f.Status == "" && f.Priority == "" && f.Category == "" && f.Tag == ""
+}
+
+// Matches 待办事项是否满足所有指定的条件
+func (f CompleteFilter) Matches(todo Todo) bool {
+	return (f.Status == "" || todo.Status == f.Status) &&
+		(f.Priority == "" || todo.Priority == f.Priority) &&
+		(f.Category == "" || todo.Category == f.Category) &&
+		(f.Tag == "" || todo.HasTag(f.Tag))
+}
+
+// CompleteResult 批量完成的结果
+type CompleteResult struct {
+	Count int   `json:"count"`
+	IDs   []int `json:"ids"`
+	// Blocked block模式下因仍有未完成子任务而未完成的ID
+	Blocked []int `json:"blocked,omitempty"`
+	// WithIncompleteSubtasks warn模式下完成时仍有未完成子任务的ID
+	WithIncompleteSubtasks []int `json:"with_incomplete_subtasks,omitempty"`
+}
+
+// CompleteByFilter 在一个事务中将满足filter的未完成待办事项标记为completed并记录完成时间，
+// 离开scheduled状态的清除排期时间段；仍有未完成子任务的按SUBTASK_COMPLETION_MODE处理
+func (d *SQLiteDatabase) CompleteByFilter(filter CompleteFilter, source string) (*CompleteResult, error) {
+	if filter.IsEmpty() {
+		return nil, fmt.Errorf("at least one of status, priority, category or tag is required")
+	}
+
+	tx, err := d.db.Begin()
+	if err != nil {
+		return nil, fmt.Errorf("failed to begin transaction: %v", err)
+	}
+
+	rows, err := tx.Query("SELECT " + todoColumns + " FROM todos WHERE status != 'completed' ORDER BY id")
+	if err != nil {
+		tx.Rollback()
+		return nil, fmt.Errorf("failed to query todos: %v", err)
+	}
+	var open, matched []Todo
+	for rows.Next() {
+		todo, err := scanTodo(rows)
+		if err != nil {
+			rows.Close()
+			tx.Rollback()
+			return nil, fmt.Errorf("failed to scan todo: %v", err)
+		}
+		open = append(open, todo)
+		if filter.Matches(todo) {
+			matched = append(matched, todo)
+		}
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		tx.Rollback()
+		return nil, fmt.Errorf("error iterating todos: %v", err)
+	}
+
+	matched, blocked, warned := resolveSubtaskCompletion(matched, open, subtaskCompletionMode())
+
+	now := time.Now()
+	result := &CompleteResult{IDs: []int{}, Blocked: blocked, WithIncompleteSubtasks: warned}
+	for _, todo := range matched {
+		_, err := tx.Exec(
+			"UPDATE todos SET status = 'completed', completed_at = ?, last_updated = ?, source = ?, scheduled_start = CASE WHEN status = 'scheduled' THEN NULL ELSE scheduled_start END, scheduled_end = CASE WHEN status = 'scheduled' THEN NULL ELSE scheduled_end END WHERE id = ?",
+			now, now, source, todo.ID,
+		)
+		if err != nil {
+			tx.Rollback()
+			return nil, fmt.Errorf("failed to complete todo %d: %v", todo.ID, err)
+		}
+		result.IDs = append(result.IDs, todo.ID)
+	}
+	result.Count = len(result.IDs)
+	if _, err := tx.Exec(clearFinishedFocus); err != nil {
+		tx.Rollback()
+		return nil, fmt.Errorf("failed to clean up focus: %v", err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf("failed to commit transaction: %v", err)
+	}
+
+	for _, before := range matched {
+		after, err := d.GetTodoByID(before.ID)
+		if err != nil {
+			continue
+		}
+		if err := d.recordAudit(&before, after, now); err != nil {
+			log.Printf("Warning: %v", err)
+		}
+	}
+	return result, nil
+}
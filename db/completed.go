@@ -0,0 +1,112 @@
+package db
+
+import (
+	"fmt"
+	"time"
+)
+
+// CompletedSummary 某段时间内完成的待办事项及预计耗时合计
+type CompletedSummary struct {
+	From           time.Time `json:"from"`
+	To             time.Time `json:"to"`
+	Todos          []Todo    `json:"todos"`
+	Count          int       `json:"count"`
+	TotalEstimated string    `json:"total_estimated"`
+	// 无法解析预计耗时的任务数，这些任务未计入TotalEstimated
+	Unestimated int `json:"unestimated"`
+}
+
+// completedAt 根据状态计算完成时间：首次变为completed时记录当前时间，已完成的保留原值，未完成的清空
+func completedAt(status string, previous *time.Time) *time.Time {
+	if status != "completed" {
+		return nil
+	}
+	if previous != nil {
+		return previous
+	}
+	now := time.Now()
+	return &now
+}
+
+// ParseDateRange 解析时间范围参数，支持"2006-01-02"和RFC3339格式。
+// 日期格式的to包含当天；from为空时为今天零点，to为空时为当前时间
+func ParseDateRange(from, to string, now time.Time) (time.Time, time.Time, error) {
+	start := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, now.Location())
+	end := now
+
+	if from != "" {
+		t, _, err := parseDateParam(from, now.Location())
+		if err != nil {
+			return time.Time{}, time.Time{}, fmt.Errorf("invalid from: %v", err)
+		}
+		start = t
+	}
+	if to != "" {
+		t, dateOnly, err := parseDateParam(to, now.Location())
+		if err != nil {
+			return time.Time{}, time.Time{}, fmt.Errorf("invalid to: %v", err)
+		}
+		if dateOnly {
+			t = t.AddDate(0, 0, 1)
+		}
+		end = t
+	}
+
+	if !start.Before(end) {
+		return time.Time{}, time.Time{}, fmt.Errorf("from must be before to")
+	}
+	return start, end, nil
+}
+
+func parseDateParam(v string, loc *time.Location) (time.Time, bool, error) {
+	if t, err := time.ParseInLocation("2006-01-02", v, loc); err == nil {
+		return t, true, nil
+	}
+	t, err := time.Parse(time.RFC3339, v)
+	if err != nil {
+		return time.Time{}, false, fmt.Errorf("%q is neither YYYY-MM-DD nor RFC3339", v)
+	}
+	return t, false, nil
+}
+
+// GetCompletedTodos 获取完成时间在[from, to)内的待办事项，按完成时间排序
+func (d *SQLiteDatabase) GetCompletedTodos(from, to time.Time) ([]Todo, error) {
+	return d.queryTodos(
+		"SELECT "+todoColumns+" FROM todos WHERE completed_at IS NOT NULL AND completed_at >= ? AND completed_at < ? ORDER BY completed_at",
+		from,
+		to,
+	)
+}
+
+// SummarizeCompleted 汇总已完成待办事项的预计耗时
+func SummarizeCompleted(todos []Todo, from, to time.Time) CompletedSummary {
+	summary := CompletedSummary{
+		From:  from,
+		To:    to,
+		Todos: todos,
+		Count: len(todos),
+	}
+	if summary.Todos == nil {
+		summary.Todos = []Todo{}
+	}
+
+	var total time.Duration
+	for _, todo := range todos {
+		d := ParseEstimatedDuration(todo.EstimatedDuration)
+		if d == 0 {
+			summary.Unestimated++
+			continue
+		}
+		total += d
+	}
+	summary.TotalEstimated = total.String()
+	return summary
+}
+
+// completedTime 完成时间，缺少时（完成时间记录之前完成的任务）以最后更新时间为准
+func completedTime(todo Todo) time.Time {
+	if todo.CompletedAt != nil {
+		return *todo.CompletedAt
+	}
+	return todo.LastUpdated
+}
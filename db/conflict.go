@@ -0,0 +1,89 @@
+package db
+
+import (
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Conflict 一对时间窗口重叠的已排期待办事项
+type Conflict struct {
+	First  Todo      `json:"first"`
+	Second Todo      `json:"second"`
+	Start  time.Time `json:"overlap_start"`
+	End    time.Time `json:"overlap_end"`
+}
+
+var durationPattern = regexp.MustCompile(`^(\d+(?:\.\d+)?)\s*(minutes?|mins?|m|hours?|hrs?|h)\b`)
+
+// ParseEstimatedDuration 解析"1.5 hours"、"30 minutes daily"等预计耗时，无法识别时返回0
+func ParseEstimatedDuration(s string) time.Duration {
+	m := durationPattern.FindStringSubmatch(strings.ToLower(strings.TrimSpace(s)))
+	if m == nil {
+		return 0
+	}
+	n, err := strconv.ParseFloat(m[1], 64)
+	if err != nil {
+		return 0
+	}
+
+	unit := time.Minute
+	if strings.HasPrefix(m[2], "h") {
+		unit = time.Hour
+	}
+	return time.Duration(n * float64(unit))
+}
+
+// Window 返回已排期待办事项的时间窗口。优先使用排期时记录的开始/结束时间，
+// 否则从DueDate开始持续预计耗时；没有预计耗时时为一个时间点
+func (t Todo) Window() (start, end time.Time, ok bool) {
+	if t.Status != "scheduled" {
+		return time.Time{}, time.Time{}, false
+	}
+	if t.ScheduledStart != nil && t.ScheduledEnd != nil {
+		return *t.ScheduledStart, *t.ScheduledEnd, true
+	}
+	if t.DueDate == nil {
+		return time.Time{}, time.Time{}, false
+	}
+	start = *t.DueDate
+	return start, start.Add(ParseEstimatedDuration(t.EstimatedDuration)), true
+}
+
+// DetectConflicts 返回所有时间窗口重叠的已排期待办事项对，按开始时间排序
+func DetectConflicts(todos []Todo) []Conflict {
+	type window struct {
+		todo       Todo
+		start, end time.Time
+	}
+
+	var windows []window
+	for _, todo := range todos {
+		if start, end, ok := todo.Window(); ok {
+			windows = append(windows, window{todo, start, end})
+		}
+	}
+	sort.SliceStable(windows, func(i, j int) bool {
+		return windows[i].start.Before(windows[j].start)
+	})
+
+	conflicts := []Conflict{}
+	for i := range windows {
+		a := windows[i]
+		for _, b := range windows[i+1:] {
+			// windows按开始时间排序，b之后的窗口也不会与a重叠
+			if b.start.After(a.end) || (b.start.Equal(a.end) && a.end.After(a.start)) {
+				break
+			}
+
+			end := a.end
+			if b.end.Before(end) {
+				end = b.end
+			}
+			conflicts = append(conflicts, Conflict{First: a.todo, Second: b.todo, Start: b.start, End: end})
+		}
+	}
+	return conflicts
+}
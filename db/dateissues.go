@@ -0,0 +1,61 @@
+package db
+
+// 截止日期或排期时间不一致的问题类型
+const (
+	DateIssueReminderWithoutDue   = "reminder_without_due_date"
+	DateIssueRecurringWithoutDue  = "recurring_without_due_date"
+	DateIssueAllDayWithoutDue     = "all_day_without_due_date"
+	DateIssueZeroDue              = "zero_due_date"
+	DateIssueScheduledWithoutTime = "scheduled_without_time"
+	DateIssuePartialWindow        = "partial_schedule_window"
+	DateIssueWindowReversed       = "schedule_end_before_start"
+)
+
+// DateIssueTodo 日期不一致的待办事项及发现的问题
+type DateIssueTodo struct {
+	Todo
+	Issues []string `json:"date_issues"`
+}
+
+// DateIssues 返回todo中依赖截止日期或排期时间、但相应时间缺失或无效的问题
+func DateIssues(todo Todo) []string {
+	var issues []string
+	if todo.DueDate != nil && todo.DueDate.IsZero() {
+		issues = append(issues, DateIssueZeroDue)
+	}
+	if todo.DueDate == nil {
+		if todo.RemindBefore != nil {
+			issues = append(issues, DateIssueReminderWithoutDue)
+		}
+		if todo.Recurrence != RecurNone {
+			issues = append(issues, DateIssueRecurringWithoutDue)
+		}
+		if todo.AllDay {
+			issues = append(issues, DateIssueAllDayWithoutDue)
+		}
+	}
+	if (todo.ScheduledStart == nil) != (todo.ScheduledEnd == nil) {
+		issues = append(issues, DateIssuePartialWindow)
+	}
+	if todo.ScheduledStart != nil && todo.ScheduledEnd != nil && todo.ScheduledEnd.Before(*todo.ScheduledStart) {
+		issues = append(issues, DateIssueWindowReversed)
+	}
+	if _, _, ok := todo.Window(); todo.Status == "scheduled" && !ok {
+		issues = append(issues, DateIssueScheduledWithoutTime)
+	}
+	return issues
+}
+
+// FindDateIssues 返回存在日期问题的未完成待办事项，保持输入顺序
+func FindDateIssues(todos []Todo) []DateIssueTodo {
+	result := []DateIssueTodo{}
+	for _, todo := range todos {
+		if todo.Status == "completed" {
+			continue
+		}
+		if issues := DateIssues(todo); len(issues) > 0 {
+			result = append(result, DateIssueTodo{Todo: todo, Issues: issues})
+		}
+	}
+	return result
+}
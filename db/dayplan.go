@@ -0,0 +1,112 @@
+package db
+
+import (
+	"fydeos/config"
+	"sort"
+	"time"
+)
+
+// energyRank 精力等级的安排顺序，高精力任务排在上午；未设置精力的任务按medium处理
+var energyRank = map[string]int{
+	"high":   0,
+	"medium": 1,
+	"low":    2,
+}
+
+func energyOf(todo Todo) int {
+	if r, ok := energyRank[todo.EnergyLevel]; ok {
+		return r
+	}
+	return energyRank["medium"]
+}
+
+// PlanSlot 日计划时间线中的一段，Kind为task时Todo为安排的任务，为break时是休息
+type PlanSlot struct {
+	Kind   string    `json:"kind"`
+	Start  time.Time `json:"start"`
+	End    time.Time `json:"end"`
+	Period string    `json:"period"` // 开始时间在工作时段前半为morning，后半为afternoon
+	Todo   *Todo     `json:"todo,omitempty"`
+}
+
+// DayPlan 按精力安排的一天计划，Unplanned为当天剩余工作时间放不下的任务
+type DayPlan struct {
+	Date      string     `json:"date"`
+	WorkDay   bool       `json:"work_day"`
+	Timeline  []PlanSlot `json:"timeline"`
+	Unplanned []Todo     `json:"unplanned"`
+}
+
+// PlanDay 为day所在日期（按day的时区）安排未完成、未委派且未排期的待办事项：先按优先级和截止日期排序，
+// 再按精力等级把高精力任务排在前面、低精力任务排在后面，从上班时间（day为今天时从now）起依次安排，
+// 低精力任务不早于工作时段中点开始；连续工作cfg.BreakAfter后插入cfg.BreakLength的休息。非工作日返回空时间线
+func PlanDay(todos []Todo, schedule WorkSchedule, day, now time.Time, cfg config.PlanConfig) (*DayPlan, error) {
+	plan := &DayPlan{
+		Date:      day.Format("2006-01-02"),
+		WorkDay:   schedule.IsWorkDay(day),
+		Timeline:  []PlanSlot{},
+		Unplanned: []Todo{},
+	}
+	if !plan.WorkDay {
+		return plan, nil
+	}
+
+	start, err := schedule.StartOn(day)
+	if err != nil {
+		return nil, err
+	}
+	end, err := schedule.EndOn(day)
+	if err != nil {
+		return nil, err
+	}
+	midday := start.Add(end.Sub(start) / 2)
+	if now.After(start) {
+		start = now
+	}
+
+	var candidates []Todo
+	for _, todo := range todos {
+		if todo.Status == "completed" || todo.Status == "scheduled" || todo.WaitingOn != "" {
+			continue
+		}
+		candidates = append(candidates, todo)
+	}
+	SortTodos(candidates, SortPriorityFirst)
+	sort.SliceStable(candidates, func(i, j int) bool {
+		return energyOf(candidates[i]) < energyOf(candidates[j])
+	})
+
+	t := start
+	var worked time.Duration
+	for i := range candidates {
+		todo := candidates[i]
+		d := ParseEstimatedDuration(todo.EstimatedDuration)
+		if d <= 0 {
+			d = cfg.DefaultDuration
+		}
+		// 上午留给需要专注的任务，低精力任务从下午开始安排
+		if energyOf(todo) == energyRank["low"] && t.Before(midday) {
+			t, worked = midday, 0
+		}
+		if cfg.BreakAfter > 0 && worked >= cfg.BreakAfter && !t.Add(cfg.BreakLength+d).After(end) {
+			plan.Timeline = append(plan.Timeline, PlanSlot{Kind: "break", Start: t, End: t.Add(cfg.BreakLength), Period: periodOf(t, midday)})
+			t = t.Add(cfg.BreakLength)
+			worked = 0
+		}
+		if t.Add(d).After(end) {
+			plan.Unplanned = append(plan.Unplanned, todo)
+			continue
+		}
+		plan.Timeline = append(plan.Timeline, PlanSlot{Kind: "task", Start: t, End: t.Add(d), Period: periodOf(t, midday), Todo: &todo})
+		t = t.Add(d)
+		worked += d
+	}
+	return plan, nil
+}
+
+func periodOf(t, midday time.Time) string {
+	if t.Before(midday) {
+		return "morning"
+	}
+	return "afternoon"
+}
@@ -0,0 +1,87 @@
+package db
+
+import (
+	"fmt"
+	"fydeos/config"
+	"strings"
+	"time"
+)
+
+// ImportKeyFields 可用于导入去重的字段
+var ImportKeyFields = []string{"title", "due_date", "category", "description"}
+
+// ImportOptions 导入选项
+type ImportOptions struct {
+	Dedup bool     // 跳过与已有待办事项（或本次已导入的项）重复的项
+	Keys  []string // 判断重复的字段，见ImportKeyFields
+	Merge bool     // 不覆盖已有数据：跳过ID已存在的待办事项，保留已有的用户配置
+}
+
+// ImportResult 导入结果
+type ImportResult struct {
+	Imported int `json:"imported"`
+	Skipped  int `json:"skipped"` // 因重复（或合并导入时ID已存在）而跳过的数量
+}
+
+// DefaultImportOptions 按IMPORT_DEDUP和IMPORT_DEDUP_KEYS配置的导入选项
+func DefaultImportOptions() ImportOptions {
+	return ImportOptions{Dedup: config.Cfg.Import.Dedup, Keys: config.Cfg.Import.DedupKeys}
+}
+
+// ParseImportKeys 解析逗号分隔的去重字段，为空时使用IMPORT_DEDUP_KEYS的配置
+func ParseImportKeys(s string) ([]string, error) {
+	if strings.TrimSpace(s) == "" {
+		return config.Cfg.Import.DedupKeys, nil
+	}
+	var keys []string
+	for _, key := range strings.Split(s, ",") {
+		key = strings.TrimSpace(key)
+		if key == "" {
+			continue
+		}
+		if !containsString(ImportKeyFields, key) {
+			return nil, fmt.Errorf("unknown dedup key %q, expected one of %s", key, strings.Join(ImportKeyFields, ", "))
+		}
+		keys = append(keys, key)
+	}
+	return keys, nil
+}
+
+// importKey 由keys对应的字段组成去重键：标题、类别和描述去空白后不区分大小写，截止日期按时刻比较
+func importKey(todo Todo, keys []string) string {
+	parts := make([]string, len(keys))
+	for i, key := range keys {
+		switch key {
+		case "title":
+			parts[i] = strings.ToLower(strings.TrimSpace(todo.Title))
+		case "description":
+			parts[i] = strings.ToLower(strings.TrimSpace(todo.Description))
+		case "category":
+			parts[i] = strings.ToLower(strings.TrimSpace(todo.Category))
+		case "due_date":
+			if todo.DueDate != nil {
+				parts[i] = todo.DueDate.UTC().Format(time.RFC3339Nano)
+			}
+		}
+	}
+	return strings.Join(parts, "\x00")
+}
+
+// existingImportKeys 返回已有待办事项的去重键
+func existingImportKeys(tx *tracedTx, keys []string) (map[string]bool, error) {
+	rows, err := tx.Query("SELECT " + todoColumns + " FROM todos")
+	if err != nil {
+		return nil, fmt.Errorf("failed to query todos: %v", err)
+	}
+	defer rows.Close()
+
+	seen := map[string]bool{}
+	for rows.Next() {
+		todo, err := scanTodo(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan todo: %v", err)
+		}
+		seen[importKey(todo, keys)] = true
+	}
+	return seen, rows.Err()
+}
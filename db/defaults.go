@@ -0,0 +1,47 @@
+package db
+
+import (
+	"fmt"
+	"fydeos/config"
+	"time"
+)
+
+// defaultDueDate 按配置计算新建待办事项的默认截止日期，未启用时返回nil
+func (d *SQLiteDatabase) defaultDueDate(cfg config.DefaultDueConfig, now time.Time) (*time.Time, error) {
+	switch cfg.Mode {
+	case "days":
+		due := now.AddDate(0, 0, cfg.Days)
+		return &due, nil
+	case "end_of_week":
+		profile := d.LoadProfileOrDefault()
+		due, err := profile.WorkSchedule.EndOfWeek(now.In(profile.Location()))
+		if err != nil {
+			return nil, err
+		}
+		return &due, nil
+	}
+	return nil, nil
+}
+
+// defaultEstimate 按优先级返回新建待办事项的默认预计耗时，未配置时返回空字符串
+func defaultEstimate(estimates map[string]time.Duration, priority string) string {
+	return formatEstimate(estimates[priority])
+}
+
+// formatEstimate 将时长格式化为"N hours"或"N minutes"，可被ParseEstimatedDuration解析；不大于0时返回空字符串
+func formatEstimate(d time.Duration) string {
+	if d <= 0 {
+		return ""
+	}
+	if d%time.Hour == 0 {
+		return plural(int(d/time.Hour), "hour")
+	}
+	return plural(int(d.Round(time.Minute)/time.Minute), "minute")
+}
+
+func plural(n int, unit string) string {
+	if n == 1 {
+		return "1 " + unit
+	}
+	return fmt.Sprintf("%d %ss", n, unit)
+}
@@ -0,0 +1,19 @@
+package db
+
+// GetDelegatedTodos 获取已委派给他人且未完成的待办事项，person不为空时只返回等待该人的任务
+func (d *SQLiteDatabase) GetDelegatedTodos(person string) ([]Todo, error) {
+	if person != "" {
+		return d.queryTodos(
+			"SELECT "+todoColumns+" FROM todos WHERE waiting_on = ? AND status != 'completed' ORDER BY due_date IS NULL, due_date",
+			person,
+		)
+	}
+	return d.queryTodos(
+		"SELECT " + todoColumns + " FROM todos WHERE waiting_on != '' AND status != 'completed' ORDER BY due_date IS NULL, due_date",
+	)
+}
+
+// IsDelegated 任务是否在等待他人完成
+func (t Todo) IsDelegated() bool {
+	return t.WaitingOn != ""
+}
@@ -0,0 +1,138 @@
+package db
+
+import (
+	"database/sql"
+	"fmt"
+)
+
+// 依赖表：todo_id依赖depends_on_id，depends_on_id完成后todo_id才能开始
+const dependenciesTable = `CREATE TABLE IF NOT EXISTS todo_dependencies (
+	todo_id INTEGER NOT NULL,
+	depends_on_id INTEGER NOT NULL,
+	PRIMARY KEY (todo_id, depends_on_id)
+);`
+
+// ErrInvalidDependency 依赖关系不合法时返回，不会写入任何依赖
+type ErrInvalidDependency struct {
+	TodoID    int
+	DependsOn int
+	Reason    string
+}
+
+func (e *ErrInvalidDependency) Error() string {
+	return fmt.Sprintf("invalid dependency %d -> %d: %s", e.TodoID, e.DependsOn, e.Reason)
+}
+
+// validateDependencies 检查自依赖和重复的依赖边
+func validateDependencies(id int, dependsOn []int) error {
+	seen := map[int]bool{}
+	for _, dep := range dependsOn {
+		if dep == id {
+			return &ErrInvalidDependency{TodoID: id, DependsOn: dep, Reason: "a todo cannot depend on itself"}
+		}
+		if seen[dep] {
+			return &ErrInvalidDependency{TodoID: id, DependsOn: dep, Reason: "duplicate dependency"}
+		}
+		seen[dep] = true
+	}
+	return nil
+}
+
+// SetDependencies 将待办事项的依赖替换为dependsOn。校验自依赖、重复依赖以及所有ID都存在后，
+// 在一个事务中写入；任何校验失败都不会修改已有依赖
+func (d *SQLiteDatabase) SetDependencies(id int, dependsOn []int) error {
+	if err := validateDependencies(id, dependsOn); err != nil {
+		return err
+	}
+
+	tx, err := d.db.Begin()
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %v", err)
+	}
+
+	if err := setDependenciesTx(tx, id, dependsOn); err != nil {
+		tx.Rollback()
+		return err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit transaction: %v", err)
+	}
+	return nil
+}
+
+func setDependenciesTx(tx *tracedTx, id int, dependsOn []int) error {
+	if err := todoExistsTx(tx, id); err != nil {
+		return err
+	}
+	for _, dep := range dependsOn {
+		if err := todoExistsTx(tx, dep); err != nil {
+			return &ErrInvalidDependency{TodoID: id, DependsOn: dep, Reason: err.Error()}
+		}
+	}
+
+	if _, err := tx.Exec("DELETE FROM todo_dependencies WHERE todo_id = ?", id); err != nil {
+		return fmt.Errorf("failed to clear dependencies: %v", err)
+	}
+	for _, dep := range dependsOn {
+		if _, err := tx.Exec("INSERT INTO todo_dependencies (todo_id, depends_on_id) VALUES (?, ?)", id, dep); err != nil {
+			return fmt.Errorf("failed to insert dependency: %v", err)
+		}
+	}
+	return nil
+}
+
+func todoExistsTx(tx *tracedTx, id int) error {
+	var exists int
+	err := tx.QueryRow("SELECT 1 FROM todos WHERE id = ?", id).Scan(&exists)
+	if err == sql.ErrNoRows {
+		return fmt.Errorf("todo with ID %d not found", id)
+	} else if err != nil {
+		return fmt.Errorf("failed to get todo: %v", err)
+	}
+	return nil
+}
+
+// GetDependencies 返回待办事项直接依赖的待办事项ID
+func (d *SQLiteDatabase) GetDependencies(id int) ([]int, error) {
+	rows, err := d.db.Query("SELECT depends_on_id FROM todo_dependencies WHERE todo_id = ? ORDER BY depends_on_id", id)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query dependencies: %v", err)
+	}
+	defer rows.Close()
+
+	ids := []int{}
+	for rows.Next() {
+		var dep int
+		if err := rows.Scan(&dep); err != nil {
+			return nil, fmt.Errorf("failed to scan dependency: %v", err)
+		}
+		ids = append(ids, dep)
+	}
+	return ids, rows.Err()
+}
+
+// DependencyEdge 一条依赖：TodoID依赖DependsOnID
+type DependencyEdge struct {
+	TodoID      int `json:"todo_id"`
+	DependsOnID int `json:"depends_on_id"`
+}
+
+// GetAllDependencies 返回所有依赖
+func (d *SQLiteDatabase) GetAllDependencies() ([]DependencyEdge, error) {
+	rows, err := d.db.Query("SELECT todo_id, depends_on_id FROM todo_dependencies ORDER BY todo_id, depends_on_id")
+	if err != nil {
+		return nil, fmt.Errorf("failed to query dependencies: %v", err)
+	}
+	defer rows.Close()
+
+	edges := []DependencyEdge{}
+	for rows.Next() {
+		var e DependencyEdge
+		if err := rows.Scan(&e.TodoID, &e.DependsOnID); err != nil {
+			return nil, fmt.Errorf("failed to scan dependency: %v", err)
+		}
+		edges = append(edges, e)
+	}
+	return edges, rows.Err()
+}
@@ -0,0 +1,88 @@
+package db
+
+import (
+	"reflect"
+	"sort"
+	"time"
+)
+
+// FieldDiff 单个字段的变化，字段名与JSON一致
+type FieldDiff struct {
+	Field string      `json:"field"`
+	Old   interface{} `json:"old"`
+	New   interface{} `json:"new"`
+}
+
+// TodoChange 同一ID的待办事项在两个快照间的字段变化
+type TodoChange struct {
+	ID      int         `json:"id"`
+	Title   string      `json:"title"`
+	Changes []FieldDiff `json:"changes"`
+}
+
+// TodoDiff 两个待办事项快照的差异，各列表按ID排序
+type TodoDiff struct {
+	Added    []Todo       `json:"added"`
+	Removed  []Todo       `json:"removed"`
+	Modified []TodoChange `json:"modified"`
+}
+
+// DiffTodos 按ID比较两个快照：只在new中的为新增，只在old中的为删除，两边都有且字段不同的为修改
+func DiffTodos(old, new []Todo) TodoDiff {
+	diff := TodoDiff{Added: []Todo{}, Removed: []Todo{}, Modified: []TodoChange{}}
+
+	oldByID := make(map[int]Todo, len(old))
+	for _, todo := range old {
+		oldByID[todo.ID] = todo
+	}
+	newIDs := make(map[int]bool, len(new))
+	for _, todo := range new {
+		newIDs[todo.ID] = true
+		prev, ok := oldByID[todo.ID]
+		if !ok {
+			diff.Added = append(diff.Added, todo)
+			continue
+		}
+		if changes := diffFields(prev, todo); len(changes) > 0 {
+			diff.Modified = append(diff.Modified, TodoChange{ID: todo.ID, Title: todo.Title, Changes: changes})
+		}
+	}
+	for _, todo := range old {
+		if !newIDs[todo.ID] {
+			diff.Removed = append(diff.Removed, todo)
+		}
+	}
+
+	sort.Slice(diff.Added, func(i, j int) bool { return diff.Added[i].ID < diff.Added[j].ID })
+	sort.Slice(diff.Removed, func(i, j int) bool { return diff.Removed[i].ID < diff.Removed[j].ID })
+	sort.Slice(diff.Modified, func(i, j int) bool { return diff.Modified[i].ID < diff.Modified[j].ID })
+	return diff
+}
+
+// diffFields 比较两个待办事项的JSON表示，返回按字段名排序的变化。
+// 时间统一换算为UTC后比较，避免同一时刻的不同时区表示被当作修改
+func diffFields(old, new Todo) []FieldDiff {
+	oldFields, newFields := diffableFields(old), diffableFields(new)
+
+	var changes []FieldDiff
+	for field, v := range newFields {
+		if !reflect.DeepEqual(oldFields[field], v) {
+			changes = append(changes, FieldDiff{Field: field, Old: oldFields[field], New: v})
+		}
+	}
+	sort.Slice(changes, func(i, j int) bool { return changes[i].Field < changes[j].Field })
+	return changes
+}
+
+func diffableFields(todo Todo) map[string]interface{} {
+	// Todo的字段都能序列化为JSON，toFieldMap不会失败
+	fields, _ := toFieldMap(&todo)
+	for field, v := range fields {
+		if s, ok := v.(string); ok {
+			if t, err := time.Parse(time.RFC3339Nano, s); err == nil {
+				fields[field] = t.UTC().Format(time.RFC3339Nano)
+			}
+		}
+	}
+	return fields
+}
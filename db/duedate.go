@@ -0,0 +1,165 @@
+package db
+
+import (
+	"fmt"
+	"time"
+)
+
+// 无法解析预计耗时的任务按该时长占用容量
+const defaultTaskDuration = time.Hour
+
+// 最多向后安排的工作日数
+const maxSuggestWorkDays = 60
+
+// DueDateProposal 为未设置截止日期的任务建议的截止日期
+type DueDateProposal struct {
+	ID       int       `json:"id"`
+	Title    string    `json:"title"`
+	Priority string    `json:"priority"`
+	DueDate  time.Time `json:"due_date"`
+	Duration string    `json:"duration"`
+}
+
+// SuggestDueDates 按优先级把没有截止日期、未委派的pending任务依次分配到从now开始的工作日，
+// 每天安排的预计耗时不超过工作时长；超过一天工作时长的任务单独占用一天。
+// 建议的截止日期为当天的下班时间
+func SuggestDueDates(todos []Todo, schedule WorkSchedule, now time.Time) ([]DueDateProposal, error) {
+	undated := undatedPending(todos)
+
+	proposals := []DueDateProposal{}
+	if len(undated) == 0 {
+		return proposals, nil
+	}
+
+	start, err := schedule.StartOn(now)
+	if err != nil {
+		return nil, err
+	}
+	end, err := schedule.EndOn(now)
+	if err != nil {
+		return nil, err
+	}
+	capacity := end.Sub(start)
+	if capacity <= 0 {
+		return nil, fmt.Errorf("work schedule end %s must be after start %s", schedule.EndTime, schedule.StartTime)
+	}
+
+	// 收集可用工作日及剩余容量，今天只计算剩余的工作时间
+	type workDay struct {
+		end       time.Time
+		remaining time.Duration
+	}
+	var days []workDay
+	for day := now; len(days) < maxSuggestWorkDays; day = day.AddDate(0, 0, 1) {
+		if !schedule.IsWorkDay(day) {
+			continue
+		}
+		dayStart, _ := schedule.StartOn(day)
+		dayEnd, _ := schedule.EndOn(day)
+		remaining := capacity
+		if now.After(dayStart) {
+			remaining = dayEnd.Sub(now)
+		}
+		if remaining > 0 {
+			days = append(days, workDay{end: dayEnd, remaining: remaining})
+		}
+	}
+	if len(days) == 0 {
+		return nil, fmt.Errorf("work schedule has no work days")
+	}
+
+	for _, todo := range undated {
+		duration := ParseEstimatedDuration(todo.EstimatedDuration)
+		if duration == 0 {
+			duration = defaultTaskDuration
+		}
+
+		for i := range days {
+			fits := days[i].remaining >= duration
+			if !fits && duration > capacity && days[i].remaining == capacity {
+				// 超过一天工作时长的任务占满一个空闲工作日
+				fits = true
+			}
+			if !fits {
+				continue
+			}
+
+			days[i].remaining -= min(duration, days[i].remaining)
+			proposals = append(proposals, DueDateProposal{
+				ID:       todo.ID,
+				Title:    todo.Title,
+				Priority: todo.Priority,
+				DueDate:  days[i].end,
+				Duration: duration.String(),
+			})
+			break
+		}
+	}
+
+	return proposals, nil
+}
+
+// undatedPending 返回没有截止日期、未委派的pending任务，按优先级排序
+func undatedPending(todos []Todo) []Todo {
+	var undated []Todo
+	for _, todo := range todos {
+		if todo.Status == "pending" && todo.DueDate == nil && !todo.IsDelegated() {
+			undated = append(undated, todo)
+		}
+	}
+	// 待分配的任务都没有截止日期，只需按优先级排序
+	SortTodos(undated, SortPriorityFirst)
+	return undated
+}
+
+// SpreadDueDates 按优先级把没有截止日期、未委派的pending任务从start所在日期起依次分配到工作日，
+// 每天最多perDay个，跳过非工作日、holidays（"2006-01-02"）以及下班时间已过的日期。
+// 截止日期为当天的下班时间
+func SpreadDueDates(todos []Todo, schedule WorkSchedule, holidays map[string]bool, start, now time.Time, perDay int) ([]DueDateProposal, error) {
+	if perDay <= 0 {
+		return nil, fmt.Errorf("tasks_per_day must be positive")
+	}
+
+	proposals := []DueDateProposal{}
+	undated := undatedPending(todos)
+	day := time.Date(start.Year(), start.Month(), start.Day(), 0, 0, 0, 0, start.Location())
+	for scanned := 0; len(undated) > 0; scanned++ {
+		if scanned > maxSuggestWorkDays*7 {
+			return nil, fmt.Errorf("no work days available within %d days", maxSuggestWorkDays*7)
+		}
+		if schedule.IsWorkDay(day) && !holidays[day.Format("2006-01-02")] {
+			end, err := schedule.EndOn(day)
+			if err != nil {
+				return nil, err
+			}
+			if end.After(now) {
+				n := min(perDay, len(undated))
+				for _, todo := range undated[:n] {
+					proposals = append(proposals, DueDateProposal{
+						ID:       todo.ID,
+						Title:    todo.Title,
+						Priority: todo.Priority,
+						DueDate:  end,
+						Duration: todo.EstimatedDuration,
+					})
+				}
+				undated = undated[n:]
+			}
+		}
+		day = day.AddDate(0, 0, 1)
+	}
+	return proposals, nil
+}
+
+// ApplyDueDates 在一个事务中将建议的截止日期写入对应的待办事项，任一项失败时全部不生效
+func (d *SQLiteDatabase) ApplyDueDates(proposals []DueDateProposal, source string) error {
+	ids := make([]int, len(proposals))
+	for i, p := range proposals {
+		ids[i] = p.ID
+	}
+	return d.updateTodos(ids, func(i int, todo *Todo) {
+		dueDate := proposals[i].DueDate
+		todo.DueDate = &dueDate
+		todo.Source = source
+	})
+}
@@ -0,0 +1,55 @@
+package db
+
+import (
+	"fmt"
+	"time"
+)
+
+// DurationRange 按预计耗时（分钟，含边界）筛选，MinMinutes、MaxMinutes为0表示该端不限。
+// 没有可识别预计耗时的任务默认排除，IncludeUnestimated为true时保留
+type DurationRange struct {
+	MinMinutes         int
+	MaxMinutes         int
+	IncludeUnestimated bool
+}
+
+// NewDurationRange 校验并构造预计耗时范围
+func NewDurationRange(minMinutes, maxMinutes int, includeUnestimated bool) (DurationRange, error) {
+	if minMinutes < 0 || maxMinutes < 0 {
+		return DurationRange{}, fmt.Errorf("min_minutes and max_minutes must not be negative")
+	}
+	if maxMinutes > 0 && minMinutes > maxMinutes {
+		return DurationRange{}, fmt.Errorf("min_minutes must not be greater than max_minutes")
+	}
+	return DurationRange{MinMinutes: minMinutes, MaxMinutes: maxMinutes, IncludeUnestimated: includeUnestimated}, nil
+}
+
+// IsEmpty 没有指定上下限时不筛选
+func (r DurationRange) IsEmpty() bool {
+	return r.MinMinutes == 0 && r.MaxMinutes == 0
+}
+
+// FilterByDuration 返回预计耗时落在r内的待办事项，保持输入顺序
+func FilterByDuration(todos []Todo, r DurationRange) []Todo {
+	if r.IsEmpty() {
+		return todos
+	}
+	filtered := []Todo{}
+	for _, todo := range todos {
+		d := ParseEstimatedDuration(todo.EstimatedDuration)
+		if d == 0 {
+			if r.IncludeUnestimated {
+				filtered = append(filtered, todo)
+			}
+			continue
+		}
+		if r.MinMinutes > 0 && d < time.Duration(r.MinMinutes)*time.Minute {
+			continue
+		}
+		if r.MaxMinutes > 0 && d > time.Duration(r.MaxMinutes)*time.Minute {
+			continue
+		}
+		filtered = append(filtered, todo)
+	}
+	return filtered
+}
@@ -0,0 +1,87 @@
+package db
+
+import (
+	"math"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// DurationEstimate 根据历史任务推算的预计耗时
+type DurationEstimate struct {
+	Suggested  string `json:"suggested"`
+	Minutes    int    `json:"minutes"`
+	SampleSize int    `json:"sample_size"`
+	Confidence string `json:"confidence"` // none, low, medium, high
+	Basis      string `json:"basis"`      // similar_title, category
+}
+
+// EstimateDuration 用同类别已完成任务的平均预计耗时估算新任务耗时。
+// 同类别中存在标题有相同词的任务时只使用这些任务；样本越多置信度越高
+func EstimateDuration(todos []Todo, title, category string) DurationEstimate {
+	titleWords := map[string]bool{}
+	for _, w := range splitWords(strings.ToLower(title)) {
+		titleWords[w] = true
+	}
+
+	var sameCategory, similar []time.Duration
+	for _, todo := range todos {
+		if todo.Status != "completed" || todo.Category != category {
+			continue
+		}
+		d := ParseEstimatedDuration(todo.EstimatedDuration)
+		if d == 0 {
+			continue
+		}
+		sameCategory = append(sameCategory, d)
+
+		for _, w := range splitWords(strings.ToLower(todo.Title)) {
+			if titleWords[w] {
+				similar = append(similar, d)
+				break
+			}
+		}
+	}
+
+	samples, basis := sameCategory, "category"
+	if len(similar) > 0 {
+		samples, basis = similar, "similar_title"
+	}
+	if len(samples) == 0 {
+		return DurationEstimate{Confidence: "none"}
+	}
+
+	var total time.Duration
+	for _, d := range samples {
+		total += d
+	}
+	avg := (total / time.Duration(len(samples))).Round(time.Minute)
+
+	confidence := "low"
+	switch {
+	case len(samples) >= 10:
+		confidence = "high"
+	case len(samples) >= 3:
+		confidence = "medium"
+	}
+
+	return DurationEstimate{
+		Suggested:  formatMinutes(avg),
+		Minutes:    int(avg.Minutes()),
+		SampleSize: len(samples),
+		Confidence: confidence,
+		Basis:      basis,
+	}
+}
+
+// formatMinutes 以与EstimatedDuration相同的写法输出，如"1.5 hours"、"45 minutes"
+func formatMinutes(d time.Duration) string {
+	if d < time.Hour {
+		return strconv.Itoa(int(d.Minutes())) + " minutes"
+	}
+	hours := math.Round(d.Hours()*10) / 10
+	if hours == 1 {
+		return "1 hour"
+	}
+	return strconv.FormatFloat(hours, 'f', -1, 64) + " hours"
+}
@@ -0,0 +1,99 @@
+package db
+
+import (
+	"fmt"
+	"time"
+)
+
+// ScheduleExplanation 日程优化结果中一个任务的位置说明
+type ScheduleExplanation struct {
+	ID        int    `json:"id"`
+	Title     string `json:"title"`
+	Placement string `json:"placement"` // selected: 已选入; excluded: 未选入
+	Rank      int    `json:"rank"`
+	Priority  string `json:"priority"`
+	Due       string `json:"due"`
+	// BlockedBy 尚未完成的依赖任务ID，优化时不考虑依赖，需要先处理这些任务
+	BlockedBy []int    `json:"blocked_by"`
+	Reasons   []string `json:"reasons"`
+}
+
+// ExplainSchedule 说明result中每个任务为何排在当前位置、未选入的任务为何被排除。
+// todos用于判断依赖是否已完成，edges为全部依赖关系
+func ExplainSchedule(result ScheduleResult, todos []Todo, edges []DependencyEdge, now time.Time, grace time.Duration) []ScheduleExplanation {
+	status := map[int]string{}
+	for _, todo := range todos {
+		status[todo.ID] = todo.Status
+	}
+	blockedBy := map[int][]int{}
+	for _, e := range edges {
+		if s, ok := status[e.DependsOnID]; ok && s != "completed" {
+			blockedBy[e.TodoID] = append(blockedBy[e.TodoID], e.DependsOnID)
+		}
+	}
+
+	total := len(result.Selected) + len(result.Excluded)
+	ranked := append(append([]ScheduledTask{}, result.Selected...), result.Excluded...)
+
+	explanations := []ScheduleExplanation{}
+	for i, task := range ranked {
+		todo := task.Todo
+		exp := ScheduleExplanation{
+			ID:        todo.ID,
+			Title:     todo.Title,
+			Placement: "selected",
+			Rank:      task.Rank,
+			Priority:  todo.Priority,
+			Due:       dueProximity(todo, now, grace),
+			BlockedBy: blockedBy[todo.ID],
+		}
+		if exp.BlockedBy == nil {
+			exp.BlockedBy = []int{}
+		}
+
+		exp.Reasons = append(exp.Reasons, fmt.Sprintf("在%d个候选任务中排第%d位（排序策略: %s）", total, task.Rank, result.Strategy))
+		if i == 0 {
+			exp.Reasons = append(exp.Reasons, "优先级和截止日期在候选任务中最靠前")
+		} else {
+			exp.Reasons = append(exp.Reasons, orderReason(ranked[i-1].Todo, todo, result.Strategy))
+		}
+		exp.Reasons = append(exp.Reasons, "截止日期: "+exp.Due)
+
+		if i >= len(result.Selected) {
+			exp.Placement = "excluded"
+			exp.Reasons = append(exp.Reasons, fmt.Sprintf("前%d个任务已占满数量上限", result.Limit))
+		}
+		for _, dep := range exp.BlockedBy {
+			exp.Reasons = append(exp.Reasons, fmt.Sprintf("依赖的任务#%d尚未完成，需先处理", dep))
+		}
+		explanations = append(explanations, exp)
+	}
+	return explanations
+}
+
+// orderReason 说明cur为何排在prev之后，与lessTodo的比较顺序一致
+func orderReason(prev, cur Todo, strategy SortStrategy) string {
+	samePriority := rankOf(prev.Priority) == rankOf(cur.Priority)
+	sameDue := compareDue(prev, cur) == 0
+
+	if !sameDue && cur.DueDate == nil && (strategy == SortDueFirst || samePriority) {
+		return fmt.Sprintf("没有截止日期，排在有截止日期的前一项「%s」之后", prev.Title)
+	}
+	if strategy == SortDueFirst {
+		if !sameDue {
+			return fmt.Sprintf("截止日期晚于前一项「%s」", prev.Title)
+		}
+		if !samePriority {
+			return fmt.Sprintf("与前一项「%s」截止日期相同，但优先级%s低于%s", prev.Title, cur.Priority, prev.Priority)
+		}
+		return fmt.Sprintf("与前一项「%s」截止日期和优先级都相同，保持原有顺序", prev.Title)
+	}
+
+	if !samePriority {
+		return fmt.Sprintf("优先级%s低于前一项「%s」的%s", cur.Priority, prev.Title, prev.Priority)
+	}
+	if !sameDue {
+		return fmt.Sprintf("与前一项「%s」同为%s优先级，但截止日期更晚", prev.Title, cur.Priority)
+	}
+	return fmt.Sprintf("与前一项「%s」优先级和截止日期都相同，保持原有顺序", prev.Title)
+}
@@ -0,0 +1,71 @@
+package db
+
+import (
+	"fmt"
+	"net/url"
+	"strings"
+)
+
+// 外部引用的索引，用于按系统和编号查找
+const externalRefIndex = `CREATE INDEX IF NOT EXISTS idx_todos_external_ref ON todos(external_system, external_id)`
+
+// ExternalRef 关联的外部问题跟踪系统条目，如GitHub issue或Jira ticket
+type ExternalRef struct {
+	System string `json:"system"` // 系统名称，如github、jira，保存时转为小写
+	ID     string `json:"id"`     // 系统内的编号，如owner/repo#123、PROJ-42
+	URL    string `json:"url"`
+}
+
+// columns 返回写入external_system、external_id、external_url列的值，没有外部引用时均为空
+func (r *ExternalRef) columns() (system, id, link string) {
+	if r == nil {
+		return "", "", ""
+	}
+	return r.System, r.ID, r.URL
+}
+
+// normalize 去掉首尾空白并将系统名称转为小写
+func (r *ExternalRef) normalize() {
+	r.System = strings.ToLower(strings.TrimSpace(r.System))
+	r.ID = strings.TrimSpace(r.ID)
+	r.URL = strings.TrimSpace(r.URL)
+}
+
+// validate 检查system和id必填，url为空或为http(s)地址
+func (r *ExternalRef) validate() error {
+	if r.System == "" || r.ID == "" {
+		return fmt.Errorf("external_ref requires both system and id")
+	}
+	if r.URL != "" {
+		u, err := url.Parse(r.URL)
+		if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+			return fmt.Errorf("external_ref url must be an http(s) URL")
+		}
+	}
+	return nil
+}
+
+// FindByExternalRef 查找关联到外部系统条目的待办事项，system不区分大小写
+func (d *SQLiteDatabase) FindByExternalRef(system, id string) ([]Todo, error) {
+	return d.queryTodos(
+		"SELECT "+todoColumns+" FROM todos WHERE external_system = ? AND external_id = ? ORDER BY id",
+		strings.ToLower(strings.TrimSpace(system)),
+		strings.TrimSpace(id),
+	)
+}
+
+// FilterByExternalRef 返回关联到system（及id，不为空时）的待办事项，system为空时原样返回
+func FilterByExternalRef(todos []Todo, system, id string) []Todo {
+	system = strings.ToLower(strings.TrimSpace(system))
+	if system == "" {
+		return todos
+	}
+	filtered := []Todo{}
+	for _, todo := range todos {
+		ref := todo.ExternalRef
+		if ref != nil && ref.System == system && (id == "" || ref.ID == id) {
+			filtered = append(filtered, todo)
+		}
+	}
+	return filtered
+}
@@ -0,0 +1,72 @@
+package db
+
+import (
+	"fmt"
+	"time"
+)
+
+// 冗余的过期/陈旧标记列上的索引，用于快速筛选
+const flagIndexes = `
+CREATE INDEX IF NOT EXISTS idx_todos_is_overdue ON todos(is_overdue);
+CREATE INDEX IF NOT EXISTS idx_todos_is_stale ON todos(is_stale);`
+
+// IsStale 未完成且超过staleAfter未更新时视为陈旧
+func IsStale(todo Todo, now time.Time, staleAfter time.Duration) bool {
+	return todo.Status != "completed" && todo.LastUpdated.Before(now.Add(-staleAfter))
+}
+
+// RecomputeFlags 按now重新计算所有待办事项的is_overdue和is_stale标记，只写入发生变化的行，返回更新的行数。
+// 全天任务的截止时间按now所在时区计算
+func (d *SQLiteDatabase) RecomputeFlags(now time.Time, grace, staleAfter time.Duration) (int, error) {
+	todos, err := d.GetAllTodos()
+	if err != nil {
+		return 0, err
+	}
+
+	rows, err := d.db.Query("SELECT id, is_overdue, is_stale FROM todos")
+	if err != nil {
+		return 0, fmt.Errorf("failed to query flags: %v", err)
+	}
+	defer rows.Close()
+	current := make(map[int][2]bool)
+	for rows.Next() {
+		var id int
+		var overdue, stale bool
+		if err := rows.Scan(&id, &overdue, &stale); err != nil {
+			return 0, fmt.Errorf("failed to scan flags: %v", err)
+		}
+		current[id] = [2]bool{overdue, stale}
+	}
+	if err := rows.Err(); err != nil {
+		return 0, fmt.Errorf("error iterating flags: %v", err)
+	}
+	rows.Close()
+
+	tx, err := d.db.Begin()
+	if err != nil {
+		return 0, fmt.Errorf("failed to begin transaction: %v", err)
+	}
+	updated := 0
+	for _, todo := range todos {
+		flags := [2]bool{IsOverdue(todo, now, grace), IsStale(todo, now, staleAfter)}
+		if flags == current[todo.ID] {
+			continue
+		}
+		// 不修改last_updated，避免刷新标记本身重置任务的陈旧状态
+		if _, err := tx.Exec("UPDATE todos SET is_overdue = ?, is_stale = ? WHERE id = ?", flags[0], flags[1], todo.ID); err != nil {
+			tx.Rollback()
+			return 0, fmt.Errorf("failed to update flags: %v", err)
+		}
+		updated++
+	}
+	if err := tx.Commit(); err != nil {
+		return 0, fmt.Errorf("failed to commit transaction: %v", err)
+	}
+	return updated, nil
+}
+
+// GetFlaggedTodos 按标记筛选待办事项，各参数为true时只返回带有对应标记的任务。
+// is_overdue和is_stale由后台任务定期刷新，可能滞后于最近的修改
+func (d *SQLiteDatabase) GetFlaggedTodos(starred, overdue, stale bool) ([]Todo, error) {
+	return d.QueryTodos(TodoFilter{Starred: starred, Overdue: overdue, Stale: stale})
+}
@@ -0,0 +1,74 @@
+package db
+
+import (
+	"database/sql"
+	"fmt"
+	"time"
+)
+
+// 目前只有一个用户，焦点按该用户ID保存，与user_profile的id一致
+const defaultUserID = 1
+
+const focusTable = `CREATE TABLE IF NOT EXISTS user_focus (
+	user_id INTEGER PRIMARY KEY,
+	todo_id INTEGER NOT NULL,
+	set_at TIMESTAMP NOT NULL
+)`
+
+// clearFinishedFocus 清除指向已完成或已删除待办事项的焦点
+const clearFinishedFocus = "DELETE FROM user_focus WHERE todo_id NOT IN (SELECT id FROM todos WHERE status != 'completed')"
+
+// Focus 用户当前专注的待办事项，没有设置时Todo为空
+type Focus struct {
+	Todo  *Todo      `json:"todo"`
+	SetAt *time.Time `json:"set_at"`
+}
+
+// GetFocus 返回当前焦点；焦点任务已完成或已删除时清除并返回空焦点
+func (d *SQLiteDatabase) GetFocus() (*Focus, error) {
+	var todoID int
+	var setAt time.Time
+	err := d.db.QueryRow("SELECT todo_id, set_at FROM user_focus WHERE user_id = ?", defaultUserID).Scan(&todoID, &setAt)
+	if err == sql.ErrNoRows {
+		return &Focus{}, nil
+	} else if err != nil {
+		return nil, fmt.Errorf("failed to get focus: %v", err)
+	}
+
+	todo, err := d.GetTodoByID(todoID)
+	if err != nil || todo.Status == "completed" {
+		if err := d.ClearFocus(); err != nil {
+			return nil, err
+		}
+		return &Focus{}, nil
+	}
+	return &Focus{Todo: todo, SetAt: &setAt}, nil
+}
+
+// SetFocus 将未完成的待办事项设为当前焦点，替换之前的焦点
+func (d *SQLiteDatabase) SetFocus(id int) (*Focus, error) {
+	todo, err := d.GetTodoByID(id)
+	if err != nil {
+		return nil, err
+	}
+	if todo.Status == "completed" {
+		return nil, fmt.Errorf("todo with ID %d is already completed", id)
+	}
+
+	now := time.Now()
+	if _, err := d.db.Exec(
+		"INSERT OR REPLACE INTO user_focus (user_id, todo_id, set_at) VALUES (?, ?, ?)",
+		defaultUserID, id, now,
+	); err != nil {
+		return nil, fmt.Errorf("failed to set focus: %v", err)
+	}
+	return &Focus{Todo: todo, SetAt: &now}, nil
+}
+
+// ClearFocus 清除当前焦点
+func (d *SQLiteDatabase) ClearFocus() error {
+	if _, err := d.db.Exec("DELETE FROM user_focus WHERE user_id = ?", defaultUserID); err != nil {
+		return fmt.Errorf("failed to clear focus: %v", err)
+	}
+	return nil
+}
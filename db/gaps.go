@@ -0,0 +1,86 @@
+package db
+
+import (
+	"sort"
+	"time"
+)
+
+// TimeGap 工作时段内没有排期的一段空闲时间
+type TimeGap struct {
+	Start   time.Time `json:"start"`
+	End     time.Time `json:"end"`
+	Minutes int       `json:"minutes"`
+}
+
+// DayGaps 某天工作时段内的空闲时间
+type DayGaps struct {
+	Date        string    `json:"date"`
+	WorkDay     bool      `json:"work_day"`
+	Gaps        []TimeGap `json:"gaps"`
+	FreeMinutes int       `json:"free_minutes"`
+}
+
+// FindGaps 返回day所在日期（按day的时区）工作时段内已排期任务之间的空闲时间。
+// 每个排期任务结束后预留buffer作为休息，短于minGap的空闲不返回，now之前的时间不算空闲；
+// 非工作日返回空列表
+func FindGaps(todos []Todo, schedule WorkSchedule, day, now time.Time, minGap, buffer time.Duration) (*DayGaps, error) {
+	result := &DayGaps{
+		Date:    day.Format("2006-01-02"),
+		WorkDay: schedule.IsWorkDay(day),
+		Gaps:    []TimeGap{},
+	}
+	if !result.WorkDay {
+		return result, nil
+	}
+
+	workStart, err := schedule.StartOn(day)
+	if err != nil {
+		return nil, err
+	}
+	workEnd, err := schedule.EndOn(day)
+	if err != nil {
+		return nil, err
+	}
+	if now.After(workStart) {
+		workStart = now
+	}
+
+	type window struct{ start, end time.Time }
+	var busy []window
+	for _, todo := range todos {
+		start, end, ok := todo.Window()
+		if !ok || !end.After(start) {
+			continue
+		}
+		end = end.Add(buffer)
+		if !end.After(workStart) || !start.Before(workEnd) {
+			continue
+		}
+		busy = append(busy, window{start, end})
+	}
+	sort.Slice(busy, func(i, j int) bool { return busy[i].start.Before(busy[j].start) })
+
+	addGap := func(start, end time.Time) {
+		if end.Sub(start) < minGap || !end.After(start) {
+			return
+		}
+		minutes := int(end.Sub(start) / time.Minute)
+		result.Gaps = append(result.Gaps, TimeGap{Start: start, End: end, Minutes: minutes})
+		result.FreeMinutes += minutes
+	}
+
+	cursor := workStart
+	for _, b := range busy {
+		if b.start.After(cursor) {
+			addGap(cursor, b.start)
+		}
+		if b.end.After(cursor) {
+			cursor = b.end
+		}
+	}
+	if cursor.After(workEnd) {
+		cursor = workEnd
+	}
+	addGap(cursor, workEnd)
+	return result, nil
+}
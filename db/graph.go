@@ -0,0 +1,161 @@
+package db
+
+import "sort"
+
+// 图中边的类型
+const (
+	EdgeDependency = "depends_on" // From完成后To才能开始
+	EdgeParent     = "parent"     // From是To的父任务
+)
+
+// GraphNode 图中的待办事项节点，包含用于着色的状态和优先级
+type GraphNode struct {
+	ID       int    `json:"id"`
+	Title    string `json:"title"`
+	Status   string `json:"status"`
+	Priority string `json:"priority"`
+	InCycle  bool   `json:"in_cycle"`
+}
+
+// GraphEdge 图中的有向边
+type GraphEdge struct {
+	From    int    `json:"from"`
+	To      int    `json:"to"`
+	Kind    string `json:"kind"`
+	InCycle bool   `json:"in_cycle"`
+}
+
+// TodoGraph 待办事项依赖图
+type TodoGraph struct {
+	Nodes  []GraphNode `json:"nodes"`
+	Edges  []GraphEdge `json:"edges"`
+	Cycles [][]int     `json:"cycles"` // 每个环中的节点ID，按同类边分别检测
+}
+
+// BuildGraph 由待办事项及其依赖构建图，指向不存在的待办事项的边会被忽略
+func BuildGraph(todos []Todo, deps []DependencyEdge) TodoGraph {
+	graph := TodoGraph{Nodes: []GraphNode{}, Edges: []GraphEdge{}, Cycles: [][]int{}}
+
+	exists := map[int]bool{}
+	for _, todo := range todos {
+		exists[todo.ID] = true
+	}
+	for _, dep := range deps {
+		if exists[dep.TodoID] && exists[dep.DependsOnID] {
+			graph.Edges = append(graph.Edges, GraphEdge{From: dep.DependsOnID, To: dep.TodoID, Kind: EdgeDependency})
+		}
+	}
+	for _, todo := range todos {
+		if todo.ParentID != nil && exists[*todo.ParentID] {
+			graph.Edges = append(graph.Edges, GraphEdge{From: *todo.ParentID, To: todo.ID, Kind: EdgeParent})
+		}
+	}
+
+	// 节点所在的环，按边类型区分：component[kind][id]为环编号
+	component := map[string]map[int]int{}
+	for _, kind := range []string{EdgeDependency, EdgeParent} {
+		component[kind] = map[int]int{}
+		for _, cycle := range findCycles(graph.Edges, kind) {
+			for _, id := range cycle {
+				component[kind][id] = len(graph.Cycles)
+			}
+			graph.Cycles = append(graph.Cycles, cycle)
+		}
+	}
+
+	inCycle := map[int]bool{}
+	for i, e := range graph.Edges {
+		from, ok1 := component[e.Kind][e.From]
+		to, ok2 := component[e.Kind][e.To]
+		if ok1 && ok2 && from == to {
+			graph.Edges[i].InCycle = true
+			inCycle[e.From] = true
+			inCycle[e.To] = true
+		}
+	}
+
+	for _, todo := range todos {
+		graph.Nodes = append(graph.Nodes, GraphNode{
+			ID:       todo.ID,
+			Title:    todo.Title,
+			Status:   todo.Status,
+			Priority: todo.Priority,
+			InCycle:  inCycle[todo.ID],
+		})
+	}
+	return graph
+}
+
+// findCycles 用Tarjan算法找出kind类边构成的强连通分量中的环（含自环），每个环的ID升序排列
+func findCycles(edges []GraphEdge, kind string) [][]int {
+	adj := map[int][]int{}
+	selfLoop := map[int]bool{}
+	var nodes []int
+	seen := map[int]bool{}
+	for _, e := range edges {
+		if e.Kind != kind {
+			continue
+		}
+		adj[e.From] = append(adj[e.From], e.To)
+		if e.From == e.To {
+			selfLoop[e.From] = true
+		}
+		for _, id := range []int{e.From, e.To} {
+			if !seen[id] {
+				seen[id] = true
+				nodes = append(nodes, id)
+			}
+		}
+	}
+	sort.Ints(nodes)
+
+	index := map[int]int{}
+	low := map[int]int{}
+	onStack := map[int]bool{}
+	var stack []int
+	var cycles [][]int
+	next := 0
+
+	var strongConnect func(v int)
+	strongConnect = func(v int) {
+		index[v] = next
+		low[v] = next
+		next++
+		stack = append(stack, v)
+		onStack[v] = true
+
+		for _, w := range adj[v] {
+			if _, visited := index[w]; !visited {
+				strongConnect(w)
+				low[v] = min(low[v], low[w])
+			} else if onStack[w] {
+				low[v] = min(low[v], index[w])
+			}
+		}
+
+		if low[v] != index[v] {
+			return
+		}
+		var scc []int
+		for {
+			w := stack[len(stack)-1]
+			stack = stack[:len(stack)-1]
+			onStack[w] = false
+			scc = append(scc, w)
+			if w == v {
+				break
+			}
+		}
+		if len(scc) > 1 || selfLoop[v] {
+			sort.Ints(scc)
+			cycles = append(cycles, scc)
+		}
+	}
+
+	for _, v := range nodes {
+		if _, visited := index[v]; !visited {
+			strongConnect(v)
+		}
+	}
+	return cycles
+}
@@ -0,0 +1,29 @@
+package db
+
+import "time"
+
+// heatmapDays 热力图的行，从周一开始
+var heatmapDays = []string{"Mon", "Tue", "Wed", "Thu", "Fri", "Sat", "Sun"}
+
+// CompletionHeatmap 按星期几和小时统计的完成次数，Cells[0]为周一，Cells[d][h]为该天h点内完成的数量
+type CompletionHeatmap struct {
+	Timezone string     `json:"timezone"`
+	Days     []string   `json:"days"`
+	Cells    [7][24]int `json:"cells"`
+	Total    int        `json:"total"`
+}
+
+// BuildCompletionHeatmap 按CompletedAt在loc中的本地时间统计已完成待办事项，没有完成时间的不计入
+func BuildCompletionHeatmap(todos []Todo, loc *time.Location) CompletionHeatmap {
+	heatmap := CompletionHeatmap{Timezone: loc.String(), Days: heatmapDays}
+	for _, todo := range todos {
+		if todo.Status != "completed" || todo.CompletedAt == nil {
+			continue
+		}
+		local := todo.CompletedAt.In(loc)
+		day := (int(local.Weekday()) + 6) % 7
+		heatmap.Cells[day][local.Hour()]++
+		heatmap.Total++
+	}
+	return heatmap
+}
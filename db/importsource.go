@@ -0,0 +1,59 @@
+package db
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"strings"
+	"time"
+)
+
+// 从URL获取导入数据的超时时间
+const importFetchTimeout = 30 * time.Second
+
+// readImportSource 读取导入数据：http(s)地址通过GET获取，否则视为本地文件路径
+func readImportSource(source string) ([]byte, error) {
+	if !strings.HasPrefix(source, "http://") && !strings.HasPrefix(source, "https://") {
+		data, err := os.ReadFile(source)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read %s: %v", source, err)
+		}
+		return data, nil
+	}
+
+	client := &http.Client{Timeout: importFetchTimeout}
+	resp, err := client.Get(source)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch %s: %v", source, err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("failed to fetch %s: status %d", source, resp.StatusCode)
+	}
+	data, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read response from %s: %v", source, err)
+	}
+	return data, nil
+}
+
+// ImportFromSource 从本地文件或http(s)地址读取数据文件格式（{user_profile, todos}）的数据，
+// 以合并方式导入，不覆盖已有的待办事项和用户配置
+func (d *SQLiteDatabase) ImportFromSource(source string) (*ImportResult, error) {
+	data, err := readImportSource(source)
+	if err != nil {
+		return nil, err
+	}
+
+	var dataStruct DataStructure
+	if err := json.Unmarshal(data, &dataStruct); err != nil {
+		return nil, fmt.Errorf("failed to parse %s: %v", source, err)
+	}
+
+	opts := DefaultImportOptions()
+	opts.Merge = true
+	return d.ImportData(dataStruct, opts)
+}
@@ -0,0 +1,123 @@
+package db
+
+import (
+	"fydeos/config"
+	"sort"
+	"strings"
+	"time"
+)
+
+// InboxCategory 返回配置的收件箱类别名
+func InboxCategory() string {
+	return config.Cfg.InboxCategory
+}
+
+// IsQuickCapture 判断待创建的待办事项是否只有标题（快速收集），这类任务默认放入收件箱
+func IsQuickCapture(todo Todo) bool {
+	return strings.TrimSpace(todo.Description) == "" &&
+		todo.Category == "" &&
+		todo.Priority == "" &&
+		todo.DueDate == nil &&
+		strings.TrimSpace(todo.EstimatedDuration) == "" &&
+		todo.ParentID == nil &&
+		todo.ProjectID == nil
+}
+
+// FilterInbox 返回收件箱类别中未完成的待办事项，保持输入顺序
+func FilterInbox(todos []Todo) []Todo {
+	inbox := []Todo{}
+	for _, todo := range todos {
+		if todo.Category == InboxCategory() && todo.Status != "completed" {
+			inbox = append(inbox, todo)
+		}
+	}
+	return inbox
+}
+
+// InboxSuggestion 为收件箱任务建议的类别、优先级和截止日期，无法推断的字段保持原值
+type InboxSuggestion struct {
+	ID       int        `json:"id"`
+	Title    string     `json:"title"`
+	Category string     `json:"category"`
+	Priority string     `json:"priority"`
+	DueDate  *time.Time `json:"due_date"`
+}
+
+// SuggestInboxTriage 为收件箱中的任务给出整理建议：类别取标题有相同词的其他任务中最常见的类别
+// （没有匹配时为personal），优先级和截止日期从原始输入或标题中解析
+func SuggestInboxTriage(todos []Todo, now time.Time, schedule WorkSchedule) []InboxSuggestion {
+	suggestions := []InboxSuggestion{}
+	for _, todo := range FilterInbox(todos) {
+		s := InboxSuggestion{
+			ID:       todo.ID,
+			Title:    todo.Title,
+			Category: suggestCategory(todos, todo.Title),
+			Priority: todo.Priority,
+			DueDate:  todo.DueDate,
+		}
+
+		input := todo.RawInput
+		if strings.TrimSpace(input) == "" {
+			input = todo.Title
+		}
+		parsed := ParseRawInput(input, now, schedule)
+		if parsed.Priority != "" {
+			s.Priority = parsed.Priority
+		}
+		if parsed.DueDate != nil && s.DueDate == nil {
+			s.DueDate = parsed.DueDate
+		}
+		suggestions = append(suggestions, s)
+	}
+	return suggestions
+}
+
+// ApplyInboxSuggestions 在一个事务中将分拣建议的类别、优先级和截止日期写入对应的待办事项，任一项失败时全部不生效
+func (d *SQLiteDatabase) ApplyInboxSuggestions(suggestions []InboxSuggestion, source string) error {
+	ids := make([]int, len(suggestions))
+	for i, s := range suggestions {
+		ids[i] = s.ID
+	}
+	return d.updateTodos(ids, func(i int, todo *Todo) {
+		todo.Category = suggestions[i].Category
+		todo.Priority = suggestions[i].Priority
+		todo.DueDate = suggestions[i].DueDate
+		todo.Source = source
+	})
+}
+
+// suggestCategory 统计标题与title有相同词的非收件箱任务的类别，返回出现最多的一个（相同时按名称）
+func suggestCategory(todos []Todo, title string) string {
+	words := map[string]bool{}
+	for _, w := range splitWords(strings.ToLower(title)) {
+		words[w] = true
+	}
+
+	counts := map[string]int{}
+	for _, todo := range todos {
+		if todo.Category == "" || todo.Category == InboxCategory() {
+			continue
+		}
+		for _, w := range splitWords(strings.ToLower(todo.Title)) {
+			if words[w] {
+				counts[todo.Category]++
+				break
+			}
+		}
+	}
+	if len(counts) == 0 {
+		return "personal"
+	}
+
+	categories := make([]string, 0, len(counts))
+	for c := range counts {
+		categories = append(categories, c)
+	}
+	sort.Slice(categories, func(i, j int) bool {
+		if counts[categories[i]] != counts[categories[j]] {
+			return counts[categories[i]] > counts[categories[j]]
+		}
+		return categories[i] < categories[j]
+	})
+	return categories[0]
+}
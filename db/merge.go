@@ -0,0 +1,148 @@
+package db
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"log"
+	"strings"
+	"time"
+)
+
+// ErrMergeSelf 合并的两个待办事项ID相同
+var ErrMergeSelf = errors.New("cannot merge a todo into itself")
+
+// 合并描述时使用的分隔符
+const mergeDescriptionSeparator = "\n\n---\n\n"
+
+// MergeTodos 在一个事务中将secondary合并到primary：保留较早的创建日期，合并清单（文本相同的项只保留一项，
+// 任一方已完成即为已完成）和标签，用分隔符拼接描述，primary没有截止日期时沿用secondary的；
+// secondary的子任务和依赖关系转移到primary，最后删除secondary。返回合并后的primary
+func (d *SQLiteDatabase) MergeTodos(primaryID, secondaryID int, source string) (*Todo, error) {
+	if primaryID == secondaryID {
+		return nil, ErrMergeSelf
+	}
+
+	tx, err := d.db.Begin()
+	if err != nil {
+		return nil, fmt.Errorf("failed to begin transaction: %v", err)
+	}
+
+	before, merged, err := mergeTodosTx(tx, primaryID, secondaryID, source)
+	if err != nil {
+		tx.Rollback()
+		return nil, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf("failed to commit transaction: %v", err)
+	}
+	if err := d.recordAudit(before, merged, merged.LastUpdated); err != nil {
+		log.Printf("Warning: %v", err)
+	}
+	return merged, nil
+}
+
+func mergeTodosTx(tx *tracedTx, primaryID, secondaryID int, source string) (before, merged *Todo, err error) {
+	primary, err := getTodoTx(tx, primaryID)
+	if err != nil {
+		return nil, nil, err
+	}
+	secondary, err := getTodoTx(tx, secondaryID)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	result := mergeTodo(*primary, *secondary)
+	result.LastUpdated = time.Now()
+	result.Source = source
+
+	_, err = tx.Exec(
+		"UPDATE todos SET created_date = ?, description = ?, checklist = ?, tags = ?, due_date = ?, parent_id = ?, last_updated = ?, source = ? WHERE id = ?",
+		result.CreatedDate, result.Description, result.Checklist, result.Tags, result.DueDate, result.ParentID, result.LastUpdated, result.Source, result.ID,
+	)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to update primary todo: %v", err)
+	}
+
+	if _, err := tx.Exec("UPDATE todos SET parent_id = ? WHERE parent_id = ? AND id != ?", primaryID, secondaryID, primaryID); err != nil {
+		return nil, nil, fmt.Errorf("failed to reparent subtasks: %v", err)
+	}
+
+	// 转移依赖关系，已存在相同的依赖时忽略，最后清理自依赖和指向secondary的残留记录
+	for _, stmt := range []string{
+		"UPDATE OR IGNORE todo_dependencies SET todo_id = ? WHERE todo_id = ?",
+		"UPDATE OR IGNORE todo_dependencies SET depends_on_id = ? WHERE depends_on_id = ?",
+	} {
+		if _, err := tx.Exec(stmt, primaryID, secondaryID); err != nil {
+			return nil, nil, fmt.Errorf("failed to move dependencies: %v", err)
+		}
+	}
+	if _, err := tx.Exec(
+		"DELETE FROM todo_dependencies WHERE todo_id = depends_on_id OR todo_id = ? OR depends_on_id = ?",
+		secondaryID, secondaryID,
+	); err != nil {
+		return nil, nil, fmt.Errorf("failed to clean up dependencies: %v", err)
+	}
+
+	if _, err := tx.Exec("DELETE FROM todos WHERE id = ?", secondaryID); err != nil {
+		return nil, nil, fmt.Errorf("failed to delete secondary todo: %v", err)
+	}
+	return primary, &result, nil
+}
+
+// mergeTodo 计算合并后的primary，不修改参数
+func mergeTodo(primary, secondary Todo) Todo {
+	merged := primary
+	if secondary.CreatedDate.Before(merged.CreatedDate) {
+		merged.CreatedDate = secondary.CreatedDate
+	}
+
+	switch {
+	case strings.TrimSpace(secondary.Description) == "":
+	case strings.TrimSpace(merged.Description) == "":
+		merged.Description = secondary.Description
+	default:
+		merged.Description += mergeDescriptionSeparator + secondary.Description
+	}
+
+	merged.Checklist = append(Checklist{}, primary.Checklist...)
+	index := map[string]int{}
+	for i, item := range merged.Checklist {
+		index[checklistKey(item.Text)] = i
+	}
+	for _, item := range secondary.Checklist {
+		if i, ok := index[checklistKey(item.Text)]; ok {
+			merged.Checklist[i].Done = merged.Checklist[i].Done || item.Done
+			continue
+		}
+		index[checklistKey(item.Text)] = len(merged.Checklist)
+		merged.Checklist = append(merged.Checklist, item)
+	}
+	merged.ChecklistProgress = merged.Checklist.Progress()
+	merged.Tags = normalizeTags(append(append(Tags{}, primary.Tags...), secondary.Tags...))
+	merged.AutoTags = AutoTagsFor(merged)
+
+	if merged.DueDate == nil {
+		merged.DueDate = secondary.DueDate
+	}
+	// primary原是secondary的子任务时，改为挂到secondary的父任务下
+	if merged.ParentID != nil && *merged.ParentID == secondary.ID {
+		merged.ParentID = secondary.ParentID
+	}
+	return merged
+}
+
+func checklistKey(text string) string {
+	return strings.ToLower(strings.TrimSpace(text))
+}
+
+func getTodoTx(tx *tracedTx, id int) (*Todo, error) {
+	todo, err := scanTodo(tx.QueryRow("SELECT "+todoColumns+" FROM todos WHERE id = ?", id))
+	if err == sql.ErrNoRows {
+		return nil, fmt.Errorf("todo with ID %d not found", id)
+	} else if err != nil {
+		return nil, fmt.Errorf("failed to get todo: %v", err)
+	}
+	return &todo, nil
+}
@@ -0,0 +1,86 @@
+package db
+
+import (
+	"fmt"
+	"fydeos/config"
+	"strings"
+)
+
+// 可检查是否缺失的元数据字段
+const (
+	MetadataDueDate     = "due_date"
+	MetadataEstimate    = "estimated_duration"
+	MetadataDescription = "description"
+)
+
+// MetadataFields 支持检查的全部字段
+var MetadataFields = []string{MetadataDueDate, MetadataEstimate, MetadataDescription}
+
+// IncompleteTodo 缺少元数据的待办事项及其缺失的字段
+type IncompleteTodo struct {
+	Todo
+	Missing []string `json:"missing_fields"`
+}
+
+// ParseMetadataFields 解析逗号分隔的字段列表，为空时使用INCOMPLETE_METADATA_FIELDS的配置
+func ParseMetadataFields(s string) ([]string, error) {
+	if strings.TrimSpace(s) == "" {
+		return config.Cfg.IncompleteMetadataFields, nil
+	}
+
+	var fields []string
+	for _, field := range strings.Split(s, ",") {
+		field = strings.TrimSpace(field)
+		if field == "" {
+			continue
+		}
+		if !isMetadataField(field) {
+			return nil, fmt.Errorf("unknown field %q, expected one of %s", field, strings.Join(MetadataFields, ", "))
+		}
+		fields = append(fields, field)
+	}
+	return fields, nil
+}
+
+func isMetadataField(field string) bool {
+	for _, f := range MetadataFields {
+		if f == field {
+			return true
+		}
+	}
+	return false
+}
+
+// MissingMetadata 返回todo在fields中缺失的字段
+func MissingMetadata(todo Todo, fields []string) []string {
+	var missing []string
+	for _, field := range fields {
+		var empty bool
+		switch field {
+		case MetadataDueDate:
+			empty = todo.DueDate == nil
+		case MetadataEstimate:
+			empty = strings.TrimSpace(todo.EstimatedDuration) == ""
+		case MetadataDescription:
+			empty = strings.TrimSpace(todo.Description) == ""
+		}
+		if empty {
+			missing = append(missing, field)
+		}
+	}
+	return missing
+}
+
+// FindIncompleteMetadata 返回未完成且缺少fields中任一字段的待办事项，保持输入顺序
+func FindIncompleteMetadata(todos []Todo, fields []string) []IncompleteTodo {
+	result := []IncompleteTodo{}
+	for _, todo := range todos {
+		if todo.Status == "completed" {
+			continue
+		}
+		if missing := MissingMetadata(todo, fields); len(missing) > 0 {
+			result = append(result, IncompleteTodo{Todo: todo, Missing: missing})
+		}
+	}
+	return result
+}
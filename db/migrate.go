@@ -0,0 +1,115 @@
+package db
+
+import (
+	"fmt"
+)
+
+// 增量迁移：为已有的todos.db补充新增列，不影响已有数据
+var todoColumnMigrations = []struct {
+	name       string
+	definition string
+}{
+	{"last_nudged", "TIMESTAMP NULL"},
+	{"completed_at", "TIMESTAMP NULL"},
+	{"parent_id", "INTEGER NULL"},
+	{"checklist", "TEXT NULL"},
+	{"waiting_on", "TEXT NOT NULL DEFAULT ''"},
+	{"scheduled_start", "TIMESTAMP NULL"},
+	{"scheduled_end", "TIMESTAMP NULL"},
+	{"all_day", "BOOLEAN NOT NULL DEFAULT 0"},
+	{"is_starred", "BOOLEAN NOT NULL DEFAULT 0"},
+	{"source", "TEXT NOT NULL DEFAULT ''"},
+	{"recurrence", "TEXT NOT NULL DEFAULT ''"},
+	{"project_id", "INTEGER NULL"},
+	{"raw_input", "TEXT NOT NULL DEFAULT ''"},
+	{"remind_before", "INTEGER NULL"},
+	{"last_reminded_for", "TIMESTAMP NULL"},
+	{"is_overdue", "BOOLEAN NOT NULL DEFAULT 0"},
+	{"is_stale", "BOOLEAN NOT NULL DEFAULT 0"},
+	{"reminder_message", "TEXT NOT NULL DEFAULT ''"},
+	{"external_system", "TEXT NOT NULL DEFAULT ''"},
+	{"external_id", "TEXT NOT NULL DEFAULT ''"},
+	{"external_url", "TEXT NOT NULL DEFAULT ''"},
+	{"tags", "TEXT NULL"},
+	{"energy_level", "TEXT NOT NULL DEFAULT ''"},
+	{"effort_points", "INTEGER NOT NULL DEFAULT 0"},
+	{"auto_close_warned_at", "TIMESTAMP NULL"},
+	{"next_occurrence_id", "INTEGER NULL"},
+}
+
+var projectColumnMigrations = []struct {
+	name       string
+	definition string
+}{
+	{"archived_at", "TIMESTAMP NULL"},
+	{"keep_active", "BOOLEAN NOT NULL DEFAULT 0"},
+}
+
+func (d *SQLiteDatabase) migrate() error {
+	for _, col := range todoColumnMigrations {
+		if err := d.ensureColumn("todos", col.name, col.definition); err != nil {
+			return err
+		}
+	}
+
+	if _, err := d.db.Exec(flagIndexes); err != nil {
+		return fmt.Errorf("failed to create flag indexes: %v", err)
+	}
+	if _, err := d.db.Exec(externalRefIndex); err != nil {
+		return fmt.Errorf("failed to create external ref index: %v", err)
+	}
+
+	if _, err := d.db.Exec(auditTable); err != nil {
+		return fmt.Errorf("failed to create todo_audit table: %v", err)
+	}
+	if _, err := d.db.Exec(projectsTable); err != nil {
+		return fmt.Errorf("failed to create projects table: %v", err)
+	}
+	if _, err := d.db.Exec(dependenciesTable); err != nil {
+		return fmt.Errorf("failed to create todo_dependencies table: %v", err)
+	}
+	if _, err := d.db.Exec(focusTable); err != nil {
+		return fmt.Errorf("failed to create user_focus table: %v", err)
+	}
+	for _, col := range projectColumnMigrations {
+		if err := d.ensureColumn("projects", col.name, col.definition); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// ensureColumn 当表中不存在该列时添加
+func (d *SQLiteDatabase) ensureColumn(table, column, definition string) error {
+	rows, err := d.db.Query(fmt.Sprintf("PRAGMA table_info(%s)", table))
+	if err != nil {
+		return fmt.Errorf("failed to inspect table %s: %v", table, err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var (
+			cid       int
+			name      string
+			colType   string
+			notNull   int
+			dfltValue interface{}
+			pk        int
+		)
+		if err := rows.Scan(&cid, &name, &colType, &notNull, &dfltValue, &pk); err != nil {
+			return fmt.Errorf("failed to scan table info: %v", err)
+		}
+		if name == column {
+			return nil
+		}
+	}
+	if err := rows.Err(); err != nil {
+		return fmt.Errorf("error iterating table info: %v", err)
+	}
+	rows.Close()
+
+	if _, err := d.db.Exec(fmt.Sprintf("ALTER TABLE %s ADD COLUMN %s %s", table, column, definition)); err != nil {
+		return fmt.Errorf("failed to add column %s.%s: %v", table, column, err)
+	}
+	return nil
+}
@@ -0,0 +1,36 @@
+package db
+
+import (
+	"fmt"
+	"time"
+)
+
+// GetNudgeCandidates 获取需要提醒的陈旧任务：
+// 未完成、在staleBefore之前最后更新，且从未提醒或上次提醒早于nudgedBefore
+func (d *SQLiteDatabase) GetNudgeCandidates(staleBefore, nudgedBefore time.Time) ([]Todo, error) {
+	return d.queryTodos(
+		"SELECT "+todoColumns+" FROM todos WHERE status != 'completed' AND last_updated < ? AND (last_nudged IS NULL OR last_nudged < ?) ORDER BY last_updated",
+		staleBefore,
+		nudgedBefore,
+	)
+}
+
+// MarkNudged 记录任务的提醒时间并写入（可能调整后的）优先级
+// 不修改last_updated，避免提醒本身重置任务的陈旧状态
+func (d *SQLiteDatabase) MarkNudged(id int, priority string, at time.Time) error {
+	result, err := d.db.Exec("UPDATE todos SET last_nudged = ?, priority = ? WHERE id = ?", at, priority, id)
+	if err != nil {
+		return fmt.Errorf("failed to mark todo as nudged: %v", err)
+	}
+
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("error checking affected rows: %v", err)
+	}
+
+	if affected == 0 {
+		return fmt.Errorf("todo with ID %d not found", id)
+	}
+
+	return nil
+}
@@ -0,0 +1,75 @@
+package db
+
+import (
+	"fmt"
+	"time"
+)
+
+// ScheduledTask 被选入日程的任务及入选原因
+type ScheduledTask struct {
+	Todo Todo `json:"todo"`
+	// Rank 在全部候选任务排序后的位置，从1开始
+	Rank    int      `json:"rank"`
+	Reasons []string `json:"reasons"`
+}
+
+// ScheduleResult 日程优化结果，Excluded为因数量上限未被选入的任务
+type ScheduleResult struct {
+	Selected []ScheduledTask `json:"optimized_tasks"`
+	Excluded []ScheduledTask `json:"excluded_tasks"`
+	Limit    int             `json:"limit"`
+	Strategy SortStrategy    `json:"strategy"`
+}
+
+// OptimizeSchedule 选出自己负责的未完成高优先级任务（不含已委派的），按strategy排序后最多保留limit个
+func OptimizeSchedule(todos []Todo, now time.Time, limit int, grace time.Duration, strategy SortStrategy) ScheduleResult {
+	var candidates []Todo
+	for _, todo := range todos {
+		if (todo.Status == "pending" || todo.Status == "in_progress") &&
+			(todo.Priority == "urgent" || todo.Priority == "high") && !todo.IsDelegated() {
+			candidates = append(candidates, todo)
+		}
+	}
+
+	SortTodos(candidates, strategy)
+
+	result := ScheduleResult{
+		Selected: []ScheduledTask{},
+		Excluded: []ScheduledTask{},
+		Limit:    limit,
+		Strategy: strategy,
+	}
+	for i, todo := range candidates {
+		task := ScheduledTask{Todo: todo, Rank: i + 1, Reasons: scheduleReasons(todo, now, grace)}
+		if i < limit {
+			result.Selected = append(result.Selected, task)
+		} else {
+			task.Reasons = append(task.Reasons, fmt.Sprintf("超出数量上限%d", limit))
+			result.Excluded = append(result.Excluded, task)
+		}
+	}
+	return result
+}
+
+// scheduleReasons 说明任务的优先级和截止日期远近
+func scheduleReasons(todo Todo, now time.Time, grace time.Duration) []string {
+	reasons := []string{"优先级: " + todo.Priority}
+	return append(reasons, dueProximity(todo, now, grace))
+}
+
+// dueProximity 描述截止日期的远近
+func dueProximity(todo Todo, now time.Time, grace time.Duration) string {
+	switch {
+	case todo.DueDate == nil:
+		return "无截止日期"
+	case IsOverdue(todo, now, grace):
+		return "已过期"
+	case todo.Deadline(now.Location()).Before(now):
+		return "已到期（宽限期内）"
+	case todo.DueDate.Format("2006-01-02") == now.Format("2006-01-02"):
+		return "今天到期"
+	default:
+		days := int(todo.DueDate.Sub(now).Hours()/24) + 1
+		return fmt.Sprintf("%d天内到期", days)
+	}
+}
@@ -0,0 +1,21 @@
+package db
+
+import "time"
+
+// IsOverdue 未完成且now已超过截止时间加宽限期时视为过期。
+// 全天任务的截止时间为截止日期（按now所在时区）当天结束
+func IsOverdue(todo Todo, now time.Time, grace time.Duration) bool {
+	if todo.Status == "completed" || todo.DueDate == nil {
+		return false
+	}
+	return now.After(todo.Deadline(now.Location()).Add(grace))
+}
+
+// Deadline 返回实际的截止时间：定时任务为DueDate，全天任务为截止日期在loc中次日零点前的最后时刻
+func (t Todo) Deadline(loc *time.Location) time.Time {
+	if !t.AllDay {
+		return *t.DueDate
+	}
+	due := t.DueDate.In(loc)
+	return time.Date(due.Year(), due.Month(), due.Day()+1, 0, 0, 0, 0, loc).Add(-time.Nanosecond)
+}
@@ -0,0 +1,68 @@
+package db
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// readOnlyPatchFields 由服务器维护、不能通过部分更新修改的字段
+var readOnlyPatchFields = map[string]bool{
+	"id":                 true,
+	"created_date":       true,
+	"last_updated":       true,
+	"completed_at":       true,
+	"source":             true,
+	"checklist_progress": true,
+	"auto_tags":          true,
+	"next_occurrence_id": true,
+}
+
+// ApplyPatch 将patch中出现的字段（JSON字段名）覆盖到todo上，未出现的字段保持原值。
+// 显式传null会清空可为空的字段（如due_date）；只读字段和未知字段返回错误，todo不做修改
+func ApplyPatch(todo *Todo, patch map[string]json.RawMessage) error {
+	fields, err := toRawFieldMap(todo)
+	if err != nil {
+		return err
+	}
+
+	var unknown []string
+	for key, value := range patch {
+		if readOnlyPatchFields[key] {
+			return fmt.Errorf("field %q is read-only", key)
+		}
+		if _, ok := fields[key]; !ok {
+			unknown = append(unknown, key)
+			continue
+		}
+		fields[key] = value
+	}
+	if len(unknown) > 0 {
+		sort.Strings(unknown)
+		return fmt.Errorf("unknown fields: %s", strings.Join(unknown, ", "))
+	}
+
+	data, err := json.Marshal(fields)
+	if err != nil {
+		return fmt.Errorf("failed to apply patch: %v", err)
+	}
+	var patched Todo
+	if err := json.Unmarshal(data, &patched); err != nil {
+		return fmt.Errorf("invalid patch: %v", err)
+	}
+	*todo = patched
+	return nil
+}
+
+func toRawFieldMap(todo *Todo) (map[string]json.RawMessage, error) {
+	data, err := json.Marshal(todo)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal todo: %v", err)
+	}
+	fields := map[string]json.RawMessage{}
+	if err := json.Unmarshal(data, &fields); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal todo: %v", err)
+	}
+	return fields, nil
+}
@@ -0,0 +1,68 @@
+package db
+
+import (
+	"fmt"
+	"time"
+)
+
+// Preset list_todos的快捷筛选预设
+type Preset string
+
+const (
+	PresetNone         Preset = ""
+	PresetActive       Preset = "active"        // pending或in_progress
+	PresetAttention    Preset = "attention"     // 未完成且已过期、urgent或今天到期
+	PresetDoneRecently Preset = "done_recently" // 最近7天内完成
+)
+
+// done_recently回看的时长
+const doneRecentlyWindow = 7 * 24 * time.Hour
+
+// ParsePreset 解析筛选预设，为空表示不筛选
+func ParsePreset(s string) (Preset, error) {
+	switch p := Preset(s); p {
+	case PresetNone, PresetActive, PresetAttention, PresetDoneRecently:
+		return p, nil
+	}
+	return PresetNone, fmt.Errorf("invalid preset %q, expected active, attention or done_recently", s)
+}
+
+// FilterByPreset 返回符合预设的待办事项，保持输入顺序；"今天"按now所在时区计算
+func FilterByPreset(todos []Todo, preset Preset, now time.Time, grace time.Duration) []Todo {
+	if preset == PresetNone {
+		return todos
+	}
+
+	filtered := []Todo{}
+	for _, todo := range todos {
+		if matchesPreset(todo, preset, now, grace) {
+			filtered = append(filtered, todo)
+		}
+	}
+	return filtered
+}
+
+func matchesPreset(todo Todo, preset Preset, now time.Time, grace time.Duration) bool {
+	switch preset {
+	case PresetActive:
+		return todo.Status == "pending" || todo.Status == "in_progress"
+	case PresetAttention:
+		if todo.Status == "completed" {
+			return false
+		}
+		return todo.Priority == "urgent" || IsOverdue(todo, now, grace) || dueOn(todo, now)
+	case PresetDoneRecently:
+		return todo.Status == "completed" && !completedTime(todo).Before(now.Add(-doneRecentlyWindow))
+	}
+	return true
+}
+
+// dueOn 截止日期是否与now在now所在时区的同一天
+func dueOn(todo Todo, now time.Time) bool {
+	if todo.DueDate == nil {
+		return false
+	}
+	y1, m1, d1 := todo.Deadline(now.Location()).Date()
+	y2, m2, d2 := now.Date()
+	return y1 == y2 && m1 == m2 && d1 == d2
+}
@@ -0,0 +1,99 @@
+package db
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"time"
+)
+
+// 推迟次数达到该值的任务附带处理建议
+const procrastinationSuggestAt = 3
+
+// 推迟建议
+const (
+	SuggestBreakDown = "break_down" // 拆分为更小的子任务
+	SuggestDelete    = "delete"     // 低优先级，考虑直接删除
+)
+
+// ProcrastinatedTodo 被反复推迟的未完成待办事项
+type ProcrastinatedTodo struct {
+	Todo
+	// Postponements 截止日期或排期开始时间被推后的次数
+	Postponements int    `json:"postponements"`
+	Suggestion    string `json:"suggestion,omitempty"`
+}
+
+// postponeSnapshot 审计快照中判断推迟所需的字段
+type postponeSnapshot struct {
+	DueDate        *time.Time `json:"due_date"`
+	ScheduledStart *time.Time `json:"scheduled_start"`
+}
+
+func postponed(before, after *time.Time) bool {
+	return before != nil && after != nil && after.After(*before)
+}
+
+// GetPostponeCounts 从审计日志统计每个待办事项截止日期或排期被推后的次数，
+// 同一次修改同时推后两者只计一次
+func (d *SQLiteDatabase) GetPostponeCounts() (map[int]int, error) {
+	rows, err := d.db.Query("SELECT todo_id, before_json, after_json FROM todo_audit")
+	if err != nil {
+		return nil, fmt.Errorf("failed to query audit log: %v", err)
+	}
+	defer rows.Close()
+
+	counts := map[int]int{}
+	for rows.Next() {
+		var id int
+		var beforeJSON, afterJSON string
+		if err := rows.Scan(&id, &beforeJSON, &afterJSON); err != nil {
+			return nil, fmt.Errorf("failed to scan audit entry: %v", err)
+		}
+		var before, after postponeSnapshot
+		if err := json.Unmarshal([]byte(beforeJSON), &before); err != nil {
+			return nil, fmt.Errorf("failed to parse audit snapshot: %v", err)
+		}
+		if err := json.Unmarshal([]byte(afterJSON), &after); err != nil {
+			return nil, fmt.Errorf("failed to parse audit snapshot: %v", err)
+		}
+		if postponed(before.DueDate, after.DueDate) || postponed(before.ScheduledStart, after.ScheduledStart) {
+			counts[id]++
+		}
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating audit log: %v", err)
+	}
+	return counts, nil
+}
+
+// RankProcrastinated 返回推迟次数最多的limit个未完成待办事项，次数相同时创建较早的在前。
+// 从未推迟的任务不计入；推迟次数较多的低优先级任务建议删除，其余建议拆分
+func RankProcrastinated(todos []Todo, counts map[int]int, limit int) []ProcrastinatedTodo {
+	ranked := []ProcrastinatedTodo{}
+	for _, todo := range todos {
+		n := counts[todo.ID]
+		if todo.Status == "completed" || n == 0 {
+			continue
+		}
+		item := ProcrastinatedTodo{Todo: todo, Postponements: n}
+		if n >= procrastinationSuggestAt {
+			item.Suggestion = SuggestBreakDown
+			if todo.Priority == "low" {
+				item.Suggestion = SuggestDelete
+			}
+		}
+		ranked = append(ranked, item)
+	}
+
+	sort.SliceStable(ranked, func(i, j int) bool {
+		if ranked[i].Postponements != ranked[j].Postponements {
+			return ranked[i].Postponements > ranked[j].Postponements
+		}
+		return ranked[i].CreatedDate.Before(ranked[j].CreatedDate)
+	})
+	if limit > 0 && len(ranked) > limit {
+		ranked = ranked[:limit]
+	}
+	return ranked
+}
@@ -0,0 +1,21 @@
+package db
+
+// progressStatus 根据清单进度的变化推导状态：进度达到100%时为completed，
+// 从0开始推进且当前为pending时为in_progress，重置为0时为pending。
+// 进度未变化或清单为空时保持原状态
+func progressStatus(status string, before float64, after Checklist) string {
+	progress := after.Progress()
+	if len(after) == 0 || progress == before {
+		return status
+	}
+
+	switch {
+	case progress >= 1:
+		return "completed"
+	case progress == 0:
+		return "pending"
+	case before == 0 && status == "pending":
+		return "in_progress"
+	}
+	return status
+}
@@ -0,0 +1,210 @@
+package db
+
+import (
+	"database/sql"
+	"fmt"
+	"time"
+)
+
+// 项目表：待办事项通过project_id归属到项目，逐步取代category
+const projectsTable = `CREATE TABLE IF NOT EXISTS projects (
+	id INTEGER PRIMARY KEY AUTOINCREMENT,
+	name TEXT NOT NULL UNIQUE,
+	created_at TIMESTAMP NOT NULL
+);`
+
+// Project 项目
+type Project struct {
+	ID         int        `json:"id"`
+	Name       string     `json:"name"`
+	CreatedAt  time.Time  `json:"created_at"`
+	ArchivedAt *time.Time `json:"archived_at"`
+	KeepActive bool       `json:"keep_active"` // 手动恢复后不再被自动归档
+}
+
+// CategoryMigration 类别迁移到项目的结果
+type CategoryMigration struct {
+	Project        Project `json:"project"`
+	ProjectCreated bool    `json:"project_created"`
+	Moved          int64   `json:"moved"`
+}
+
+// MigrateCategoryToProject 在一个事务中按名称查找或创建项目，并将category下的所有待办事项归属到该项目
+func (d *SQLiteDatabase) MigrateCategoryToProject(category, projectName, source string) (*CategoryMigration, error) {
+	tx, err := d.db.Begin()
+	if err != nil {
+		return nil, fmt.Errorf("failed to begin transaction: %v", err)
+	}
+
+	result, err := migrateCategoryTx(tx, category, projectName, source)
+	if err != nil {
+		tx.Rollback()
+		return nil, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf("failed to commit transaction: %v", err)
+	}
+	return result, nil
+}
+
+func migrateCategoryTx(tx *tracedTx, category, projectName, source string) (*CategoryMigration, error) {
+	var result CategoryMigration
+	var err error
+	result.Project, err = scanProject(tx.QueryRow("SELECT "+projectColumns+" FROM projects WHERE name = ?", projectName))
+	if err == sql.ErrNoRows {
+		now := time.Now()
+		res, err := tx.Exec("INSERT INTO projects (name, created_at) VALUES (?, ?)", projectName, now)
+		if err != nil {
+			return nil, fmt.Errorf("failed to create project: %v", err)
+		}
+		id, err := res.LastInsertId()
+		if err != nil {
+			return nil, fmt.Errorf("failed to create project: %v", err)
+		}
+		result.Project = Project{ID: int(id), Name: projectName, CreatedAt: now}
+		result.ProjectCreated = true
+	} else if err != nil {
+		return nil, fmt.Errorf("failed to get project: %v", err)
+	}
+
+	res, err := tx.Exec(
+		"UPDATE todos SET project_id = ?, last_updated = ?, source = ? WHERE category = ?",
+		result.Project.ID, time.Now(), source, category,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to assign todos to project: %v", err)
+	}
+	if result.Moved, err = res.RowsAffected(); err != nil {
+		return nil, fmt.Errorf("failed to assign todos to project: %v", err)
+	}
+	return &result, nil
+}
+
+const projectColumns = "id, name, created_at, archived_at, keep_active"
+
+func scanProject(row rowScanner) (Project, error) {
+	var p Project
+	var archivedAt sql.NullTime
+	if err := row.Scan(&p.ID, &p.Name, &p.CreatedAt, &archivedAt, &p.KeepActive); err != nil {
+		return p, err
+	}
+	if archivedAt.Valid {
+		p.ArchivedAt = &archivedAt.Time
+	}
+	return p, nil
+}
+
+// GetProjects 返回项目列表，默认不含已归档的项目
+func (d *SQLiteDatabase) GetProjects(includeArchived bool) ([]Project, error) {
+	query := "SELECT " + projectColumns + " FROM projects"
+	if !includeArchived {
+		query += " WHERE archived_at IS NULL"
+	}
+	rows, err := d.db.Query(query + " ORDER BY name")
+	if err != nil {
+		return nil, fmt.Errorf("failed to query projects: %v", err)
+	}
+	defer rows.Close()
+
+	projects := []Project{}
+	for rows.Next() {
+		p, err := scanProject(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan project: %v", err)
+		}
+		projects = append(projects, p)
+	}
+	return projects, rows.Err()
+}
+
+// GetProjectByID 根据ID获取项目
+func (d *SQLiteDatabase) GetProjectByID(id int) (*Project, error) {
+	p, err := scanProject(d.db.QueryRow("SELECT "+projectColumns+" FROM projects WHERE id = ?", id))
+	if err == sql.ErrNoRows {
+		return nil, fmt.Errorf("project with ID %d not found", id)
+	} else if err != nil {
+		return nil, fmt.Errorf("failed to get project: %v", err)
+	}
+	return &p, nil
+}
+
+// SetProjectArchived 手动归档或恢复项目。恢复的项目标记为keep_active，不再被自动归档；
+// 手动归档会清除该标记
+func (d *SQLiteDatabase) SetProjectArchived(id int, archived bool) (*Project, error) {
+	var err error
+	if archived {
+		_, err = d.db.Exec("UPDATE projects SET archived_at = ?, keep_active = 0 WHERE id = ?", time.Now(), id)
+	} else {
+		_, err = d.db.Exec("UPDATE projects SET archived_at = NULL, keep_active = 1 WHERE id = ?", id)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to update project: %v", err)
+	}
+	return d.GetProjectByID(id)
+}
+
+// InactiveProjects 返回可以自动归档的项目：未归档、未标记keep_active，至少有一个待办事项，
+// 且所有待办事项均已完成、最近一次更新早于now-after
+func InactiveProjects(projects []Project, todos []Todo, now time.Time, after time.Duration) []Project {
+	type activity struct {
+		total, open int
+		lastUpdated time.Time
+	}
+	byProject := map[int]*activity{}
+	for _, todo := range todos {
+		if todo.ProjectID == nil {
+			continue
+		}
+		a, ok := byProject[*todo.ProjectID]
+		if !ok {
+			a = &activity{}
+			byProject[*todo.ProjectID] = a
+		}
+		a.total++
+		if todo.Status != "completed" {
+			a.open++
+		}
+		if todo.LastUpdated.After(a.lastUpdated) {
+			a.lastUpdated = todo.LastUpdated
+		}
+	}
+
+	cutoff := now.Add(-after)
+	inactive := []Project{}
+	for _, p := range projects {
+		if p.ArchivedAt != nil || p.KeepActive {
+			continue
+		}
+		a, ok := byProject[p.ID]
+		if !ok || a.open > 0 || !a.lastUpdated.Before(cutoff) {
+			continue
+		}
+		inactive = append(inactive, p)
+	}
+	return inactive
+}
+
+// AutoArchiveProjects 将InactiveProjects选出的项目归档，归档时间为now
+func (d *SQLiteDatabase) AutoArchiveProjects(now time.Time, after time.Duration) ([]Project, error) {
+	projects, err := d.GetProjects(false)
+	if err != nil {
+		return nil, err
+	}
+	todos, err := d.GetAllTodos()
+	if err != nil {
+		return nil, err
+	}
+
+	archived := InactiveProjects(projects, todos, now, after)
+	for i := range archived {
+		if _, err := d.db.Exec(
+			"UPDATE projects SET archived_at = ? WHERE id = ? AND archived_at IS NULL AND keep_active = 0",
+			now, archived[i].ID,
+		); err != nil {
+			return nil, fmt.Errorf("failed to archive project: %v", err)
+		}
+		archived[i].ArchivedAt = &now
+	}
+	return archived, nil
+}
@@ -0,0 +1,101 @@
+package db
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"sort"
+	"strings"
+)
+
+// todoFieldNames Todo可投影的字段，与JSON字段名一致
+var todoFieldNames = jsonFieldNames(reflect.TypeOf(Todo{}))
+
+func jsonFieldNames(t reflect.Type) map[string]bool {
+	names := map[string]bool{}
+	for i := 0; i < t.NumField(); i++ {
+		name, _, _ := strings.Cut(t.Field(i).Tag.Get("json"), ",")
+		if name != "" && name != "-" {
+			names[name] = true
+		}
+	}
+	return names
+}
+
+// ParseFields 解析逗号分隔的字段投影，字段名须为Todo的JSON字段或extra中的字段；为空时返回nil表示不投影
+func ParseFields(s string, extra ...string) ([]string, error) {
+	if strings.TrimSpace(s) == "" {
+		return nil, nil
+	}
+
+	var fields []string
+	for _, field := range strings.Split(s, ",") {
+		field = strings.TrimSpace(field)
+		if field == "" {
+			continue
+		}
+		if !todoFieldNames[field] && !containsString(extra, field) {
+			return nil, fmt.Errorf("unknown field %q, expected one of %s", field, strings.Join(fieldNames(extra), ", "))
+		}
+		fields = append(fields, field)
+	}
+	return fields, nil
+}
+
+func fieldNames(extra []string) []string {
+	names := append([]string{}, extra...)
+	for name := range todoFieldNames {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+func containsString(list []string, s string) bool {
+	for _, item := range list {
+		if item == s {
+			return true
+		}
+	}
+	return false
+}
+
+// ProjectFields 只保留v（单个对象或对象数组）中fields列出的字段，fields为空时原样返回
+func ProjectFields(v interface{}, fields []string) (interface{}, error) {
+	if len(fields) == 0 {
+		return v, nil
+	}
+
+	data, err := json.Marshal(v)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal result: %v", err)
+	}
+	var generic interface{}
+	if err := json.Unmarshal(data, &generic); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal result: %v", err)
+	}
+
+	switch g := generic.(type) {
+	case []interface{}:
+		for i, item := range g {
+			g[i] = projectObject(item, fields)
+		}
+		return g, nil
+	default:
+		return projectObject(g, fields), nil
+	}
+}
+
+func projectObject(v interface{}, fields []string) interface{} {
+	obj, ok := v.(map[string]interface{})
+	if !ok {
+		return v
+	}
+	projected := make(map[string]interface{}, len(fields))
+	for _, field := range fields {
+		if value, ok := obj[field]; ok {
+			projected[field] = value
+		}
+	}
+	return projected
+}
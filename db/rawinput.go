@@ -0,0 +1,112 @@
+package db
+
+import (
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// ParsedInput 从自然语言输入中提取的字段，未识别的字段为零值
+type ParsedInput struct {
+	DueDate  *time.Time `json:"due_date"`
+	Priority string     `json:"priority"`
+}
+
+var (
+	inDaysPattern  = regexp.MustCompile(`\bin (\d+) days?\b|(\d+)\s*天后`)
+	weekdayPattern = regexp.MustCompile(`\b(monday|tuesday|wednesday|thursday|friday|saturday|sunday)\b|(?:周|星期|礼拜)([一二三四五六日天])`)
+)
+
+var chineseWeekdays = map[string]time.Weekday{
+	"一": time.Monday, "二": time.Tuesday, "三": time.Wednesday, "四": time.Thursday,
+	"五": time.Friday, "六": time.Saturday, "日": time.Sunday, "天": time.Sunday,
+}
+
+// priorityKeywords 按优先级从高到低匹配
+var priorityKeywords = []struct {
+	priority string
+	words    []string
+}{
+	{"urgent", []string{"urgent", "asap", "紧急", "马上", "立刻"}},
+	{"high", []string{"important", "high priority", "重要"}},
+	{"low", []string{"low priority", "whenever", "不急", "有空"}},
+}
+
+// ParseRawInput 从"remind me to call mom tomorrow"、"周五前交报告，紧急"等输入中提取截止日期和优先级。
+// 识别今天/明天/后天、N天后、下周、本周、星期几；截止时间取当天的下班时间
+func ParseRawInput(input string, now time.Time, schedule WorkSchedule) ParsedInput {
+	text := strings.ToLower(input)
+	var parsed ParsedInput
+
+	for _, p := range priorityKeywords {
+		if containsAny(text, p.words...) {
+			parsed.Priority = p.priority
+			break
+		}
+	}
+
+	if due, ok := parseDueDay(text, now, schedule); ok {
+		parsed.DueDate = &due
+	}
+	return parsed
+}
+
+func parseDueDay(text string, now time.Time, schedule WorkSchedule) (time.Time, bool) {
+	today := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, now.Location())
+	endOn := func(day time.Time) (time.Time, bool) {
+		end, err := schedule.EndOn(day)
+		return end, err == nil
+	}
+
+	switch {
+	case containsAny(text, "day after tomorrow", "后天"):
+		return endOn(today.AddDate(0, 0, 2))
+	case containsAny(text, "tomorrow", "明天"):
+		return endOn(today.AddDate(0, 0, 1))
+	case containsAny(text, "today", "tonight", "今天", "今晚"):
+		return endOn(today)
+	}
+
+	if m := inDaysPattern.FindStringSubmatch(text); m != nil {
+		n, err := strconv.Atoi(m[1] + m[2])
+		if err == nil {
+			return endOn(today.AddDate(0, 0, n))
+		}
+	}
+
+	if m := weekdayPattern.FindStringSubmatch(text); m != nil {
+		var target time.Weekday
+		if m[1] != "" {
+			for d := time.Sunday; d <= time.Saturday; d++ {
+				if strings.EqualFold(d.String(), m[1]) {
+					target = d
+				}
+			}
+		} else {
+			target = chineseWeekdays[m[2]]
+		}
+		// 不含今天，取之后最近的一天
+		days := (int(target)-int(today.Weekday())+6)%7 + 1
+		return endOn(today.AddDate(0, 0, days))
+	}
+
+	switch {
+	case containsAny(text, "next week", "下周"):
+		end, err := schedule.EndOfWeek(today.AddDate(0, 0, 7))
+		return end, err == nil
+	case containsAny(text, "this week", "end of week", "本周", "这周"):
+		end, err := schedule.EndOfWeek(now)
+		return end, err == nil
+	}
+	return time.Time{}, false
+}
+
+func containsAny(s string, subs ...string) bool {
+	for _, sub := range subs {
+		if strings.Contains(s, sub) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,126 @@
+package db
+
+import (
+	"errors"
+	"fmt"
+	"time"
+)
+
+// Recurrence 待办事项的重复规则，为空表示不重复
+type Recurrence string
+
+const (
+	RecurNone     Recurrence = ""
+	RecurDaily    Recurrence = "daily"
+	RecurWeekdays Recurrence = "weekdays" // 周一至周五
+	RecurWeekly   Recurrence = "weekly"
+	RecurMonthly  Recurrence = "monthly"
+	RecurYearly   Recurrence = "yearly"
+)
+
+// ErrNotRecurring 待办事项没有设置重复规则
+var ErrNotRecurring = errors.New("todo is not recurring")
+
+// ParseRecurrence 校验重复规则
+func ParseRecurrence(s string) (Recurrence, error) {
+	switch Recurrence(s) {
+	case RecurNone, RecurDaily, RecurWeekdays, RecurWeekly, RecurMonthly, RecurYearly:
+		return Recurrence(s), nil
+	}
+	return "", fmt.Errorf("invalid recurrence %q, expected daily, weekdays, weekly, monthly or yearly", s)
+}
+
+// NextOccurrence 返回from之后按rule的下一次发生时间，保留时分秒。
+// 按月重复时目标月份没有对应日期则取该月最后一天
+func NextOccurrence(rule Recurrence, from time.Time) (time.Time, error) {
+	if months := monthsOf(rule); months > 0 {
+		return addMonthsClamped(from, months), nil
+	}
+	switch rule {
+	case RecurDaily:
+		return from.AddDate(0, 0, 1), nil
+	case RecurWeekdays:
+		next := from.AddDate(0, 0, 1)
+		for next.Weekday() == time.Saturday || next.Weekday() == time.Sunday {
+			next = next.AddDate(0, 0, 1)
+		}
+		return next, nil
+	case RecurWeekly:
+		return from.AddDate(0, 0, 7), nil
+	}
+	return time.Time{}, ErrNotRecurring
+}
+
+func addMonthsClamped(t time.Time, months int) time.Time {
+	first := time.Date(t.Year(), t.Month(), 1, t.Hour(), t.Minute(), t.Second(), t.Nanosecond(), t.Location())
+	target := first.AddDate(0, months, 0)
+	last := target.AddDate(0, 1, -1).Day()
+	day := t.Day()
+	if day > last {
+		day = last
+	}
+	return target.AddDate(0, 0, day-1)
+}
+
+// Occurrences 计算重复待办事项在当前一次之后、晚于now的接下来count次发生时间，不做持久化。
+// 当前一次的时间依次取截止日期、排期开始时间、创建时间；按now的时区计算，跨越夏令时切换时保持本地时间不变
+func Occurrences(todo Todo, now time.Time, count int) ([]time.Time, error) {
+	if todo.Recurrence == RecurNone {
+		return nil, ErrNotRecurring
+	}
+
+	current := todo.CreatedDate
+	if todo.DueDate != nil {
+		current = *todo.DueDate
+	} else if todo.ScheduledStart != nil {
+		current = *todo.ScheduledStart
+	}
+
+	return occurrencesAfter(todo.Recurrence, current.In(now.Location()), now, count)
+}
+
+// PreviewRecurrence 按rule计算anchor之后的count次发生时间，用于保存前预览规则，规则无效时返回错误
+func PreviewRecurrence(rule string, anchor time.Time, count int) ([]time.Time, error) {
+	recurrence, err := ParseRecurrence(rule)
+	if err != nil {
+		return nil, err
+	}
+	if recurrence == RecurNone {
+		return nil, ErrNotRecurring
+	}
+	return occurrencesAfter(recurrence, anchor, anchor, count)
+}
+
+// occurrencesAfter 从current开始按rule推算，返回晚于after的count次发生时间，按current的时区计算
+func occurrencesAfter(rule Recurrence, current, after time.Time, count int) ([]time.Time, error) {
+	anchor := current
+	occurrences := []time.Time{}
+	for i := 1; len(occurrences) < count; i++ {
+		var next time.Time
+		if months := monthsOf(rule); months > 0 {
+			// 按月重复从原始日期推算，避免月末日期被截断后逐次漂移（1/31 -> 2/28 -> 3/28）
+			next = addMonthsClamped(anchor, months*i)
+		} else {
+			var err error
+			if next, err = NextOccurrence(rule, current); err != nil {
+				return nil, err
+			}
+		}
+		if next.After(after) {
+			occurrences = append(occurrences, next)
+		}
+		current = next
+	}
+	return occurrences, nil
+}
+
+// monthsOf 返回按月重复规则的月数间隔，其他规则返回0
+func monthsOf(rule Recurrence) int {
+	switch rule {
+	case RecurMonthly:
+		return 1
+	case RecurYearly:
+		return 12
+	}
+	return 0
+}
@@ -0,0 +1,101 @@
+package db
+
+import (
+	"errors"
+	"fmt"
+	"time"
+)
+
+// ErrAlreadyRegenerated 重复待办事项已经生成过下一次实例
+var ErrAlreadyRegenerated = errors.New("next occurrence already created")
+
+// NextInstance 根据已完成的重复待办事项生成下一次实例（尚未保存）。下一次截止日期取晚于
+// 原截止日期和完成时间的第一次发生时间，逾期完成时跳过已错过的发生；按loc计算以保持本地时间不变。
+// 清单重置为未完成，排期时间段不保留
+func NextInstance(todo Todo, loc *time.Location) (Todo, error) {
+	if todo.Recurrence == RecurNone {
+		return Todo{}, ErrNotRecurring
+	}
+	if todo.DueDate == nil {
+		return Todo{}, fmt.Errorf("recurring todo %d has no due date", todo.ID)
+	}
+
+	after := *todo.DueDate
+	if todo.CompletedAt != nil && todo.CompletedAt.After(after) {
+		after = *todo.CompletedAt
+	}
+	occurrences, err := occurrencesAfter(todo.Recurrence, todo.DueDate.In(loc), after, 1)
+	if err != nil {
+		return Todo{}, err
+	}
+	due := occurrences[0]
+
+	var checklist Checklist
+	for _, item := range todo.Checklist {
+		checklist = append(checklist, ChecklistItem{Text: item.Text})
+	}
+	return Todo{
+		Title:             todo.Title,
+		Description:       todo.Description,
+		Priority:          todo.Priority,
+		Status:            "pending",
+		DueDate:           &due,
+		EstimatedDuration: todo.EstimatedDuration,
+		Category:          todo.Category,
+		ParentID:          todo.ParentID,
+		Checklist:         checklist,
+		AllDay:            todo.AllDay,
+		IsStarred:         todo.IsStarred,
+		Source:            SourceRecurrence,
+		Recurrence:        todo.Recurrence,
+		ProjectID:         todo.ProjectID,
+		RemindBefore:      todo.RemindBefore,
+		ReminderMessage:   todo.ReminderMessage,
+		Tags:              todo.Tags,
+		EnergyLevel:       todo.EnergyLevel,
+		EffortPoints:      todo.EffortPoints,
+	}, nil
+}
+
+// GetRecurringToRegenerate 返回since之后完成、有截止日期且尚未生成下一次实例的重复待办事项
+func (d *SQLiteDatabase) GetRecurringToRegenerate(since time.Time) ([]Todo, error) {
+	return d.queryTodos(
+		"SELECT "+todoColumns+" FROM todos WHERE status = 'completed' AND recurrence != '' AND due_date IS NOT NULL AND next_occurrence_id IS NULL AND completed_at >= ? ORDER BY id",
+		since,
+	)
+}
+
+// CreateNextInstance 在一个事务中保存next并在todo上记录其ID。todo已经生成过下一次实例时
+// 返回ErrAlreadyRegenerated且不做修改，重复完成同一实例不会生成多个下一次实例
+func (d *SQLiteDatabase) CreateNextInstance(todo Todo, next *Todo) error {
+	d.ids.mu.Lock()
+	defer d.ids.mu.Unlock()
+
+	tx, err := d.db.Begin()
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %v", err)
+	}
+	if err := d.prepareNewTodo(next, d.ids.next); err != nil {
+		tx.Rollback()
+		return err
+	}
+	res, err := tx.Exec("UPDATE todos SET next_occurrence_id = ? WHERE id = ? AND next_occurrence_id IS NULL", next.ID, todo.ID)
+	if err != nil {
+		tx.Rollback()
+		return fmt.Errorf("failed to mark todo %d as regenerated: %v", todo.ID, err)
+	}
+	if n, err := res.RowsAffected(); err != nil || n == 0 {
+		tx.Rollback()
+		return ErrAlreadyRegenerated
+	}
+	if err := insertTodo(tx, next); err != nil {
+		tx.Rollback()
+		return fmt.Errorf("failed to create next occurrence of todo %d: %v", todo.ID, err)
+	}
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit transaction: %v", err)
+	}
+
+	d.ids.next++
+	return nil
+}
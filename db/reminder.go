@@ -0,0 +1,164 @@
+package db
+
+import (
+	"database/sql"
+	"fmt"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// NextReminder 返回待办事项下一次需要提醒的发生时间及提醒时刻。
+// 时间均在loc中按墙上时间计算，重复任务跨越夏令时切换时仍在相同的本地时间到期和提醒。
+// 已到期或已提醒过的发生不再提醒：重复任务顺延到之后的发生，非重复任务返回false
+func NextReminder(todo Todo, lastRemindedFor *time.Time, now time.Time, loc *time.Location) (occurrence, at time.Time, ok bool) {
+	if todo.RemindBefore == nil || todo.DueDate == nil || todo.Status == "completed" {
+		return time.Time{}, time.Time{}, false
+	}
+
+	occurrence = todo.DueDate.In(loc)
+	for !occurrence.After(now) || (lastRemindedFor != nil && !occurrence.After(*lastRemindedFor)) {
+		if todo.Recurrence == RecurNone {
+			return time.Time{}, time.Time{}, false
+		}
+		next, err := NextOccurrence(todo.Recurrence, occurrence)
+		if err != nil {
+			return time.Time{}, time.Time{}, false
+		}
+		occurrence = next
+	}
+	return occurrence, reminderAt(occurrence, *todo.RemindBefore), true
+}
+
+// reminderAt 计算到期前minutes分钟的提醒时刻，整天部分按日期回退以保持本地时间不受夏令时影响
+func reminderAt(due time.Time, minutes int) time.Time {
+	days := minutes / (24 * 60)
+	rest := minutes % (24 * 60)
+	return due.AddDate(0, 0, -days).Add(-time.Duration(rest) * time.Minute)
+}
+
+// ApplyDefaultReminder 待办事项没有显式设置提醒时，按优先级使用offsets中的默认提前量
+func ApplyDefaultReminder(todo *Todo, offsets map[string]time.Duration) {
+	if todo.RemindBefore != nil {
+		return
+	}
+	if d, ok := offsets[todo.Priority]; ok && d > 0 {
+		minutes := int(d.Minutes())
+		todo.RemindBefore = &minutes
+	}
+}
+
+// GetReminderCandidates 返回有截止日期的未完成待办事项中设置了提醒或优先级在defaultPriorities中的，
+// 以及每个待办事项上次提醒对应的发生时间
+func (d *SQLiteDatabase) GetReminderCandidates(defaultPriorities []string) ([]Todo, map[int]time.Time, error) {
+	condition := "remind_before IS NOT NULL"
+	args := []interface{}{}
+	if len(defaultPriorities) > 0 {
+		placeholders := make([]string, len(defaultPriorities))
+		for i, p := range defaultPriorities {
+			placeholders[i] = "?"
+			args = append(args, p)
+		}
+		condition = "(remind_before IS NOT NULL OR priority IN (" + strings.Join(placeholders, ", ") + "))"
+	}
+	todos, err := d.queryTodos(
+		"SELECT "+todoColumns+" FROM todos WHERE "+condition+" AND due_date IS NOT NULL AND status != 'completed' ORDER BY due_date",
+		args...,
+	)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	rows, err := d.db.Query("SELECT id, last_reminded_for FROM todos WHERE last_reminded_for IS NOT NULL")
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to query reminders: %v", err)
+	}
+	defer rows.Close()
+
+	reminded := map[int]time.Time{}
+	for rows.Next() {
+		var id int
+		var at sql.NullTime
+		if err := rows.Scan(&id, &at); err != nil {
+			return nil, nil, fmt.Errorf("failed to scan reminder: %v", err)
+		}
+		if at.Valid {
+			reminded[id] = at.Time
+		}
+	}
+	return todos, reminded, rows.Err()
+}
+
+// MarkReminded 记录已为occurrence这次发生发送提醒，不修改last_updated
+func (d *SQLiteDatabase) MarkReminded(id int, occurrence time.Time) error {
+	if _, err := d.db.Exec("UPDATE todos SET last_reminded_for = ? WHERE id = ?", occurrence, id); err != nil {
+		return fmt.Errorf("failed to mark todo as reminded: %v", err)
+	}
+	return nil
+}
+
+// DefaultReminderTemplate 待办事项未设置提醒消息时使用的模板
+const DefaultReminderTemplate = "🔔 任务「{title}」(ID: {id}) 将于{due}到期"
+
+var reminderPlaceholder = regexp.MustCompile(`\{([^{}]*)\}`)
+
+// reminderFields 提醒模板支持的占位符及其取值
+var reminderFields = map[string]func(todo Todo, occurrence, now time.Time) string{
+	"title":       func(t Todo, _, _ time.Time) string { return t.Title },
+	"id":          func(t Todo, _, _ time.Time) string { return strconv.Itoa(t.ID) },
+	"description": func(t Todo, _, _ time.Time) string { return t.Description },
+	"priority":    func(t Todo, _, _ time.Time) string { return t.Priority },
+	"category":    func(t Todo, _, _ time.Time) string { return t.Category },
+	"due":         func(_ Todo, occurrence, _ time.Time) string { return occurrence.Format("2006-01-02 15:04") },
+	"time":        func(_ Todo, occurrence, now time.Time) string { return formatUntil(occurrence.Sub(now)) },
+}
+
+// ValidateReminderTemplate 检查模板中的占位符是否都受支持
+func ValidateReminderTemplate(tmpl string) error {
+	for _, m := range reminderPlaceholder.FindAllStringSubmatch(tmpl, -1) {
+		if _, ok := reminderFields[m[1]]; !ok {
+			names := make([]string, 0, len(reminderFields))
+			for name := range reminderFields {
+				names = append(names, "{"+name+"}")
+			}
+			sort.Strings(names)
+			return fmt.Errorf("unknown placeholder {%s}, expected one of %s", m[1], strings.Join(names, ", "))
+		}
+	}
+	return nil
+}
+
+// RenderReminder 用待办事项在occurrence这次发生的字段渲染提醒消息，
+// 待办事项未设置模板或模板无效时使用DefaultReminderTemplate
+func RenderReminder(todo Todo, occurrence, now time.Time) string {
+	tmpl := todo.ReminderMessage
+	if tmpl == "" || ValidateReminderTemplate(tmpl) != nil {
+		tmpl = DefaultReminderTemplate
+	}
+	return reminderPlaceholder.ReplaceAllStringFunc(tmpl, func(placeholder string) string {
+		return reminderFields[placeholder[1:len(placeholder)-1]](todo, occurrence, now)
+	})
+}
+
+// formatUntil 将距到期的时长格式化为"1天2小时"、"30分钟"等，精确到分钟
+func formatUntil(d time.Duration) string {
+	minutes := int(d.Round(time.Minute) / time.Minute)
+	if minutes <= 0 {
+		return "不到1分钟"
+	}
+	days, hours, minutes := minutes/(24*60), minutes/60%24, minutes%60
+
+	var b strings.Builder
+	if days > 0 {
+		fmt.Fprintf(&b, "%d天", days)
+	}
+	if hours > 0 {
+		fmt.Fprintf(&b, "%d小时", hours)
+	}
+	if minutes > 0 {
+		fmt.Fprintf(&b, "%d分钟", minutes)
+	}
+	return b.String()
+}
@@ -0,0 +1,39 @@
+package db
+
+import (
+	"fydeos/config"
+	"log"
+	"strings"
+	"time"
+)
+
+// isBusy 判断是否为SQLite的SQLITE_BUSY/SQLITE_LOCKED错误。
+// 写操作的错误经过fmt包装后失去了原始类型，因此按错误信息判断
+func isBusy(err error) bool {
+	if err == nil {
+		return false
+	}
+	msg := err.Error()
+	return strings.Contains(msg, "database is locked") ||
+		strings.Contains(msg, "database table is locked") ||
+		strings.Contains(msg, "SQLITE_BUSY") ||
+		strings.Contains(msg, "SQLITE_LOCKED")
+}
+
+// withRetry 执行op，遇到busy/locked错误时按指数退避重试，最多尝试DB_BUSY_ATTEMPTS次，返回最后一次的错误。
+// op必须是原子的（单条语句或完整的事务），失败时不留下部分写入，重试才不会重复插入
+func withRetry(op func() error) error {
+	cfg := config.Cfg.DB
+	backoff := cfg.BusyBackoff
+
+	var err error
+	for attempt := 1; ; attempt++ {
+		err = op()
+		if !isBusy(err) || attempt >= cfg.BusyAttempts {
+			return err
+		}
+		log.Printf("Warning: database busy (attempt %d/%d), retrying in %v: %v", attempt, cfg.BusyAttempts, backoff, err)
+		time.Sleep(backoff)
+		backoff *= 2
+	}
+}
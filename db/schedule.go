@@ -0,0 +1,127 @@
+package db
+
+import (
+	"fmt"
+	"fydeos/config"
+	"time"
+)
+
+// DefaultWorkSchedule 用户未配置工作时间时使用的默认值
+func DefaultWorkSchedule() WorkSchedule {
+	return WorkSchedule{
+		StartTime: "09:00",
+		EndTime:   "17:00",
+		WorkDays:  []string{"Monday", "Tuesday", "Wednesday", "Thursday", "Friday"},
+	}
+}
+
+// Location 返回用户时区，未配置或无法识别时使用服务器时区（SERVER_TIMEZONE，默认UTC）
+func (p *UserProfile) Location() *time.Location {
+	if p != nil && p.Timezone != "" {
+		if loc, err := time.LoadLocation(p.Timezone); err == nil {
+			return loc
+		}
+	}
+	return ServerLocation()
+}
+
+// ServerLocation 返回配置的服务器时区，未配置时为UTC
+func ServerLocation() *time.Location {
+	if loc := config.Cfg.ServerLocation; loc != nil {
+		return loc
+	}
+	return time.UTC
+}
+
+// IsWorkDay 判断t所在的星期是否为工作日
+func (ws WorkSchedule) IsWorkDay(t time.Time) bool {
+	day := t.Weekday().String()
+	for _, d := range ws.WorkDays {
+		if d == day {
+			return true
+		}
+	}
+	return false
+}
+
+// StartOn 返回t所在日期的上班时间（使用t的时区）
+func (ws WorkSchedule) StartOn(t time.Time) (time.Time, error) {
+	return ClockOn(t, ws.StartTime)
+}
+
+// EndOn 返回t所在日期的下班时间（使用t的时区）
+func (ws WorkSchedule) EndOn(t time.Time) (time.Time, error) {
+	return ClockOn(t, ws.EndTime)
+}
+
+// ClockOn 将"HH:MM"格式的时间应用到t所在的日期
+func ClockOn(t time.Time, clock string) (time.Time, error) {
+	parsed, err := time.Parse("15:04", clock)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("invalid time %q, expected HH:MM", clock)
+	}
+	return time.Date(t.Year(), t.Month(), t.Day(), parsed.Hour(), parsed.Minute(), 0, 0, t.Location()), nil
+}
+
+// EndOfWeek 返回t所在周（周一至周日）最后一个工作日的下班时间；
+// 若已经过去或本周没有剩余工作日，则返回下一周的
+func (ws WorkSchedule) EndOfWeek(t time.Time) (time.Time, error) {
+	offset := (int(t.Weekday()) + 6) % 7
+	monday := time.Date(t.Year(), t.Month(), t.Day()-offset, 0, 0, 0, 0, t.Location())
+
+	for week := 0; week < 2; week++ {
+		for i := 6; i >= 0; i-- {
+			day := monday.AddDate(0, 0, week*7+i)
+			if !ws.IsWorkDay(day) {
+				continue
+			}
+			end, err := ws.EndOn(day)
+			if err != nil {
+				return time.Time{}, err
+			}
+			if end.After(t) {
+				return end, nil
+			}
+			break
+		}
+	}
+	return time.Time{}, fmt.Errorf("work schedule has no work days")
+}
+
+// AddWorkingTime 返回从start起累计d工作时长后的时间，只计入工作日的上下班时间段
+func (ws WorkSchedule) AddWorkingTime(start time.Time, d time.Duration) (time.Time, error) {
+	if len(ws.WorkDays) == 0 {
+		return time.Time{}, fmt.Errorf("work schedule has no work days")
+	}
+
+	t := start
+	remaining := d
+	for {
+		day := time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, t.Location())
+		if ws.IsWorkDay(day) {
+			dayStart, err := ws.StartOn(day)
+			if err != nil {
+				return time.Time{}, err
+			}
+			dayEnd, err := ws.EndOn(day)
+			if err != nil {
+				return time.Time{}, err
+			}
+			if !dayEnd.After(dayStart) {
+				return time.Time{}, fmt.Errorf("work schedule end time must be after start time")
+			}
+
+			if t.Before(dayStart) {
+				t = dayStart
+			}
+			if t.Before(dayEnd) {
+				available := dayEnd.Sub(t)
+				if remaining <= available {
+					return t.Add(remaining), nil
+				}
+				remaining -= available
+			}
+		}
+		t = day.AddDate(0, 0, 1)
+	}
+}
@@ -0,0 +1,68 @@
+package db
+
+import (
+	"sort"
+	"time"
+)
+
+// ScoreWeights PriorityScore中各项的权重，均不能为负
+type ScoreWeights struct {
+	Priority float64 `json:"priority"`
+	Due      float64 `json:"due"`
+	Stale    float64 `json:"stale"`
+}
+
+// DefaultScoreWeights 未指定权重时使用的默认值
+func DefaultScoreWeights() ScoreWeights {
+	return ScoreWeights{Priority: 1, Due: 1, Stale: 0.5}
+}
+
+// ScoredTodo 带有评分的待办事项
+type ScoredTodo struct {
+	Todo
+	Score float64 `json:"score"`
+}
+
+// PriorityScore 计算待办事项的加权评分，各项取值均在[0, 1]：
+// 优先级urgent为1、low为0.25；截止日期已过为1，否则为1/(1+剩余天数)，没有截止日期为0；
+// 陈旧度为距最后更新的天数/30，最多为1
+func PriorityScore(todo Todo, now time.Time, w ScoreWeights) float64 {
+	priority := 0.0
+	if r, ok := priorityRank[todo.Priority]; ok {
+		priority = float64(len(priorityRank)+1-r) / float64(len(priorityRank))
+	}
+
+	due := 0.0
+	if todo.DueDate != nil {
+		days := todo.DueDate.Sub(now).Hours() / 24
+		if days <= 0 {
+			due = 1
+		} else {
+			due = 1 / (1 + days)
+		}
+	}
+
+	stale := now.Sub(todo.LastUpdated).Hours() / 24 / 30
+	if stale < 0 {
+		stale = 0
+	} else if stale > 1 {
+		stale = 1
+	}
+
+	return w.Priority*priority + w.Due*due + w.Stale*stale
+}
+
+// ScoreTodos 按PriorityScore从高到低排序，评分相同时按优先级优先的规则排序
+func ScoreTodos(todos []Todo, now time.Time, w ScoreWeights) []ScoredTodo {
+	scored := make([]ScoredTodo, 0, len(todos))
+	for _, todo := range todos {
+		scored = append(scored, ScoredTodo{Todo: todo, Score: PriorityScore(todo, now, w)})
+	}
+	sort.SliceStable(scored, func(i, j int) bool {
+		if scored[i].Score != scored[j].Score {
+			return scored[i].Score > scored[j].Score
+		}
+		return lessTodo(scored[i].Todo, scored[j].Todo, SortPriorityFirst)
+	})
+	return scored
+}
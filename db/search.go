@@ -0,0 +1,115 @@
+package db
+
+import (
+	"sort"
+	"strings"
+	"unicode"
+)
+
+// SearchResult 搜索命中的待办事项，Distance为模糊匹配的编辑距离之和，精确匹配时为0
+type SearchResult struct {
+	Todo     Todo `json:"todo"`
+	Exact    bool `json:"exact"`
+	Distance int  `json:"distance"`
+}
+
+// SearchTodos 在标题、描述和外部引用编号中搜索query（不区分大小写的子串匹配）
+func (d *SQLiteDatabase) SearchTodos(query string) ([]Todo, error) {
+	pattern := "%" + escapeLike(query) + "%"
+	return d.queryTodos(
+		"SELECT "+todoColumns+" FROM todos WHERE title LIKE ? ESCAPE '\\' OR description LIKE ? ESCAPE '\\' OR external_id LIKE ? ESCAPE '\\' ORDER BY last_updated DESC",
+		pattern,
+		pattern,
+		pattern,
+	)
+}
+
+// FuzzySearchTodos 容忍拼写错误的搜索：query中的每个词都需要与标题或描述中某个词的编辑距离不超过maxDistance。
+// 精确匹配排在模糊匹配之前，模糊匹配按编辑距离升序排列
+func (d *SQLiteDatabase) FuzzySearchTodos(query string, maxDistance int) ([]SearchResult, error) {
+	todos, err := d.GetAllTodos()
+	if err != nil {
+		return nil, err
+	}
+	return FuzzyMatch(todos, query, maxDistance), nil
+}
+
+// FuzzyMatch 对todos执行模糊匹配，规则同FuzzySearchTodos
+func FuzzyMatch(todos []Todo, query string, maxDistance int) []SearchResult {
+	query = strings.ToLower(strings.TrimSpace(query))
+	terms := splitWords(query)
+	results := []SearchResult{}
+	if len(terms) == 0 {
+		return results
+	}
+
+	for _, todo := range todos {
+		text := strings.ToLower(todo.Title + " " + todo.Description)
+		if strings.Contains(text, query) {
+			results = append(results, SearchResult{Todo: todo, Exact: true})
+			continue
+		}
+
+		words := splitWords(text)
+		total := 0
+		matched := true
+		for _, term := range terms {
+			best := -1
+			for _, word := range words {
+				if dist := levenshtein(term, word); dist <= maxDistance && (best < 0 || dist < best) {
+					best = dist
+				}
+			}
+			if best < 0 {
+				matched = false
+				break
+			}
+			total += best
+		}
+		if matched {
+			results = append(results, SearchResult{Todo: todo, Distance: total})
+		}
+	}
+
+	sort.SliceStable(results, func(i, j int) bool {
+		if results[i].Exact != results[j].Exact {
+			return results[i].Exact
+		}
+		return results[i].Distance < results[j].Distance
+	})
+	return results
+}
+
+func splitWords(s string) []string {
+	return strings.FieldsFunc(s, func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsNumber(r)
+	})
+}
+
+// levenshtein 计算两个字符串按rune计的编辑距离
+func levenshtein(a, b string) int {
+	ra, rb := []rune(a), []rune(b)
+	prev := make([]int, len(rb)+1)
+	curr := make([]int, len(rb)+1)
+	for j := range prev {
+		prev[j] = j
+	}
+
+	for i := 1; i <= len(ra); i++ {
+		curr[0] = i
+		for j := 1; j <= len(rb); j++ {
+			cost := 1
+			if ra[i-1] == rb[j-1] {
+				cost = 0
+			}
+			curr[j] = min(prev[j]+1, curr[j-1]+1, prev[j-1]+cost)
+		}
+		prev, curr = curr, prev
+	}
+	return prev[len(rb)]
+}
+
+func escapeLike(s string) string {
+	r := strings.NewReplacer(`\`, `\\`, `%`, `\%`, `_`, `\_`)
+	return r.Replace(s)
+}
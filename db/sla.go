@@ -0,0 +1,58 @@
+package db
+
+import "time"
+
+// SLAEntry 违反或即将违反SLA的待办事项
+type SLAEntry struct {
+	Todo     Todo      `json:"todo"`
+	SLA      string    `json:"sla"`
+	Deadline time.Time `json:"deadline"`
+}
+
+// SLAReport SLA分析结果
+type SLAReport struct {
+	Breached []SLAEntry `json:"breached"`
+	AtRisk   []SLAEntry `json:"at_risk"`
+}
+
+// EvaluateSLA 按优先级对应的SLA（工作时长，从创建时起算）检查待办事项。
+// 完成时间或当前时间晚于截止时间的为breached；未完成且已用去atRiskPercent%以上SLA的为at_risk。
+// 未配置SLA的优先级不参与检查
+func EvaluateSLA(todos []Todo, slas map[string]time.Duration, atRiskPercent int, schedule WorkSchedule, now time.Time) (SLAReport, error) {
+	report := SLAReport{Breached: []SLAEntry{}, AtRisk: []SLAEntry{}}
+	loc := now.Location()
+
+	for _, todo := range todos {
+		sla, ok := slas[todo.Priority]
+		if !ok || sla <= 0 {
+			continue
+		}
+
+		created := todo.CreatedDate.In(loc)
+		deadline, err := schedule.AddWorkingTime(created, sla)
+		if err != nil {
+			return report, err
+		}
+		entry := SLAEntry{Todo: todo, SLA: sla.String(), Deadline: deadline}
+
+		if todo.Status == "completed" {
+			if done := completedTime(todo); done.After(deadline) {
+				report.Breached = append(report.Breached, entry)
+			}
+			continue
+		}
+		if now.After(deadline) {
+			report.Breached = append(report.Breached, entry)
+			continue
+		}
+
+		riskAt, err := schedule.AddWorkingTime(created, sla*time.Duration(atRiskPercent)/100)
+		if err != nil {
+			return report, err
+		}
+		if !now.Before(riskAt) {
+			report.AtRisk = append(report.AtRisk, entry)
+		}
+	}
+	return report, nil
+}
@@ -0,0 +1,81 @@
+package db
+
+import (
+	"fmt"
+	"fydeos/config"
+	"sort"
+)
+
+// SortStrategy 优先级与截止日期的排序取舍
+type SortStrategy string
+
+const (
+	// SortPriorityFirst 先按优先级，同优先级内按截止日期
+	SortPriorityFirst SortStrategy = "priority_first"
+	// SortDueFirst 先按截止日期，截止日期相同时按优先级
+	SortDueFirst SortStrategy = "due_first"
+)
+
+// ParseSortStrategy 解析排序策略，为空时使用配置的默认策略
+func ParseSortStrategy(s string) (SortStrategy, error) {
+	if s == "" {
+		s = config.Cfg.Sort.Strategy
+	}
+	switch SortStrategy(s) {
+	case SortPriorityFirst, SortDueFirst:
+		return SortStrategy(s), nil
+	}
+	return "", fmt.Errorf("invalid sort strategy %q, expected priority_first or due_first", s)
+}
+
+// priorityRank 优先级排序权重，数值越小越靠前；未知优先级排在最后
+var priorityRank = map[string]int{
+	"urgent": 1,
+	"high":   2,
+	"medium": 3,
+	"low":    4,
+}
+
+func rankOf(priority string) int {
+	if r, ok := priorityRank[priority]; ok {
+		return r
+	}
+	return len(priorityRank) + 1
+}
+
+// compareDue 比较截止日期，有截止日期的排在没有的前面，都有时早的在前
+func compareDue(a, b Todo) int {
+	switch {
+	case a.DueDate == nil && b.DueDate == nil:
+		return 0
+	case a.DueDate == nil:
+		return 1
+	case b.DueDate == nil:
+		return -1
+	}
+	return a.DueDate.Compare(*b.DueDate)
+}
+
+// lessTodo 待办事项的统一排序规则。priority_first先按优先级再按截止日期，
+// due_first先按截止日期再按优先级；两种策略下有截止日期的都排在没有的前面
+func lessTodo(a, b Todo, strategy SortStrategy) bool {
+	byPriority := rankOf(a.Priority) - rankOf(b.Priority)
+	byDue := compareDue(a, b)
+	if strategy == SortDueFirst {
+		if byDue != 0 {
+			return byDue < 0
+		}
+		return byPriority < 0
+	}
+	if byPriority != 0 {
+		return byPriority < 0
+	}
+	return byDue < 0
+}
+
+// SortTodos 按lessTodo对待办事项进行稳定排序
+func SortTodos(todos []Todo, strategy SortStrategy) {
+	sort.SliceStable(todos, func(i, j int) bool {
+		return lessTodo(todos[i], todos[j], strategy)
+	})
+}
@@ -1,11 +1,14 @@
 package db
 
 import (
+	"context"
 	"database/sql"
 	"encoding/json"
+	"errors"
 	"fmt"
+	"fydeos/config"
 	"log"
-	"os"
+	"sync"
 	"time"
 
 	_ "github.com/mattn/go-sqlite3"
@@ -24,16 +27,39 @@ type WorkSchedule struct {
 }
 
 type Todo struct {
-	ID                int        `json:"id"`
-	Title             string     `json:"title"`
-	Description       string     `json:"description"`
-	Priority          string     `json:"priority"`
-	Status            string     `json:"status"`
-	CreatedDate       time.Time  `json:"created_date"`
-	DueDate           *time.Time `json:"due_date"`
-	LastUpdated       time.Time  `json:"last_updated"`
-	EstimatedDuration string     `json:"estimated_duration"`
-	Category          string     `json:"category"`
+	ID                int          `json:"id"`
+	Title             string       `json:"title"`
+	Description       string       `json:"description"`
+	Priority          string       `json:"priority"`
+	Status            string       `json:"status"`
+	CreatedDate       time.Time    `json:"created_date"`
+	DueDate           *time.Time   `json:"due_date"`
+	LastUpdated       time.Time    `json:"last_updated"`
+	EstimatedDuration string       `json:"estimated_duration"`
+	Category          string       `json:"category"`
+	CompletedAt       *time.Time   `json:"completed_at"`
+	ParentID          *int         `json:"parent_id"`
+	Checklist         Checklist    `json:"checklist"`
+	WaitingOn         string       `json:"waiting_on"` // 已委派给他人时为对方名称
+	ScheduledStart    *time.Time   `json:"scheduled_start"`
+	ScheduledEnd      *time.Time   `json:"scheduled_end"`
+	AllDay            bool         `json:"all_day"` // 截止日期只精确到天，忽略时间部分
+	IsStarred         bool         `json:"is_starred"`
+	Source            string       `json:"source"` // 最近一次创建或修改的来源：api, mcp, import, auto_close, recurrence
+	Recurrence        Recurrence   `json:"recurrence"`
+	ProjectID         *int         `json:"project_id"`
+	RawInput          string       `json:"raw_input"`             // 创建时的原始自然语言输入，用于重新解析
+	RemindBefore      *int         `json:"remind_before_minutes"` // 截止前多少分钟提醒，为空表示不提醒
+	ReminderMessage   string       `json:"reminder_message"`      // 自定义提醒消息模板，为空时使用默认模板
+	ExternalRef       *ExternalRef `json:"external_ref"`          // 关联的外部问题跟踪系统条目
+	Tags              Tags         `json:"tags"`                  // 手动添加的标签
+	EnergyLevel       string       `json:"energy_level"`          // 所需精力：high、medium、low，为空表示未设置
+	EffortPoints      int          `json:"effort_points"`         // 工作量点数（故事点），与预计耗时相互独立，0表示未估算
+	NextOccurrenceID  *int         `json:"next_occurrence_id"`    // 重复任务完成后生成的下一次实例，由服务器维护
+	// 清单完成比例（0~1），由Checklist计算，没有清单项时为0
+	ChecklistProgress float64 `json:"checklist_progress"`
+	// 按AUTO_TAGS规则从类别和项目推导的标签，读取时计算，不保存
+	AutoTags []string `json:"auto_tags"`
 }
 
 type DataStructure struct {
@@ -52,13 +78,49 @@ type AIResponse struct {
 	Data    interface{} `json:"data"`
 }
 
+// 写入来源，记录在Todo.Source中
+const (
+	SourceAPI        = "api"
+	SourceMCP        = "mcp"
+	SourceImport     = "import"
+	SourceAutoClose  = "auto_close"
+	SourceRecurrence = "recurrence"
+)
+
+// FilterBySource 返回来源为source的待办事项，source为空时原样返回
+func FilterBySource(todos []Todo, source string) []Todo {
+	if source == "" {
+		return todos
+	}
+	filtered := []Todo{}
+	for _, todo := range todos {
+		if todo.Source == source {
+			filtered = append(filtered, todo)
+		}
+	}
+	return filtered
+}
+
 // 全局数据库实例
 var DB *SQLiteDatabase
 
 // SQLiteDatabase 使用SQLite3存储的数据库实现
 type SQLiteDatabase struct {
-	db     *sql.DB
-	nextID int
+	db  *tracedDB
+	ids *idAllocator
+}
+
+// idAllocator 分配待办事项ID，由同一数据库的所有WithContext实例共享
+type idAllocator struct {
+	// mu 保护next，保证并发创建时分配的ID不重复
+	mu   sync.Mutex
+	next int
+}
+
+// WithContext 返回在ctx下执行的数据库实例，每条SQL语句记录为ctx中span的子span。
+// 返回的实例与d共享连接和ID分配，可在单个请求或工具调用内使用
+func (d *SQLiteDatabase) WithContext(ctx context.Context) *SQLiteDatabase {
+	return &SQLiteDatabase{db: &tracedDB{conn: d.db.conn, ctx: ctx}, ids: d.ids}
 }
 
 func NewSQLiteDatabase() (*SQLiteDatabase, error) {
@@ -75,19 +137,19 @@ func NewSQLiteDatabase() (*SQLiteDatabase, error) {
 
 	// 创建SQLite数据库实例
 	sqliteDB := &SQLiteDatabase{
-		db:     db,
-		nextID: 1,
+		db:  &tracedDB{conn: db, ctx: context.Background()},
+		ids: &idAllocator{next: 1},
 	}
 
-	// 导入初始数据
-	//if err := sqliteDB.ImportFromJSON("data.json"); err != nil {
-	//	log.Printf("Warning: Failed to import data from data.json: %v", err)
-	//}
-
 	// 初始化数据库表
-	//if err := sqliteDB.initDatabase(); err != nil {
-	//	return nil, fmt.Errorf("failed to initialize database: %v", err)
-	//}
+	if err := sqliteDB.initDatabase(); err != nil {
+		return nil, fmt.Errorf("failed to initialize database: %v", err)
+	}
+
+	// 为已有数据库补充新增列
+	if err := sqliteDB.migrate(); err != nil {
+		return nil, fmt.Errorf("failed to migrate database: %v", err)
+	}
 
 	// 获取当前最大ID
 	sqliteDB.updateNextID()
@@ -145,40 +207,45 @@ func (d *SQLiteDatabase) updateNextID() {
 		maxID = 0
 	}
 
-	d.nextID = maxID + 1
+	d.ids.mu.Lock()
+	d.ids.next = maxID + 1
+	d.ids.mu.Unlock()
 }
 
-func (d *SQLiteDatabase) ImportFromJSON(filename string) error {
-	data, err := os.ReadFile(filename)
-	if err != nil {
-		return fmt.Errorf("failed to read data.json: %v", err)
-	}
-
-	var dataStruct DataStructure
-	if err := json.Unmarshal(data, &dataStruct); err != nil {
-		return fmt.Errorf("failed to parse data.json: %v", err)
-	}
+// ImportData 在一个事务中导入用户配置和待办事项，opts.Dedup为true时跳过与已有待办事项重复的项。
+// opts.Merge为true时不覆盖已有数据：ID已存在的待办事项计为跳过，已有用户配置时保持不变
+func (d *SQLiteDatabase) ImportData(dataStruct DataStructure, opts ImportOptions) (*ImportResult, error) {
+	result := &ImportResult{}
 
 	// 开始事务
 	tx, err := d.db.Begin()
 	if err != nil {
-		return fmt.Errorf("failed to begin transaction: %v", err)
+		return nil, fmt.Errorf("failed to begin transaction: %v", err)
 	}
 
 	// 导入用户配置
-	if dataStruct.UserProfile.Name != "" {
+	keepProfile := false
+	if dataStruct.UserProfile.Name != "" && opts.Merge {
+		var count int
+		if err := tx.QueryRow("SELECT COUNT(*) FROM user_profile").Scan(&count); err != nil {
+			tx.Rollback()
+			return nil, fmt.Errorf("failed to check user profile: %v", err)
+		}
+		keepProfile = count > 0
+	}
+	if dataStruct.UserProfile.Name != "" && !keepProfile {
 		// 首先删除现有的用户配置
 		_, err = tx.Exec("DELETE FROM user_profile")
 		if err != nil {
 			tx.Rollback()
-			return fmt.Errorf("failed to clear user profile: %v", err)
+			return nil, fmt.Errorf("failed to clear user profile: %v", err)
 		}
 
 		// 将工作日数组转换为JSON字符串
 		workDaysJSON, err := json.Marshal(dataStruct.UserProfile.WorkSchedule.WorkDays)
 		if err != nil {
 			tx.Rollback()
-			return fmt.Errorf("failed to marshal work days: %v", err)
+			return nil, fmt.Errorf("failed to marshal work days: %v", err)
 		}
 
 		// 插入用户配置
@@ -192,23 +259,45 @@ func (d *SQLiteDatabase) ImportFromJSON(filename string) error {
 		)
 		if err != nil {
 			tx.Rollback()
-			return fmt.Errorf("failed to insert user profile: %v", err)
+			return nil, fmt.Errorf("failed to insert user profile: %v", err)
 		}
 	}
 
 	// 导入待办事项
 	if len(dataStruct.Todos) > 0 {
+		var seen map[string]bool
+		if opts.Dedup {
+			if seen, err = existingImportKeys(tx, opts.Keys); err != nil {
+				tx.Rollback()
+				return nil, err
+			}
+		}
+
 		// 插入待办事项数据
 		for _, todo := range dataStruct.Todos {
+			if opts.Dedup {
+				key := importKey(todo, opts.Keys)
+				if seen[key] {
+					result.Skipped++
+					continue
+				}
+				seen[key] = true
+			}
 			var dueDate interface{}
 			if todo.DueDate != nil {
 				dueDate = todo.DueDate
 			} else {
 				dueDate = nil
 			}
+			externalSystem, externalID, externalURL := todo.ExternalRef.columns()
 
-			_, err = tx.Exec(
-				"INSERT OR REPLACE INTO todos (id, title, description, priority, status, created_date, due_date, last_updated, estimated_duration, category) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?)",
+			conflict := "REPLACE"
+			if opts.Merge {
+				conflict = "IGNORE"
+			}
+			// 文件中没有next_occurrence_id（如旧版本导出）时保留已有记录的值，避免已生成过的重复任务被再次生成
+			res, err := tx.Exec(
+				"INSERT OR "+conflict+" INTO todos (id, title, description, priority, status, created_date, due_date, last_updated, estimated_duration, category, completed_at, parent_id, checklist, waiting_on, scheduled_start, scheduled_end, all_day, is_starred, source, recurrence, project_id, raw_input, remind_before, reminder_message, external_system, external_id, external_url, tags, energy_level, effort_points, next_occurrence_id) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, COALESCE(?, (SELECT next_occurrence_id FROM todos WHERE id = ?)))",
 				todo.ID,
 				todo.Title,
 				todo.Description,
@@ -219,31 +308,188 @@ func (d *SQLiteDatabase) ImportFromJSON(filename string) error {
 				todo.LastUpdated,
 				todo.EstimatedDuration,
 				todo.Category,
+				todo.CompletedAt,
+				todo.ParentID,
+				todo.Checklist,
+				todo.WaitingOn,
+				todo.ScheduledStart,
+				todo.ScheduledEnd,
+				todo.AllDay,
+				todo.IsStarred,
+				SourceImport,
+				todo.Recurrence,
+				todo.ProjectID,
+				todo.RawInput,
+				todo.RemindBefore,
+				todo.ReminderMessage,
+				externalSystem,
+				externalID,
+				externalURL,
+				todo.Tags,
+				todo.EnergyLevel,
+				todo.EffortPoints,
+				todo.NextOccurrenceID,
+				todo.ID,
 			)
 			if err != nil {
 				tx.Rollback()
-				return fmt.Errorf("failed to insert todo: %v", err)
+				return nil, fmt.Errorf("failed to insert todo: %v", err)
+			}
+			if affected, err := res.RowsAffected(); err == nil && affected == 0 {
+				result.Skipped++
+				continue
 			}
+			result.Imported++
 		}
 	}
 
 	// 提交事务
 	if err := tx.Commit(); err != nil {
-		return fmt.Errorf("failed to commit transaction: %v", err)
+		return nil, fmt.Errorf("failed to commit transaction: %v", err)
 	}
 
 	// 更新nextID
 	d.updateNextID()
-
-	log.Println("Data imported successfully from data.json to SQLite database")
-	return nil
+	return result, nil
 }
 
 // CRUD 操作
 func (d *SQLiteDatabase) GetAllTodos() ([]Todo, error) {
-	rows, err := d.db.Query(
-		"SELECT id, title, description, priority, status, created_date, due_date, last_updated, estimated_duration, category FROM todos ORDER BY created_date DESC, CASE priority WHEN 'urgent' THEN 1 WHEN 'high' THEN 2 WHEN 'medium' THEN 3 WHEN 'low' THEN 4 END",
+	return d.queryTodos("SELECT " + todoColumns + " FROM todos ORDER BY " + listOrder())
+}
+
+// GetTodosPaged 分页获取待办事项，排序与GetAllTodos一致，同时返回总数
+func (d *SQLiteDatabase) GetTodosPaged(limit, offset int) ([]Todo, int, error) {
+	return d.QueryTodosPaged(TodoFilter{}, limit, offset)
+}
+
+// GetStarredTodos 获取所有星标待办事项
+func (d *SQLiteDatabase) GetStarredTodos() ([]Todo, error) {
+	return d.queryTodos("SELECT " + todoColumns + " FROM todos WHERE is_starred = 1 ORDER BY " + listOrder())
+}
+
+// listOrder 列表排序：按配置将星标任务置顶，其余按创建时间倒序、优先级排序，最后按id保证顺序稳定
+func listOrder() string {
+	order := "created_date DESC, CASE priority WHEN 'urgent' THEN 1 WHEN 'high' THEN 2 WHEN 'medium' THEN 3 WHEN 'low' THEN 4 END, id DESC"
+	if config.Cfg.StarredFirst {
+		order = "is_starred DESC, " + order
+	}
+	return order
+}
+
+// todos表查询时统一使用的列，与scanTodo的顺序保持一致
+const todoColumns = "id, title, description, priority, status, created_date, due_date, last_updated, estimated_duration, category, completed_at, parent_id, checklist, waiting_on, scheduled_start, scheduled_end, all_day, is_starred, source, recurrence, project_id, raw_input, remind_before, reminder_message, external_system, external_id, external_url, tags, energy_level, effort_points, next_occurrence_id"
+
+type rowScanner interface {
+	Scan(dest ...interface{}) error
+}
+
+// scanTodo 将一行todos记录扫描为Todo
+func scanTodo(row rowScanner) (Todo, error) {
+	var todo Todo
+	var dueDate, completedAt, scheduledStart, scheduledEnd sql.NullTime
+	var parentID, projectID, remindBefore, nextOccurrenceID sql.NullInt64
+	var checklist, waitingOn, tags sql.NullString
+	var externalSystem, externalID, externalURL string
+
+	err := row.Scan(
+		&todo.ID,
+		&todo.Title,
+		&todo.Description,
+		&todo.Priority,
+		&todo.Status,
+		&todo.CreatedDate,
+		&dueDate,
+		&todo.LastUpdated,
+		&todo.EstimatedDuration,
+		&todo.Category,
+		&completedAt,
+		&parentID,
+		&checklist,
+		&waitingOn,
+		&scheduledStart,
+		&scheduledEnd,
+		&todo.AllDay,
+		&todo.IsStarred,
+		&todo.Source,
+		&todo.Recurrence,
+		&projectID,
+		&todo.RawInput,
+		&remindBefore,
+		&todo.ReminderMessage,
+		&externalSystem,
+		&externalID,
+		&externalURL,
+		&tags,
+		&todo.EnergyLevel,
+		&todo.EffortPoints,
+		&nextOccurrenceID,
 	)
+	if err != nil {
+		return todo, err
+	}
+
+	if dueDate.Valid {
+		todo.DueDate = &dueDate.Time
+	} else {
+		todo.DueDate = nil
+	}
+
+	if completedAt.Valid {
+		todo.CompletedAt = &completedAt.Time
+	}
+
+	if parentID.Valid {
+		id := int(parentID.Int64)
+		todo.ParentID = &id
+	}
+
+	if projectID.Valid {
+		id := int(projectID.Int64)
+		todo.ProjectID = &id
+	}
+
+	if remindBefore.Valid {
+		minutes := int(remindBefore.Int64)
+		todo.RemindBefore = &minutes
+	}
+
+	if nextOccurrenceID.Valid {
+		id := int(nextOccurrenceID.Int64)
+		todo.NextOccurrenceID = &id
+	}
+
+	if externalSystem != "" {
+		todo.ExternalRef = &ExternalRef{System: externalSystem, ID: externalID, URL: externalURL}
+	}
+
+	if checklist.Valid && checklist.String != "" {
+		if err := json.Unmarshal([]byte(checklist.String), &todo.Checklist); err != nil {
+			return todo, fmt.Errorf("failed to unmarshal checklist: %v", err)
+		}
+	}
+	todo.ChecklistProgress = todo.Checklist.Progress()
+	if tags.Valid && tags.String != "" {
+		if err := json.Unmarshal([]byte(tags.String), &todo.Tags); err != nil {
+			return todo, fmt.Errorf("failed to unmarshal tags: %v", err)
+		}
+	}
+	todo.AutoTags = AutoTagsFor(todo)
+	todo.WaitingOn = waitingOn.String
+
+	if scheduledStart.Valid {
+		todo.ScheduledStart = &scheduledStart.Time
+	}
+	if scheduledEnd.Valid {
+		todo.ScheduledEnd = &scheduledEnd.Time
+	}
+
+	return todo, nil
+}
+
+// queryTodos 执行查询并扫描所有返回的待办事项
+func (d *SQLiteDatabase) queryTodos(query string, args ...interface{}) ([]Todo, error) {
+	rows, err := d.db.Query(query, args...)
 	if err != nil {
 		return nil, fmt.Errorf("failed to query todos: %v", err)
 	}
@@ -251,31 +497,10 @@ func (d *SQLiteDatabase) GetAllTodos() ([]Todo, error) {
 
 	var todos []Todo
 	for rows.Next() {
-		var todo Todo
-		var dueDate sql.NullTime
-
-		err := rows.Scan(
-			&todo.ID,
-			&todo.Title,
-			&todo.Description,
-			&todo.Priority,
-			&todo.Status,
-			&todo.CreatedDate,
-			&dueDate,
-			&todo.LastUpdated,
-			&todo.EstimatedDuration,
-			&todo.Category,
-		)
+		todo, err := scanTodo(rows)
 		if err != nil {
 			return nil, fmt.Errorf("failed to scan todo: %v", err)
 		}
-
-		if dueDate.Valid {
-			todo.DueDate = &dueDate.Time
-		} else {
-			todo.DueDate = nil
-		}
-
 		todos = append(todos, todo)
 	}
 
@@ -286,45 +511,83 @@ func (d *SQLiteDatabase) GetAllTodos() ([]Todo, error) {
 	return todos, nil
 }
 
-func (d *SQLiteDatabase) GetTodoByID(id int) (*Todo, error) {
-	var todo Todo
-	var dueDate sql.NullTime
+// ErrTodoNotFound 指定ID的待办事项不存在
+type ErrTodoNotFound struct {
+	ID int
+}
+
+func (e *ErrTodoNotFound) Error() string {
+	return fmt.Sprintf("todo with ID %d not found", e.ID)
+}
 
+func (d *SQLiteDatabase) GetTodoByID(id int) (*Todo, error) {
 	row := d.db.QueryRow(
-		"SELECT id, title, description, priority, status, created_date, due_date, last_updated, estimated_duration, category FROM todos WHERE id = ?",
+		"SELECT "+todoColumns+" FROM todos WHERE id = ?",
 		id,
 	)
 
-	err := row.Scan(
-		&todo.ID,
-		&todo.Title,
-		&todo.Description,
-		&todo.Priority,
-		&todo.Status,
-		&todo.CreatedDate,
-		&dueDate,
-		&todo.LastUpdated,
-		&todo.EstimatedDuration,
-		&todo.Category,
-	)
-
+	todo, err := scanTodo(row)
 	if err == sql.ErrNoRows {
-		return nil, fmt.Errorf("todo with ID %d not found", id)
+		return nil, &ErrTodoNotFound{ID: id}
 	} else if err != nil {
 		return nil, fmt.Errorf("failed to get todo: %v", err)
 	}
 
-	if dueDate.Valid {
-		todo.DueDate = &dueDate.Time
-	} else {
-		todo.DueDate = nil
-	}
-
 	return &todo, nil
 }
 
 func (d *SQLiteDatabase) CreateTodo(todo *Todo) error {
-	todo.ID = d.nextID
+	// 分配ID到插入完成期间持有锁，插入失败时ID不会被消耗
+	d.ids.mu.Lock()
+	defer d.ids.mu.Unlock()
+
+	if err := d.prepareNewTodo(todo, d.ids.next); err != nil {
+		return err
+	}
+
+	// 单条INSERT是原子的，busy时没有写入任何数据，可以安全重试
+	err := withRetry(func() error {
+		return insertTodo(d.db, todo)
+	})
+
+	if err != nil {
+		return fmt.Errorf("failed to create todo: %v", err)
+	}
+
+	d.ids.next++
+	return nil
+}
+
+// CreateTodos 在一个事务中创建多个待办事项，任一条失败时全部回滚
+func (d *SQLiteDatabase) CreateTodos(todos []Todo) error {
+	d.ids.mu.Lock()
+	defer d.ids.mu.Unlock()
+
+	tx, err := d.db.Begin()
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %v", err)
+	}
+	for i := range todos {
+		if err := d.prepareNewTodo(&todos[i], d.ids.next+i); err != nil {
+			tx.Rollback()
+			return err
+		}
+		if err := insertTodo(tx, &todos[i]); err != nil {
+			tx.Rollback()
+			return fmt.Errorf("failed to create todo %q: %v", todos[i].Title, err)
+		}
+	}
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit transaction: %v", err)
+	}
+
+	d.ids.next += len(todos)
+	return nil
+}
+
+// prepareNewTodo 为新建的待办事项分配id并填充创建时间和各项默认值
+func (d *SQLiteDatabase) prepareNewTodo(todo *Todo, id int) error {
+	todo.ID = id
 	todo.CreatedDate = time.Now()
 	todo.LastUpdated = time.Now()
 
@@ -338,16 +601,39 @@ func (d *SQLiteDatabase) CreateTodo(todo *Todo) error {
 	if todo.Category == "" {
 		todo.Category = "personal"
 	}
+	todo.CompletedAt = completedAt(todo.Status, nil)
+	todo.ChecklistProgress = todo.Checklist.Progress()
+	todo.AutoTags = AutoTagsFor(*todo)
+	if todo.DueDate == nil {
+		dueDate, err := d.defaultDueDate(config.Cfg.DefaultDue, todo.CreatedDate)
+		if err != nil {
+			return fmt.Errorf("failed to compute default due date: %v", err)
+		}
+		todo.DueDate = dueDate
+	}
+	if todo.EstimatedDuration == "" {
+		todo.EstimatedDuration = defaultEstimate(config.Cfg.DefaultEstimates, todo.Priority)
+	}
+	return nil
+}
+
+// execer 可以执行写语句的数据库连接或事务
+type execer interface {
+	Exec(query string, args ...interface{}) (sql.Result, error)
+}
 
+// insertTodo 插入一条已填充默认值的待办事项
+func insertTodo(exec execer, todo *Todo) error {
 	var dueDate interface{}
 	if todo.DueDate != nil {
 		dueDate = todo.DueDate
 	} else {
 		dueDate = nil
 	}
+	externalSystem, externalID, externalURL := todo.ExternalRef.columns()
 
-	_, err := d.db.Exec(
-		"INSERT INTO todos (id, title, description, priority, status, created_date, due_date, last_updated, estimated_duration, category) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?)",
+	_, err := exec.Exec(
+		"INSERT INTO todos (id, title, description, priority, status, created_date, due_date, last_updated, estimated_duration, category, completed_at, parent_id, checklist, waiting_on, scheduled_start, scheduled_end, all_day, is_starred, source, recurrence, project_id, raw_input, remind_before, reminder_message, external_system, external_id, external_url, tags, energy_level, effort_points) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)",
 		todo.ID,
 		todo.Title,
 		todo.Description,
@@ -358,14 +644,28 @@ func (d *SQLiteDatabase) CreateTodo(todo *Todo) error {
 		todo.LastUpdated,
 		todo.EstimatedDuration,
 		todo.Category,
+		todo.CompletedAt,
+		todo.ParentID,
+		todo.Checklist,
+		todo.WaitingOn,
+		todo.ScheduledStart,
+		todo.ScheduledEnd,
+		todo.AllDay,
+		todo.IsStarred,
+		todo.Source,
+		todo.Recurrence,
+		todo.ProjectID,
+		todo.RawInput,
+		todo.RemindBefore,
+		todo.ReminderMessage,
+		externalSystem,
+		externalID,
+		externalURL,
+		todo.Tags,
+		todo.EnergyLevel,
+		todo.EffortPoints,
 	)
-
-	if err != nil {
-		return fmt.Errorf("failed to create todo: %v", err)
-	}
-
-	d.nextID++
-	return nil
+	return err
 }
 
 func (d *SQLiteDatabase) UpdateTodo(todo *Todo) error {
@@ -375,19 +675,142 @@ func (d *SQLiteDatabase) UpdateTodo(todo *Todo) error {
 		return err
 	}
 
+	// 调用方没有显式修改状态时，按清单进度自动推导
+	explicitStatus := todo.Status != existingTodo.Status
+	if config.Cfg.AutoStatus && !explicitStatus {
+		todo.Status = progressStatus(todo.Status, existingTodo.ChecklistProgress, todo.Checklist)
+	}
+
+	// 变为completed时按SUBTASK_COMPLETION_MODE处理未完成的子任务
+	var subtasks []Todo
+	if todo.Status == "completed" && existingTodo.Status != "completed" {
+		subtasks, err = d.checkSubtasksOnComplete(todo.ID)
+		var blocked *ErrIncompleteSubtasks
+		if errors.As(err, &blocked) && !explicitStatus {
+			// 按清单进度自动完成被阻止时保持原状态，清单修改照常保存
+			todo.Status, err = existingTodo.Status, nil
+		}
+		if err != nil {
+			return err
+		}
+	}
+
 	// 保留创建日期，更新最后修改日期
 	todo.CreatedDate = existingTodo.CreatedDate
 	todo.LastUpdated = time.Now()
+	todo.CompletedAt = completedAt(todo.Status, existingTodo.CompletedAt)
+	todo.ChecklistProgress = todo.Checklist.Progress()
+	todo.AutoTags = AutoTagsFor(*todo)
+
+	// complete模式下一并完成的子孙任务，记录修改前的快照用于审计
+	before := make([]Todo, len(subtasks))
+	copy(before, subtasks)
+	for i := range subtasks {
+		markSubtaskCompleted(&subtasks[i], todo.Source, todo.LastUpdated)
+	}
+
+	// 父任务和子孙任务在同一个事务中写入，失败时整体回滚，busy时可以安全重试
+	err = withRetry(func() error {
+		tx, err := d.db.Begin()
+		if err != nil {
+			return fmt.Errorf("failed to begin transaction: %v", err)
+		}
+		if err := updateTodo(tx, todo); err != nil {
+			tx.Rollback()
+			return err
+		}
+		for i := range subtasks {
+			if err := updateTodo(tx, &subtasks[i]); err != nil {
+				tx.Rollback()
+				return fmt.Errorf("failed to complete subtask %d: %v", subtasks[i].ID, err)
+			}
+		}
+		return tx.Commit()
+	})
+	if err != nil {
+		return fmt.Errorf("failed to update todo: %v", err)
+	}
+
+	if err := d.recordAudit(existingTodo, todo, todo.LastUpdated); err != nil {
+		log.Printf("Warning: %v", err)
+	}
+	for i := range subtasks {
+		if err := d.recordAudit(&before[i], &subtasks[i], todo.LastUpdated); err != nil {
+			log.Printf("Warning: %v", err)
+		}
+	}
+	if todo.Status == "completed" {
+		if _, err := d.db.Exec(clearFinishedFocus); err != nil {
+			log.Printf("Warning: failed to clean up focus: %v", err)
+		}
+	}
+
+	return nil
+}
+
+// updateTodos 在一个事务中读取ids对应的待办事项，经edit修改后写回，任一项失败时全部回滚；
+// edit只修改不涉及状态的字段，提交后记录审计快照
+func (d *SQLiteDatabase) updateTodos(ids []int, edit func(i int, todo *Todo)) error {
+	var before, after []Todo
+	err := withRetry(func() error {
+		before, after = nil, nil
+		tx, err := d.db.Begin()
+		if err != nil {
+			return fmt.Errorf("failed to begin transaction: %v", err)
+		}
+
+		now := time.Now()
+		for i, id := range ids {
+			todo, err := scanTodo(tx.QueryRow("SELECT "+todoColumns+" FROM todos WHERE id = ?", id))
+			if err == sql.ErrNoRows {
+				tx.Rollback()
+				return &ErrTodoNotFound{ID: id}
+			} else if err != nil {
+				tx.Rollback()
+				return fmt.Errorf("failed to get todo: %v", err)
+			}
+
+			updated := todo
+			edit(i, &updated)
+			updated.LastUpdated = now
+			updated.AutoTags = AutoTagsFor(updated)
+			if err := updateTodo(tx, &updated); err != nil {
+				tx.Rollback()
+				return fmt.Errorf("failed to update todo %d: %v", id, err)
+			}
+			before = append(before, todo)
+			after = append(after, updated)
+		}
+
+		if err := tx.Commit(); err != nil {
+			return fmt.Errorf("failed to commit transaction: %v", err)
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	for i := range after {
+		if err := d.recordAudit(&before[i], &after[i], after[i].LastUpdated); err != nil {
+			log.Printf("Warning: %v", err)
+		}
+	}
+	return nil
+}
 
+// updateTodo 按ID写回待办事项的全部可修改字段
+func updateTodo(exec execer, todo *Todo) error {
 	var dueDate interface{}
 	if todo.DueDate != nil {
 		dueDate = todo.DueDate
 	} else {
 		dueDate = nil
 	}
+	externalSystem, externalID, externalURL := todo.ExternalRef.columns()
 
-	_, err = d.db.Exec(
-		"UPDATE todos SET title = ?, description = ?, priority = ?, status = ?, due_date = ?, last_updated = ?, estimated_duration = ?, category = ? WHERE id = ?",
+	_, err := exec.Exec(
+		"UPDATE todos SET title = ?, description = ?, priority = ?, status = ?, due_date = ?, last_updated = ?, estimated_duration = ?, category = ?, completed_at = ?, parent_id = ?, checklist = ?, waiting_on = ?, scheduled_start = ?, scheduled_end = ?, all_day = ?, is_starred = ?, source = ?, recurrence = ?, project_id = ?, raw_input = ?, remind_before = ?, reminder_message = ?, external_system = ?, external_id = ?, external_url = ?, tags = ?, energy_level = ?, effort_points = ? WHERE id = ?",
 		todo.Title,
 		todo.Description,
 		todo.Priority,
@@ -396,32 +819,29 @@ func (d *SQLiteDatabase) UpdateTodo(todo *Todo) error {
 		todo.LastUpdated,
 		todo.EstimatedDuration,
 		todo.Category,
+		todo.CompletedAt,
+		todo.ParentID,
+		todo.Checklist,
+		todo.WaitingOn,
+		todo.ScheduledStart,
+		todo.ScheduledEnd,
+		todo.AllDay,
+		todo.IsStarred,
+		todo.Source,
+		todo.Recurrence,
+		todo.ProjectID,
+		todo.RawInput,
+		todo.RemindBefore,
+		todo.ReminderMessage,
+		externalSystem,
+		externalID,
+		externalURL,
+		todo.Tags,
+		todo.EnergyLevel,
+		todo.EffortPoints,
 		todo.ID,
 	)
-
-	if err != nil {
-		return fmt.Errorf("failed to update todo: %v", err)
-	}
-
-	return nil
-}
-
-func (d *SQLiteDatabase) DeleteTodo(id int) error {
-	result, err := d.db.Exec("DELETE FROM todos WHERE id = ?", id)
-	if err != nil {
-		return fmt.Errorf("failed to delete todo: %v", err)
-	}
-
-	affected, err := result.RowsAffected()
-	if err != nil {
-		return fmt.Errorf("error checking affected rows: %v", err)
-	}
-
-	if affected == 0 {
-		return fmt.Errorf("todo with ID %d not found", id)
-	}
-
-	return nil
+	return err
 }
 
 func (d *SQLiteDatabase) GetUserProfile() (*UserProfile, error) {
@@ -458,6 +878,16 @@ func (d *SQLiteDatabase) GetUserProfile() (*UserProfile, error) {
 	return &profile, nil
 }
 
+// LoadProfileOrDefault 读取用户配置，失败时记录警告并返回使用默认工作时间和UTC时区的配置
+func (d *SQLiteDatabase) LoadProfileOrDefault() *UserProfile {
+	profile, err := d.GetUserProfile()
+	if err != nil {
+		log.Printf("Warning: failed to load user profile, using defaults: %v", err)
+		return &UserProfile{WorkSchedule: DefaultWorkSchedule()}
+	}
+	return profile
+}
+
 func (d *SQLiteDatabase) Close() error {
 	if d.db != nil {
 		return d.db.Close()
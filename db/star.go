@@ -0,0 +1,24 @@
+package db
+
+import (
+	"fmt"
+	"time"
+)
+
+// SetStarred 设置待办事项的星标状态
+func (d *SQLiteDatabase) SetStarred(id int, starred bool, source string) (*Todo, error) {
+	result, err := d.db.Exec("UPDATE todos SET is_starred = ?, last_updated = ?, source = ? WHERE id = ?", starred, time.Now(), source, id)
+	if err != nil {
+		return nil, fmt.Errorf("failed to update star: %v", err)
+	}
+
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return nil, fmt.Errorf("error checking affected rows: %v", err)
+	}
+	if affected == 0 {
+		return nil, fmt.Errorf("todo with ID %d not found", id)
+	}
+
+	return d.GetTodoByID(id)
+}
@@ -0,0 +1,75 @@
+package db
+
+import (
+	"time"
+)
+
+// AccountStats 全部待办事项的汇总统计
+type AccountStats struct {
+	Total              int            `json:"total"`
+	ByStatus           map[string]int `json:"by_status"`
+	ByPriority         map[string]int `json:"by_priority"`
+	Overdue            int            `json:"overdue"`
+	CompletedThisWeek  int            `json:"completed_this_week"`
+	AvgCompletionHours float64        `json:"avg_completion_hours"` // 创建到完成的平均耗时，无完成记录时为0
+	OldestOpenAgeHours float64        `json:"oldest_open_age_hours"`
+	OldestOpenID       *int           `json:"oldest_open_id"`
+}
+
+// GetAccountStats 以一次查询读取所有待办事项并计算统计
+func (d *SQLiteDatabase) GetAccountStats(now time.Time, grace time.Duration) (AccountStats, error) {
+	todos, err := d.queryTodos("SELECT " + todoColumns + " FROM todos")
+	if err != nil {
+		return AccountStats{}, err
+	}
+	return ComputeStats(todos, now, grace), nil
+}
+
+// ComputeStats 计算统计，本周从now所在时区的周一零点开始，过期判定使用grace宽限期
+func ComputeStats(todos []Todo, now time.Time, grace time.Duration) AccountStats {
+	stats := AccountStats{
+		Total:      len(todos),
+		ByStatus:   map[string]int{},
+		ByPriority: map[string]int{},
+	}
+
+	offset := (int(now.Weekday()) + 6) % 7
+	weekStart := time.Date(now.Year(), now.Month(), now.Day()-offset, 0, 0, 0, 0, now.Location())
+
+	var completionTotal time.Duration
+	var completionCount int
+	var oldest *Todo
+
+	for i, todo := range todos {
+		stats.ByStatus[todo.Status]++
+		stats.ByPriority[todo.Priority]++
+
+		if todo.Status == "completed" {
+			if todo.CompletedAt != nil {
+				if !todo.CompletedAt.Before(weekStart) {
+					stats.CompletedThisWeek++
+				}
+				completionTotal += todo.CompletedAt.Sub(todo.CreatedDate)
+				completionCount++
+			}
+			continue
+		}
+
+		if IsOverdue(todo, now, grace) {
+			stats.Overdue++
+		}
+		if oldest == nil || todo.CreatedDate.Before(oldest.CreatedDate) {
+			oldest = &todos[i]
+		}
+	}
+
+	if completionCount > 0 {
+		stats.AvgCompletionHours = (completionTotal / time.Duration(completionCount)).Hours()
+	}
+	if oldest != nil {
+		id := oldest.ID
+		stats.OldestOpenID = &id
+		stats.OldestOpenAgeHours = now.Sub(oldest.CreatedDate).Hours()
+	}
+	return stats
+}
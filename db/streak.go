@@ -0,0 +1,74 @@
+package db
+
+import (
+	"sort"
+	"time"
+)
+
+// CompletionStreak 连续有任务完成的天数（按用户时区的自然日）
+type CompletionStreak struct {
+	Timezone string `json:"timezone"`
+	// Current 截至今天的连续天数；今天还没有完成任务时从昨天开始计算，昨天也没有时为0
+	Current      int    `json:"current"`
+	CurrentStart string `json:"current_start,omitempty"`
+	Longest      int    `json:"longest"`
+	LongestStart string `json:"longest_start,omitempty"`
+	LongestEnd   string `json:"longest_end,omitempty"`
+	// LastCompleted 最近一次有任务完成的日期
+	LastCompleted string `json:"last_completed,omitempty"`
+}
+
+// civilDay 将t在loc中的日期表示为UTC零点，按天加减时不受夏令时影响
+func civilDay(t time.Time, loc *time.Location) time.Time {
+	y, m, d := t.In(loc).Date()
+	return time.Date(y, m, d, 0, 0, 0, 0, time.UTC)
+}
+
+// ComputeCompletionStreak 按CompletedAt在loc中的日期计算当前和最长的连续完成天数，没有完成时间的不计入
+func ComputeCompletionStreak(todos []Todo, now time.Time, loc *time.Location) CompletionStreak {
+	streak := CompletionStreak{Timezone: loc.String()}
+
+	seen := map[time.Time]bool{}
+	var days []time.Time
+	for _, todo := range todos {
+		if todo.Status != "completed" || todo.CompletedAt == nil {
+			continue
+		}
+		day := civilDay(*todo.CompletedAt, loc)
+		if !seen[day] {
+			seen[day] = true
+			days = append(days, day)
+		}
+	}
+	if len(days) == 0 {
+		return streak
+	}
+	sort.Slice(days, func(i, j int) bool { return days[i].Before(days[j]) })
+
+	const layout = "2006-01-02"
+	start, length := days[0], 1
+	for i := 1; i <= len(days); i++ {
+		if i < len(days) && days[i].Equal(days[i-1].AddDate(0, 0, 1)) {
+			length++
+			continue
+		}
+		// 一段连续的天数在days[i-1]结束
+		if length >= streak.Longest {
+			streak.Longest = length
+			streak.LongestStart = start.Format(layout)
+			streak.LongestEnd = days[i-1].Format(layout)
+		}
+		if i < len(days) {
+			start, length = days[i], 1
+		}
+	}
+
+	last := days[len(days)-1]
+	streak.LastCompleted = last.Format(layout)
+	today := civilDay(now, loc)
+	if last.Equal(today) || last.Equal(today.AddDate(0, 0, -1)) {
+		streak.Current = length
+		streak.CurrentStart = start.Format(layout)
+	}
+	return streak
+}
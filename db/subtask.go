@@ -0,0 +1,176 @@
+package db
+
+import (
+	"database/sql"
+	"fmt"
+)
+
+// DeleteMode 删除带有子任务的待办事项时对子任务的处理方式
+type DeleteMode string
+
+const (
+	DeleteBlock    DeleteMode = "block"    // 存在子任务时拒绝删除
+	DeleteCascade  DeleteMode = "cascade"  // 同时删除所有子孙任务
+	DeleteReparent DeleteMode = "reparent" // 将子任务挂到被删除任务的父任务下
+)
+
+// ErrHasSubtasks 在block模式下删除仍有子任务的待办事项时返回
+type ErrHasSubtasks struct {
+	ID    int
+	Count int
+}
+
+func (e *ErrHasSubtasks) Error() string {
+	return fmt.Sprintf("todo with ID %d has %d subtasks; use mode cascade or reparent", e.ID, e.Count)
+}
+
+// ParseDeleteMode 解析删除模式，为空时使用最安全的block
+func ParseDeleteMode(s string) (DeleteMode, error) {
+	switch DeleteMode(s) {
+	case "":
+		return DeleteBlock, nil
+	case DeleteBlock, DeleteCascade, DeleteReparent:
+		return DeleteMode(s), nil
+	}
+	return "", fmt.Errorf("invalid delete mode %q, expected block, cascade or reparent", s)
+}
+
+// DeleteTodo 按mode处理子任务后删除待办事项
+func (d *SQLiteDatabase) DeleteTodo(id int, mode DeleteMode) error {
+	// 整个事务失败时已回滚，busy时可以安全重试
+	return withRetry(func() error { return d.deleteTodo(id, mode) })
+}
+
+func (d *SQLiteDatabase) deleteTodo(id int, mode DeleteMode) error {
+	tx, err := d.db.Begin()
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %v", err)
+	}
+
+	if err := deleteTodoTx(tx, id, mode); err != nil {
+		tx.Rollback()
+		return err
+	}
+
+	// 清理指向已删除待办事项的依赖和焦点
+	if _, err := tx.Exec(
+		"DELETE FROM todo_dependencies WHERE todo_id NOT IN (SELECT id FROM todos) OR depends_on_id NOT IN (SELECT id FROM todos)",
+	); err != nil {
+		tx.Rollback()
+		return fmt.Errorf("failed to clean up dependencies: %v", err)
+	}
+	if _, err := tx.Exec(clearFinishedFocus); err != nil {
+		tx.Rollback()
+		return fmt.Errorf("failed to clean up focus: %v", err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit transaction: %v", err)
+	}
+	return nil
+}
+
+func deleteTodoTx(tx *tracedTx, id int, mode DeleteMode) error {
+	var parentID sql.NullInt64
+	err := tx.QueryRow("SELECT parent_id FROM todos WHERE id = ?", id).Scan(&parentID)
+	if err == sql.ErrNoRows {
+		return fmt.Errorf("todo with ID %d not found", id)
+	} else if err != nil {
+		return fmt.Errorf("failed to get todo: %v", err)
+	}
+
+	switch mode {
+	case DeleteBlock:
+		var count int
+		if err := tx.QueryRow("SELECT COUNT(*) FROM todos WHERE parent_id = ?", id).Scan(&count); err != nil {
+			return fmt.Errorf("failed to count subtasks: %v", err)
+		}
+		if count > 0 {
+			return &ErrHasSubtasks{ID: id, Count: count}
+		}
+	case DeleteCascade:
+		_, err = tx.Exec(
+			`WITH RECURSIVE descendants(id) AS (
+				SELECT id FROM todos WHERE parent_id = ?
+				UNION
+				SELECT t.id FROM todos t JOIN descendants d ON t.parent_id = d.id
+			)
+			DELETE FROM todos WHERE id IN (SELECT id FROM descendants)`,
+			id,
+		)
+		if err != nil {
+			return fmt.Errorf("failed to delete subtasks: %v", err)
+		}
+	case DeleteReparent:
+		var newParent interface{}
+		if parentID.Valid {
+			newParent = parentID.Int64
+		}
+		if _, err := tx.Exec("UPDATE todos SET parent_id = ? WHERE parent_id = ?", newParent, id); err != nil {
+			return fmt.Errorf("failed to reparent subtasks: %v", err)
+		}
+	default:
+		return fmt.Errorf("invalid delete mode %q", mode)
+	}
+
+	if _, err := tx.Exec("DELETE FROM todos WHERE id = ?", id); err != nil {
+		return fmt.Errorf("failed to delete todo: %v", err)
+	}
+	return nil
+}
+
+// DeletePreview 删除操作将影响的待办事项
+type DeletePreview struct {
+	Mode       DeleteMode `json:"mode"`
+	Deleted    []Todo     `json:"deleted"`    // 将被删除的待办事项（含cascade模式下的子孙任务）
+	Reparented []Todo     `json:"reparented"` // reparent模式下将被挂到上级任务的子任务
+	Error      string     `json:"error,omitempty"`
+}
+
+// GetSubtasks 返回待办事项的直接子任务，按ID排序
+func (d *SQLiteDatabase) GetSubtasks(id int) ([]Todo, error) {
+	return d.queryTodos("SELECT "+todoColumns+" FROM todos WHERE parent_id = ? ORDER BY id", id)
+}
+
+// PreviewDeleteTodo 返回按mode删除待办事项将影响的范围，不修改数据
+func (d *SQLiteDatabase) PreviewDeleteTodo(id int, mode DeleteMode) (*DeletePreview, error) {
+	todo, err := d.GetTodoByID(id)
+	if err != nil {
+		return nil, err
+	}
+
+	children, err := d.GetSubtasks(id)
+	if err != nil {
+		return nil, err
+	}
+
+	preview := &DeletePreview{Mode: mode, Deleted: []Todo{}, Reparented: []Todo{}}
+	switch mode {
+	case DeleteBlock:
+		if len(children) > 0 {
+			preview.Error = (&ErrHasSubtasks{ID: id, Count: len(children)}).Error()
+			return preview, nil
+		}
+	case DeleteCascade:
+		descendants, err := d.queryTodos(
+			`WITH RECURSIVE descendants(id) AS (
+				SELECT id FROM todos WHERE parent_id = ?
+				UNION
+				SELECT t.id FROM todos t JOIN descendants d ON t.parent_id = d.id
+			)
+			SELECT `+todoColumns+` FROM todos WHERE id IN (SELECT id FROM descendants) ORDER BY id`,
+			id,
+		)
+		if err != nil {
+			return nil, err
+		}
+		preview.Deleted = append(preview.Deleted, descendants...)
+	case DeleteReparent:
+		preview.Reparented = append(preview.Reparented, children...)
+	default:
+		return nil, fmt.Errorf("invalid delete mode %q", mode)
+	}
+
+	preview.Deleted = append([]Todo{*todo}, preview.Deleted...)
+	return preview, nil
+}
@@ -0,0 +1,91 @@
+package db
+
+import (
+	"database/sql/driver"
+	"encoding/json"
+	"fmt"
+	"fydeos/config"
+	"strconv"
+	"strings"
+)
+
+// Tags 手动添加的标签，以JSON形式存储在todos.tags列中
+type Tags []string
+
+// Value 实现driver.Valuer，没有标签时存为NULL
+func (t Tags) Value() (driver.Value, error) {
+	if len(t) == 0 {
+		return nil, nil
+	}
+	data, err := json.Marshal(t)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal tags: %v", err)
+	}
+	return string(data), nil
+}
+
+// normalizeTags 去掉空白、转为小写并去重，保持原有顺序
+func normalizeTags(tags Tags) Tags {
+	var normalized Tags
+	seen := map[string]bool{}
+	for _, tag := range tags {
+		tag = strings.ToLower(strings.TrimSpace(tag))
+		if tag == "" || seen[tag] {
+			continue
+		}
+		seen[tag] = true
+		normalized = append(normalized, tag)
+	}
+	return normalized
+}
+
+// AutoTagsFor 按AUTO_TAGS规则返回待办事项当前类别和项目对应的标签，已手动添加的标签不重复返回。
+// 自动标签不保存，类别或项目变化后下次读取即重新计算
+func AutoTagsFor(todo Todo) []string {
+	auto := []string{}
+	seen := map[string]bool{}
+	for _, tag := range todo.Tags {
+		seen[tag] = true
+	}
+	for _, rule := range config.Cfg.AutoTags {
+		var matched bool
+		switch rule.Field {
+		case "category":
+			matched = strings.EqualFold(todo.Category, rule.Value)
+		case "project":
+			matched = todo.ProjectID != nil && strconv.Itoa(*todo.ProjectID) == rule.Value
+		}
+		if matched && !seen[rule.Tag] {
+			seen[rule.Tag] = true
+			auto = append(auto, rule.Tag)
+		}
+	}
+	return auto
+}
+
+// HasTag 待办事项是否带有tag（手动或自动），不区分大小写
+func (t Todo) HasTag(tag string) bool {
+	tag = strings.ToLower(strings.TrimSpace(tag))
+	for _, list := range [][]string{t.Tags, t.AutoTags} {
+		for _, existing := range list {
+			if existing == tag {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// FilterByTag 返回带有tag（手动或自动）的待办事项，tag为空时原样返回
+func FilterByTag(todos []Todo, tag string) []Todo {
+	if tag == "" {
+		return todos
+	}
+	filtered := []Todo{}
+	for _, todo := range todos {
+		if todo.HasTag(tag) {
+			filtered = append(filtered, todo)
+		}
+	}
+	return filtered
+}
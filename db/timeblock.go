@@ -0,0 +1,59 @@
+package db
+
+import (
+	"fmt"
+	"time"
+)
+
+// ErrScheduleConflict 排期与其他已排期任务的时间窗口重叠时返回
+type ErrScheduleConflict struct {
+	Conflicts []Conflict
+}
+
+func (e *ErrScheduleConflict) Error() string {
+	return fmt.Sprintf("schedule overlaps %d scheduled todos; use force to override", len(e.Conflicts))
+}
+
+// ScheduleTodo 将待办事项排期到start开始的时间段，结束时间按预计耗时计算，状态设为scheduled。
+// 与其他已排期任务重叠时返回*ErrScheduleConflict，force为true时忽略冲突
+func (d *SQLiteDatabase) ScheduleTodo(id int, start time.Time, force bool, source string) (*Todo, error) {
+	todo, err := d.GetTodoByID(id)
+	if err != nil {
+		return nil, err
+	}
+
+	end := start.Add(ParseEstimatedDuration(todo.EstimatedDuration))
+	todo.Status = "scheduled"
+	todo.ScheduledStart = &start
+	todo.ScheduledEnd = &end
+	todo.Source = source
+
+	if !force {
+		todos, err := d.GetAllTodos()
+		if err != nil {
+			return nil, err
+		}
+
+		candidates := []Todo{*todo}
+		for _, other := range todos {
+			if other.ID != id {
+				candidates = append(candidates, other)
+			}
+		}
+
+		var conflicts []Conflict
+		for _, c := range DetectConflicts(candidates) {
+			if c.First.ID == id || c.Second.ID == id {
+				conflicts = append(conflicts, c)
+			}
+		}
+		if len(conflicts) > 0 {
+			return nil, &ErrScheduleConflict{Conflicts: conflicts}
+		}
+	}
+
+	if err := d.UpdateTodo(todo); err != nil {
+		return nil, err
+	}
+	return todo, nil
+}
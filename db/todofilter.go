@@ -0,0 +1,110 @@
+package db
+
+import (
+	"fmt"
+	"strings"
+	"time"
+)
+
+// TodoFilter 在SQL中筛选待办事项的条件。同一字段的多个取值为"或"，不同字段之间为"且"，为空的字段不限制
+type TodoFilter struct {
+	Statuses   []string
+	Priorities []string
+	Categories []string
+	Starred    bool // 只返回星标任务
+	Overdue    bool // 只返回已过期任务（按后台刷新的标记）
+	Stale      bool // 只返回陈旧任务（按后台刷新的标记）
+	// DueFrom/DueTo 截止日期在[DueFrom, DueTo)内，设置任一端时排除没有截止日期的任务
+	DueFrom *time.Time
+	DueTo   *time.Time
+}
+
+// ParseDueBounds 解析截止日期范围，支持"2006-01-02"和RFC3339格式，日期格式的to包含当天；为空的一端返回nil
+func ParseDueBounds(from, to string, loc *time.Location) (*time.Time, *time.Time, error) {
+	var start, end *time.Time
+	if from != "" {
+		t, _, err := parseDateParam(from, loc)
+		if err != nil {
+			return nil, nil, fmt.Errorf("invalid due_from: %v", err)
+		}
+		start = &t
+	}
+	if to != "" {
+		t, dateOnly, err := parseDateParam(to, loc)
+		if err != nil {
+			return nil, nil, fmt.Errorf("invalid due_to: %v", err)
+		}
+		if dateOnly {
+			t = t.AddDate(0, 0, 1)
+		}
+		end = &t
+	}
+	if start != nil && end != nil && !start.Before(*end) {
+		return nil, nil, fmt.Errorf("due_from must be before due_to")
+	}
+	return start, end, nil
+}
+
+// where 生成参数化的WHERE子句及其参数
+func (f TodoFilter) where() (string, []interface{}) {
+	conds := []string{"1 = 1"}
+	var args []interface{}
+
+	in := func(column string, values []string) {
+		if len(values) == 0 {
+			return
+		}
+		placeholders := make([]string, len(values))
+		for i, v := range values {
+			placeholders[i] = "?"
+			args = append(args, v)
+		}
+		conds = append(conds, column+" IN ("+strings.Join(placeholders, ", ")+")")
+	}
+	in("status", f.Statuses)
+	in("priority", f.Priorities)
+	in("category", f.Categories)
+
+	if f.Starred {
+		conds = append(conds, "is_starred = 1")
+	}
+	if f.Overdue {
+		conds = append(conds, "is_overdue = 1")
+	}
+	if f.Stale {
+		conds = append(conds, "is_stale = 1")
+	}
+	if f.DueFrom != nil {
+		conds = append(conds, "due_date >= ?")
+		args = append(args, *f.DueFrom)
+	}
+	if f.DueTo != nil {
+		conds = append(conds, "due_date < ?")
+		args = append(args, *f.DueTo)
+	}
+	return strings.Join(conds, " AND "), args
+}
+
+// QueryTodos 按filter在数据库中筛选待办事项，排序与GetAllTodos一致
+func (d *SQLiteDatabase) QueryTodos(filter TodoFilter) ([]Todo, error) {
+	where, args := filter.where()
+	return d.queryTodos("SELECT "+todoColumns+" FROM todos WHERE "+where+" ORDER BY "+listOrder(), args...)
+}
+
+// QueryTodosPaged 按filter筛选并分页返回待办事项，同时返回符合条件的总数。
+// 排序与QueryTodos一致并以id兜底，保证翻页时顺序稳定
+func (d *SQLiteDatabase) QueryTodosPaged(filter TodoFilter, limit, offset int) ([]Todo, int, error) {
+	where, args := filter.where()
+
+	var total int
+	if err := d.db.QueryRow("SELECT COUNT(*) FROM todos WHERE "+where, args...).Scan(&total); err != nil {
+		return nil, 0, fmt.Errorf("failed to count todos: %v", err)
+	}
+
+	todos, err := d.queryTodos("SELECT "+todoColumns+" FROM todos WHERE "+where+" ORDER BY "+listOrder()+" LIMIT ? OFFSET ?",
+		append(args, limit, offset)...)
+	if err != nil {
+		return nil, 0, err
+	}
+	return todos, total, nil
+}
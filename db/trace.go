@@ -0,0 +1,118 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fydeos/tracing"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// tracedDB 在ctx下执行SQL语句，每条语句记录为ctx中span的一个子span。
+// 语句执行不受ctx取消影响，客户端断开时不会中断进行中的写入
+type tracedDB struct {
+	conn *sql.DB
+	ctx  context.Context
+}
+
+func (t *tracedDB) Query(query string, args ...interface{}) (*sql.Rows, error) {
+	ctx, span := startSpan(t.ctx, "db.query", query)
+	rows, err := t.conn.QueryContext(context.WithoutCancel(ctx), query, args...)
+	endSpan(span, err)
+	return rows, err
+}
+
+func (t *tracedDB) QueryRow(query string, args ...interface{}) *sql.Row {
+	ctx, span := startSpan(t.ctx, "db.query", query)
+	row := t.conn.QueryRowContext(context.WithoutCancel(ctx), query, args...)
+	endSpan(span, row.Err())
+	return row
+}
+
+func (t *tracedDB) Exec(query string, args ...interface{}) (sql.Result, error) {
+	ctx, span := startSpan(t.ctx, "db.exec", query)
+	res, err := t.conn.ExecContext(context.WithoutCancel(ctx), query, args...)
+	endSpan(span, err)
+	return res, err
+}
+
+// Begin 开始事务，事务内的语句记录为db.transaction span的子span，提交或回滚时结束该span
+func (t *tracedDB) Begin() (*tracedTx, error) {
+	ctx, span := tracing.Tracer().Start(t.ctx, "db.transaction",
+		trace.WithSpanKind(trace.SpanKindClient),
+		trace.WithAttributes(attribute.String("db.system", "sqlite")),
+	)
+	tx, err := t.conn.BeginTx(context.WithoutCancel(ctx), nil)
+	if err != nil {
+		endSpan(span, err)
+		return nil, err
+	}
+	return &tracedTx{tx: tx, ctx: ctx, span: span}, nil
+}
+
+func (t *tracedDB) Close() error {
+	return t.conn.Close()
+}
+
+// tracedTx 记录事务内每条语句的*sql.Tx
+type tracedTx struct {
+	tx   *sql.Tx
+	ctx  context.Context
+	span trace.Span
+}
+
+func (t *tracedTx) Query(query string, args ...interface{}) (*sql.Rows, error) {
+	ctx, span := startSpan(t.ctx, "db.query", query)
+	rows, err := t.tx.QueryContext(context.WithoutCancel(ctx), query, args...)
+	endSpan(span, err)
+	return rows, err
+}
+
+func (t *tracedTx) QueryRow(query string, args ...interface{}) *sql.Row {
+	ctx, span := startSpan(t.ctx, "db.query", query)
+	row := t.tx.QueryRowContext(context.WithoutCancel(ctx), query, args...)
+	endSpan(span, row.Err())
+	return row
+}
+
+func (t *tracedTx) Exec(query string, args ...interface{}) (sql.Result, error) {
+	ctx, span := startSpan(t.ctx, "db.exec", query)
+	res, err := t.tx.ExecContext(context.WithoutCancel(ctx), query, args...)
+	endSpan(span, err)
+	return res, err
+}
+
+func (t *tracedTx) Commit() error {
+	err := t.tx.Commit()
+	endSpan(t.span, err)
+	return err
+}
+
+func (t *tracedTx) Rollback() error {
+	err := t.tx.Rollback()
+	t.span.SetStatus(codes.Error, "rolled back")
+	t.span.End()
+	return err
+}
+
+func startSpan(ctx context.Context, name, query string) (context.Context, trace.Span) {
+	return tracing.Tracer().Start(ctx, name,
+		trace.WithSpanKind(trace.SpanKindClient),
+		trace.WithAttributes(
+			attribute.String("db.system", "sqlite"),
+			attribute.String("db.statement", query),
+		),
+	)
+}
+
+// endSpan 记录错误并结束span，没有结果行不算错误
+func endSpan(span trace.Span, err error) {
+	if err != nil && !errors.Is(err, sql.ErrNoRows) {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+	}
+	span.End()
+}
@@ -0,0 +1,232 @@
+package db
+
+import (
+	"fmt"
+	"fydeos/config"
+	"time"
+)
+
+// statusTransitions 允许的状态转换
+var statusTransitions = map[string][]string{
+	"pending":     {"in_progress", "scheduled", "completed"},
+	"in_progress": {"pending", "scheduled", "completed"},
+	"scheduled":   {"pending", "in_progress", "completed"},
+	"completed":   {"pending"},
+}
+
+// TransitionResult 单个待办事项的状态转换结果
+type TransitionResult struct {
+	ID      int    `json:"id"`
+	Success bool   `json:"success"`
+	From    string `json:"from,omitempty"`
+	Error   string `json:"error,omitempty"`
+	Warning string `json:"warning,omitempty"`
+}
+
+// CheckTransition 检查from到to的状态转换是否合法。
+// 不在规则表中的历史状态可以转换为任意已知状态
+func CheckTransition(from, to string) error {
+	if _, ok := statusTransitions[to]; !ok {
+		return fmt.Errorf("unknown status %q", to)
+	}
+	if from == to {
+		return fmt.Errorf("todo is already %s", to)
+	}
+
+	allowed, ok := statusTransitions[from]
+	if !ok {
+		return nil
+	}
+	for _, s := range allowed {
+		if s == to {
+			return nil
+		}
+	}
+	return fmt.Errorf("cannot transition from %s to %s", from, to)
+}
+
+// BulkTransition 逐个将待办事项转换为status，单个失败不影响其他项。
+// 完成时间等副作用由UpdateTodo按项处理；离开scheduled状态时清除排期时间段
+func (d *SQLiteDatabase) BulkTransition(ids []int, status, source string) []TransitionResult {
+	results := make([]TransitionResult, 0, len(ids))
+	for _, id := range ids {
+		result := TransitionResult{ID: id}
+
+		todo, err := d.GetTodoByID(id)
+		if err != nil {
+			result.Error = err.Error()
+			results = append(results, result)
+			continue
+		}
+		result.From = todo.Status
+
+		if err := CheckTransition(todo.Status, status); err != nil {
+			result.Error = err.Error()
+			results = append(results, result)
+			continue
+		}
+
+		if todo.Status == "scheduled" {
+			todo.ScheduledStart = nil
+			todo.ScheduledEnd = nil
+		}
+		todo.Status = status
+		todo.Source = source
+		if err := d.UpdateTodo(todo); err != nil {
+			result.Error = err.Error()
+		} else {
+			result.Success = true
+			result.Warning = d.SubtaskWarning(todo)
+		}
+		results = append(results, result)
+	}
+	return results
+}
+
+// SubtaskCompletionMode 完成仍有未完成子任务的父任务时的处理方式
+type SubtaskCompletionMode string
+
+const (
+	SubtaskBlock    SubtaskCompletionMode = "block"    // 拒绝完成
+	SubtaskComplete SubtaskCompletionMode = "complete" // 同时完成所有未完成的子孙任务
+	SubtaskWarn     SubtaskCompletionMode = "warn"     // 允许完成，并提示仍有未完成的子任务
+)
+
+// ErrIncompleteSubtasks 在block模式下完成仍有未完成子任务的待办事项时返回
+type ErrIncompleteSubtasks struct {
+	ID    int
+	Count int
+}
+
+func (e *ErrIncompleteSubtasks) Error() string {
+	return fmt.Sprintf("todo with ID %d has %d incomplete subtasks; complete them first", e.ID, e.Count)
+}
+
+func subtaskCompletionMode() SubtaskCompletionMode {
+	return SubtaskCompletionMode(config.Cfg.SubtaskCompletion)
+}
+
+// IncompleteSubtasks 返回待办事项未完成的直接子任务
+func (d *SQLiteDatabase) IncompleteSubtasks(id int) ([]Todo, error) {
+	return d.queryTodos("SELECT "+todoColumns+" FROM todos WHERE parent_id = ? AND status != 'completed' ORDER BY id", id)
+}
+
+// SubtaskWarning 待办事项已完成但仍有未完成的子任务时返回提示信息，否则返回空字符串。
+// 通常出现在warn模式下，或父任务完成后又新增了子任务
+func (d *SQLiteDatabase) SubtaskWarning(todo *Todo) string {
+	if todo.Status != "completed" {
+		return ""
+	}
+	children, err := d.IncompleteSubtasks(todo.ID)
+	if err != nil || len(children) == 0 {
+		return ""
+	}
+	return fmt.Sprintf("todo with ID %d was completed with %d incomplete subtasks", todo.ID, len(children))
+}
+
+// checkSubtasksOnComplete 在待办事项变为completed前按SUBTASK_COMPLETION_MODE检查子任务：
+// block模式下存在未完成子任务时返回*ErrIncompleteSubtasks，complete模式下返回需要一并完成的子孙任务
+func (d *SQLiteDatabase) checkSubtasksOnComplete(id int) ([]Todo, error) {
+	switch subtaskCompletionMode() {
+	case SubtaskWarn:
+		return nil, nil
+	case SubtaskBlock:
+		children, err := d.IncompleteSubtasks(id)
+		if err != nil {
+			return nil, err
+		}
+		if len(children) > 0 {
+			return nil, &ErrIncompleteSubtasks{ID: id, Count: len(children)}
+		}
+		return nil, nil
+	}
+	return d.incompleteDescendants(id)
+}
+
+// incompleteDescendants 返回经由未完成子任务可达的所有未完成子孙任务，按ID排序
+func (d *SQLiteDatabase) incompleteDescendants(id int) ([]Todo, error) {
+	return d.queryTodos(
+		`WITH RECURSIVE descendants(id) AS (
+			SELECT id FROM todos WHERE parent_id = ? AND status != 'completed'
+			UNION
+			SELECT t.id FROM todos t JOIN descendants d ON t.parent_id = d.id WHERE t.status != 'completed'
+		)
+		SELECT `+todoColumns+` FROM todos WHERE id IN (SELECT id FROM descendants) ORDER BY id`,
+		id,
+	)
+}
+
+// markSubtaskCompleted 将随父任务一并完成的子任务标记为completed，离开scheduled状态的清除排期时间段
+func markSubtaskCompleted(child *Todo, source string, now time.Time) {
+	if child.Status == "scheduled" {
+		child.ScheduledStart = nil
+		child.ScheduledEnd = nil
+	}
+	child.Status = "completed"
+	child.Source = source
+	child.LastUpdated = now
+	child.CompletedAt = completedAt(child.Status, child.CompletedAt)
+	child.AutoTags = AutoTagsFor(*child)
+}
+
+// resolveSubtaskCompletion 对一批将被完成的待办事项应用子任务规则，open为全部未完成的待办事项。
+// complete模式下加入所有未完成的子孙任务；block模式下剔除仍有不在本批中的未完成子任务的项（返回blocked）；
+// warn模式下全部保留，并返回仍有未完成子任务的项（warned）
+func resolveSubtaskCompletion(batch, open []Todo, mode SubtaskCompletionMode) (complete []Todo, blocked, warned []int) {
+	children := map[int][]Todo{}
+	for _, todo := range open {
+		if todo.ParentID != nil {
+			children[*todo.ParentID] = append(children[*todo.ParentID], todo)
+		}
+	}
+
+	included := map[int]bool{}
+	for _, todo := range batch {
+		included[todo.ID] = true
+	}
+	complete = append(complete, batch...)
+
+	switch mode {
+	case SubtaskComplete:
+		for i := 0; i < len(complete); i++ {
+			for _, child := range children[complete[i].ID] {
+				if !included[child.ID] {
+					included[child.ID] = true
+					complete = append(complete, child)
+				}
+			}
+		}
+	case SubtaskBlock:
+		// 剔除一项后其父任务也可能被阻止，直到没有变化为止
+		for changed := true; changed; {
+			changed = false
+			kept := complete[:0]
+			for _, todo := range complete {
+				if hasOpenChildOutside(children[todo.ID], included) {
+					delete(included, todo.ID)
+					blocked = append(blocked, todo.ID)
+					changed = true
+					continue
+				}
+				kept = append(kept, todo)
+			}
+			complete = kept
+		}
+	default:
+		for _, todo := range complete {
+			if hasOpenChildOutside(children[todo.ID], included) {
+				warned = append(warned, todo.ID)
+			}
+		}
+	}
+	return complete, blocked, warned
+}
+
+func hasOpenChildOutside(children []Todo, included map[int]bool) bool {
+	for _, child := range children {
+		if !included[child.ID] {
+			return true
+		}
+	}
+	return false
+}
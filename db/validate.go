@@ -0,0 +1,134 @@
+package db
+
+import (
+	"errors"
+	"fmt"
+	"fydeos/config"
+	"strings"
+	"time"
+	"unicode/utf8"
+)
+
+// ValidationIssue 校验发现的问题，Field为JSON字段名
+type ValidationIssue struct {
+	Field   string `json:"field"`
+	Message string `json:"message"`
+}
+
+// ValidationResult 校验结果：存在Errors时不能保存，Warnings只作提示
+type ValidationResult struct {
+	Valid    bool              `json:"valid"`
+	Errors   []ValidationIssue `json:"errors"`
+	Warnings []ValidationIssue `json:"warnings"`
+}
+
+func (r *ValidationResult) addError(field, message string) {
+	r.Errors = append(r.Errors, ValidationIssue{Field: field, Message: message})
+}
+
+// Err 将校验错误合并为一个error，校验通过时返回nil
+func (r ValidationResult) Err() error {
+	if r.Valid {
+		return nil
+	}
+	messages := make([]string, 0, len(r.Errors))
+	for _, issue := range r.Errors {
+		messages = append(messages, issue.Message)
+	}
+	return errors.New(strings.Join(messages, "; "))
+}
+
+func (r *ValidationResult) addWarning(field, message string) {
+	r.Warnings = append(r.Warnings, ValidationIssue{Field: field, Message: message})
+}
+
+// ValidateTodo 校验待保存的待办事项，创建、更新和预校验共用
+func ValidateTodo(todo Todo, now time.Time) ValidationResult {
+	result := ValidationResult{Errors: []ValidationIssue{}, Warnings: []ValidationIssue{}}
+
+	limits := config.Cfg.Limits
+	if title := strings.TrimSpace(todo.Title); title == "" {
+		result.addError("title", "title is required")
+	} else if n := utf8.RuneCountInString(title); n < limits.MinTitle {
+		result.addError("title", fmt.Sprintf("title must be at least %d characters (got %d)", limits.MinTitle, n))
+	}
+	if n := utf8.RuneCountInString(todo.Title); n > limits.MaxTitle {
+		result.addError("title", fmt.Sprintf("title must be at most %d characters (got %d)", limits.MaxTitle, n))
+	}
+	if n := utf8.RuneCountInString(todo.Description); n > limits.MaxDescription {
+		result.addError("description", fmt.Sprintf("description must be at most %d characters (got %d)", limits.MaxDescription, n))
+	}
+	if _, ok := priorityRank[todo.Priority]; !ok {
+		result.addError("priority", "priority must be urgent, high, medium or low")
+	}
+	if _, ok := statusTransitions[todo.Status]; !ok {
+		result.addError("status", "status must be pending, in_progress, scheduled or completed")
+	}
+	if _, err := ParseRecurrence(string(todo.Recurrence)); err != nil {
+		result.addError("recurrence", err.Error())
+	}
+	if todo.ScheduledStart != nil && todo.ScheduledEnd != nil && todo.ScheduledEnd.Before(*todo.ScheduledStart) {
+		result.addError("scheduled_end", "scheduled_end must not be before scheduled_start")
+	}
+	if _, ok := energyRank[todo.EnergyLevel]; !ok && todo.EnergyLevel != "" {
+		result.addError("energy_level", "energy_level must be high, medium or low")
+	}
+	if todo.EffortPoints < 0 {
+		result.addError("effort_points", "effort_points must not be negative")
+	}
+	if todo.RemindBefore != nil && *todo.RemindBefore < 0 {
+		result.addError("remind_before_minutes", "remind_before_minutes must not be negative")
+	}
+	if todo.ParentID != nil && todo.ID != 0 && *todo.ParentID == todo.ID {
+		result.addError("parent_id", "a todo cannot be its own parent")
+	}
+
+	if todo.DueDate != nil && todo.Status != "completed" && todo.DueDate.Before(now) {
+		result.addWarning("due_date", "due date is in the past")
+	}
+	if todo.EstimatedDuration != "" && ParseEstimatedDuration(todo.EstimatedDuration) == 0 {
+		result.addWarning("estimated_duration", "estimated duration is not recognized and will count as 0")
+	}
+	if err := ValidateReminderTemplate(todo.ReminderMessage); err != nil {
+		result.addError("reminder_message", err.Error())
+	}
+	if todo.ExternalRef != nil {
+		if err := todo.ExternalRef.validate(); err != nil {
+			result.addError("external_ref", err.Error())
+		}
+	}
+	if todo.RemindBefore != nil && todo.DueDate == nil {
+		result.addWarning("remind_before_minutes", "reminder has no effect without a due date")
+	}
+	if todo.Recurrence != RecurNone && todo.DueDate == nil {
+		result.addError("recurrence", "recurring todo requires a due date")
+	}
+
+	result.Valid = len(result.Errors) == 0
+	return result
+}
+
+// NormalizeTodo 在校验前整理文本字段：去掉标题首尾空白，
+// LENGTH_LIMIT_MODE为truncate时把超长的标题和描述截断到上限
+func NormalizeTodo(todo *Todo) {
+	todo.Title = strings.TrimSpace(todo.Title)
+	todo.Tags = normalizeTags(todo.Tags)
+	todo.EnergyLevel = strings.ToLower(strings.TrimSpace(todo.EnergyLevel))
+	if todo.ExternalRef != nil {
+		todo.ExternalRef.normalize()
+	}
+
+	limits := config.Cfg.Limits
+	if limits.Mode != "truncate" {
+		return
+	}
+	todo.Title = truncateRunes(todo.Title, limits.MaxTitle)
+	todo.Description = truncateRunes(todo.Description, limits.MaxDescription)
+}
+
+func truncateRunes(s string, max int) string {
+	if utf8.RuneCountInString(s) <= max {
+		return s
+	}
+	return string([]rune(s)[:max])
+}
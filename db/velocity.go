@@ -0,0 +1,72 @@
+package db
+
+import (
+	"fmt"
+	"time"
+)
+
+// VelocityWeek 某个ISO周内完成的工作量点数
+type VelocityWeek struct {
+	Year      int       `json:"year"`
+	Week      int       `json:"week"`
+	Start     time.Time `json:"start"` // 周一零点
+	Points    int       `json:"points"`
+	Completed int       `json:"completed"` // 本周完成的带点数任务数
+	Unpointed int       `json:"unpointed"` // 本周完成但没有点数的任务数，不计入Points
+}
+
+// Velocity 最近若干周的完成速度，AveragePoints为不含本周的之前各周平均值（只有本周时为本周点数）
+type Velocity struct {
+	Weeks         []VelocityWeek `json:"weeks"`
+	AveragePoints float64        `json:"average_points"`
+}
+
+// ComputeVelocity 按完成时间统计截至now所在周的最近weeks个ISO周（周一开始，按now的时区）完成的工作量点数，
+// 按时间先后排列。只使用EffortPoints，与预计耗时无关
+func ComputeVelocity(todos []Todo, now time.Time, weeks int) (*Velocity, error) {
+	if weeks < 1 || weeks > maxBreakdownWeeks {
+		return nil, fmt.Errorf("weeks must be between 1 and %d", maxBreakdownWeeks)
+	}
+
+	loc := now.Location()
+	current := time.Date(now.Year(), now.Month(), now.Day()-(int(now.Weekday())+6)%7, 0, 0, 0, 0, loc)
+	first := current.AddDate(0, 0, -7*(weeks-1))
+	velocity := &Velocity{Weeks: make([]VelocityWeek, weeks)}
+	for i := range velocity.Weeks {
+		weekStart := first.AddDate(0, 0, 7*i)
+		year, week := weekStart.ISOWeek()
+		velocity.Weeks[i] = VelocityWeek{Year: year, Week: week, Start: weekStart}
+	}
+
+	end := current.AddDate(0, 0, 7)
+	for _, todo := range todos {
+		if todo.Status != "completed" || todo.CompletedAt == nil {
+			continue
+		}
+		completed := todo.CompletedAt.In(loc)
+		if completed.Before(first) || !completed.Before(end) {
+			continue
+		}
+		// 按日期差计算所在周，跨越夏令时切换时不受一天不足24小时的影响
+		days := int(time.Date(completed.Year(), completed.Month(), completed.Day(), 0, 0, 0, 0, time.UTC).
+			Sub(time.Date(first.Year(), first.Month(), first.Day(), 0, 0, 0, 0, time.UTC)).Hours() / 24)
+		w := &velocity.Weeks[days/7]
+		if todo.EffortPoints > 0 {
+			w.Points += todo.EffortPoints
+			w.Completed++
+		} else {
+			w.Unpointed++
+		}
+	}
+
+	previous := velocity.Weeks[:len(velocity.Weeks)-1]
+	if len(previous) == 0 {
+		previous = velocity.Weeks
+	}
+	total := 0
+	for _, w := range previous {
+		total += w.Points
+	}
+	velocity.AveragePoints = float64(total) / float64(len(previous))
+	return velocity, nil
+}
@@ -0,0 +1,8 @@
+package db
+
+import "strconv"
+
+// Version 待办事项的版本号，由最后修改时间生成，每次通过UpdateTodo更新都会改变
+func (t Todo) Version() string {
+	return strconv.FormatInt(t.LastUpdated.UnixNano(), 10)
+}
@@ -0,0 +1,69 @@
+package db
+
+import (
+	"fmt"
+	"time"
+)
+
+// 单次最多汇总的周数
+const maxBreakdownWeeks = 52
+
+// WeekBucket 某个ISO周内到期的未完成待办事项汇总
+type WeekBucket struct {
+	Year           int       `json:"year"`
+	Week           int       `json:"week"`
+	Start          time.Time `json:"start"` // 周一零点
+	End            time.Time `json:"end"`   // 下周一零点（不含）
+	Count          int       `json:"count"`
+	EstimatedHours float64   `json:"estimated_hours"`
+	TodoIDs        []int     `json:"todo_ids"`
+}
+
+// WeeklyBreakdown 未来若干周的到期汇总，已过期的任务单独结转
+type WeeklyBreakdown struct {
+	Overdue WeekBucket   `json:"overdue"`
+	Weeks   []WeekBucket `json:"weeks"`
+}
+
+// BuildWeeklyBreakdown 按截止时间把未完成的待办事项归入从now所在周开始的weeks个ISO周，
+// 周的边界按now所在时区的周一零点计算；已过期的任务计入Overdue，超出范围或没有截止日期的不计入
+func BuildWeeklyBreakdown(todos []Todo, now time.Time, weeks int, grace time.Duration) (*WeeklyBreakdown, error) {
+	if weeks < 1 || weeks > maxBreakdownWeeks {
+		return nil, fmt.Errorf("weeks must be between 1 and %d", maxBreakdownWeeks)
+	}
+
+	loc := now.Location()
+	start := time.Date(now.Year(), now.Month(), now.Day()-(int(now.Weekday())+6)%7, 0, 0, 0, 0, loc)
+	breakdown := &WeeklyBreakdown{Overdue: WeekBucket{TodoIDs: []int{}}, Weeks: make([]WeekBucket, weeks)}
+	for i := range breakdown.Weeks {
+		weekStart := start.AddDate(0, 0, 7*i)
+		year, week := weekStart.ISOWeek()
+		breakdown.Weeks[i] = WeekBucket{Year: year, Week: week, Start: weekStart, End: weekStart.AddDate(0, 0, 7), TodoIDs: []int{}}
+	}
+
+	for _, todo := range todos {
+		if todo.Status == "completed" || todo.DueDate == nil {
+			continue
+		}
+
+		var bucket *WeekBucket
+		if IsOverdue(todo, now, grace) {
+			bucket = &breakdown.Overdue
+		} else {
+			deadline := todo.Deadline(loc)
+			for i := range breakdown.Weeks {
+				if w := &breakdown.Weeks[i]; !deadline.Before(w.Start) && deadline.Before(w.End) {
+					bucket = w
+					break
+				}
+			}
+		}
+		if bucket == nil {
+			continue
+		}
+		bucket.Count++
+		bucket.EstimatedHours += ParseEstimatedDuration(todo.EstimatedDuration).Hours()
+		bucket.TodoIDs = append(bucket.TodoIDs, todo.ID)
+	}
+	return breakdown, nil
+}
@@ -0,0 +1,117 @@
+package db
+
+import (
+	"fmt"
+	"time"
+)
+
+// 每日工作量最多统计的天数
+const maxWorkloadDays = 366
+
+// DayWorkload 某天到期的未完成任务的预计耗时与当天可用工作时间
+type DayWorkload struct {
+	Date             string `json:"date"`
+	TodoCount        int    `json:"todo_count"`
+	Unestimated      int    `json:"unestimated"` // 没有可识别预计耗时的任务数，不计入EstimatedMinutes
+	EstimatedMinutes int    `json:"estimated_minutes"`
+	EffortPoints     int    `json:"effort_points"` // 当天到期任务的工作量点数合计，不参与超负荷判定
+	AvailableMinutes int    `json:"available_minutes"`
+	Overloaded       bool   `json:"overloaded"`
+}
+
+// DailyWorkload 按天汇总的工作量
+type DailyWorkload struct {
+	From           string        `json:"from"`
+	To             string        `json:"to"`
+	Days           []DayWorkload `json:"days"`
+	OverloadedDays int           `json:"overloaded_days"`
+}
+
+// ParseDayRange 解析YYYY-MM-DD格式的日期范围（均包含当天），from为空时为now所在日期，
+// to为空时为from之后第defaultDays-1天
+func ParseDayRange(from, to string, now time.Time, defaultDays int) (time.Time, time.Time, error) {
+	loc := now.Location()
+	start := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, loc)
+	if from != "" {
+		t, err := time.ParseInLocation("2006-01-02", from, loc)
+		if err != nil {
+			return time.Time{}, time.Time{}, fmt.Errorf("invalid from %q, expected YYYY-MM-DD", from)
+		}
+		start = t
+	}
+	end := start.AddDate(0, 0, defaultDays-1)
+	if to != "" {
+		t, err := time.ParseInLocation("2006-01-02", to, loc)
+		if err != nil {
+			return time.Time{}, time.Time{}, fmt.Errorf("invalid to %q, expected YYYY-MM-DD", to)
+		}
+		end = t
+	}
+
+	if end.Before(start) {
+		return time.Time{}, time.Time{}, fmt.Errorf("from must not be after to")
+	}
+	if end.After(start.AddDate(0, 0, maxWorkloadDays-1)) {
+		return time.Time{}, time.Time{}, fmt.Errorf("range must not exceed %d days", maxWorkloadDays)
+	}
+	return start, end, nil
+}
+
+// ComputeDailyWorkload 统计[from, to]每天（按from的时区）到期的未完成任务预计耗时，与当天工作时间比较；
+// 非工作日和holidays中的日期可用时间为0，预计耗时超过可用时间的日期标记为overloaded
+func ComputeDailyWorkload(todos []Todo, schedule WorkSchedule, holidays map[string]bool, from, to time.Time) (*DailyWorkload, error) {
+	loc := from.Location()
+	result := &DailyWorkload{
+		From: from.Format("2006-01-02"),
+		To:   to.Format("2006-01-02"),
+		Days: []DayWorkload{},
+	}
+
+	index := map[string]int{}
+	for day := from; !day.After(to); day = day.AddDate(0, 0, 1) {
+		key := day.Format("2006-01-02")
+		entry := DayWorkload{Date: key}
+		if schedule.IsWorkDay(day) && !holidays[key] {
+			start, err := schedule.StartOn(day)
+			if err != nil {
+				return nil, err
+			}
+			end, err := schedule.EndOn(day)
+			if err != nil {
+				return nil, err
+			}
+			if end.After(start) {
+				entry.AvailableMinutes = int(end.Sub(start).Minutes())
+			}
+		}
+		index[key] = len(result.Days)
+		result.Days = append(result.Days, entry)
+	}
+
+	for _, todo := range todos {
+		if todo.Status == "completed" || todo.DueDate == nil {
+			continue
+		}
+		i, ok := index[todo.DueDate.In(loc).Format("2006-01-02")]
+		if !ok {
+			continue
+		}
+		entry := &result.Days[i]
+		entry.TodoCount++
+		entry.EffortPoints += todo.EffortPoints
+		if d := ParseEstimatedDuration(todo.EstimatedDuration); d > 0 {
+			entry.EstimatedMinutes += int(d.Minutes())
+		} else {
+			entry.Unestimated++
+		}
+	}
+
+	for i := range result.Days {
+		entry := &result.Days[i]
+		entry.Overloaded = entry.EstimatedMinutes > entry.AvailableMinutes
+		if entry.Overloaded {
+			result.OverloadedDays++
+		}
+	}
+	return result, nil
+}
@@ -0,0 +1,54 @@
+package db
+
+import (
+	"sort"
+	"time"
+)
+
+// CurrentWork 当天工作时段内排期的待办事项
+type CurrentWork struct {
+	InWorkHours bool      `json:"in_work_hours"`
+	WindowStart time.Time `json:"window_start"`
+	WindowEnd   time.Time `json:"window_end"`
+	Todos       []Todo    `json:"todos"`
+}
+
+// FindCurrentWork 返回排期时间与now所在日期工作时段（按now的时区）重叠的待办事项，按开始时间排序。
+// now不在工作时间内（非工作日或上班前、下班后）时返回空列表，anyTime为true时仍返回当天工作时段内的排期
+func FindCurrentWork(todos []Todo, schedule WorkSchedule, now time.Time, anyTime bool) (*CurrentWork, error) {
+	start, err := schedule.StartOn(now)
+	if err != nil {
+		return nil, err
+	}
+	end, err := schedule.EndOn(now)
+	if err != nil {
+		return nil, err
+	}
+
+	work := &CurrentWork{
+		InWorkHours: schedule.IsWorkDay(now) && !now.Before(start) && now.Before(end),
+		WindowStart: start,
+		WindowEnd:   end,
+		Todos:       []Todo{},
+	}
+	if !work.InWorkHours && !anyTime {
+		return work, nil
+	}
+
+	for _, todo := range todos {
+		s, e, ok := todo.Window()
+		if !ok {
+			continue
+		}
+		// 没有预计耗时的排期是一个时间点，落在时段内即可
+		if s.Before(end) && (e.After(start) || (e.Equal(s) && !s.Before(start))) {
+			work.Todos = append(work.Todos, todo)
+		}
+	}
+	sort.SliceStable(work.Todos, func(i, j int) bool {
+		si, _, _ := work.Todos[i].Window()
+		sj, _, _ := work.Todos[j].Window()
+		return si.Before(sj)
+	})
+	return work, nil
+}
@@ -0,0 +1,55 @@
+package jobs
+
+import (
+	"context"
+	"fydeos/config"
+	"fydeos/db"
+	"log"
+	"time"
+)
+
+// ProjectArchiver 定期归档所有待办事项均已完成且长期无更新的项目
+type ProjectArchiver struct {
+	store *db.SQLiteDatabase
+	cfg   config.ProjectArchiveConfig
+	// Now 返回当前时间，便于替换时钟
+	Now func() time.Time
+}
+
+func NewProjectArchiver(store *db.SQLiteDatabase, cfg config.ProjectArchiveConfig) *ProjectArchiver {
+	return &ProjectArchiver{
+		store: store,
+		cfg:   cfg,
+		Now:   time.Now,
+	}
+}
+
+// Start 按配置的间隔循环检查，直到ctx结束
+func (a *ProjectArchiver) Start(ctx context.Context) {
+	ticker := time.NewTicker(a.cfg.Interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+
+		if _, err := a.RunOnce(); err != nil {
+			log.Printf("Warning: project auto-archive failed: %v", err)
+		}
+	}
+}
+
+// RunOnce 执行一次自动归档，返回本次归档的项目
+func (a *ProjectArchiver) RunOnce() ([]db.Project, error) {
+	archived, err := a.store.AutoArchiveProjects(a.Now(), a.cfg.After)
+	if err != nil {
+		return nil, err
+	}
+	for _, p := range archived {
+		log.Printf("Archived inactive project %q (ID: %d)", p.Name, p.ID)
+	}
+	return archived, nil
+}
@@ -0,0 +1,134 @@
+package jobs
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"fydeos/config"
+	"fydeos/db"
+	"fydeos/notify"
+	"log"
+	"time"
+)
+
+// AutoCloseResult 一次自动关闭扫描的结果
+type AutoCloseResult struct {
+	Warned []db.Todo
+	Closed []db.Todo
+}
+
+// AutoCloser 定期关闭长期陈旧的低优先级任务。任务先收到一次警告，
+// 之后的扫描中仍未更新才按配置完成或删除
+type AutoCloser struct {
+	store    *db.SQLiteDatabase
+	cfg      config.AutoCloseConfig
+	notifier notify.Notifier
+	// Now 返回当前时间，便于替换时钟
+	Now func() time.Time
+}
+
+func NewAutoCloser(store *db.SQLiteDatabase, cfg config.AutoCloseConfig, notifier notify.Notifier) *AutoCloser {
+	return &AutoCloser{
+		store:    store,
+		cfg:      cfg,
+		notifier: notifier,
+		Now:      time.Now,
+	}
+}
+
+// Start 按配置的间隔循环执行，直到ctx结束
+func (a *AutoCloser) Start(ctx context.Context) {
+	ticker := time.NewTicker(a.cfg.Interval)
+	defer ticker.Stop()
+
+	for {
+		if _, err := a.RunOnce(ctx); err != nil {
+			log.Printf("Warning: stale todo auto-close failed: %v", err)
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// RunOnce 执行一次扫描：先关闭上次扫描已警告的任务，再警告新的陈旧任务
+func (a *AutoCloser) RunOnce(ctx context.Context) (*AutoCloseResult, error) {
+	now := a.Now()
+	staleBefore := now.Add(-a.cfg.After)
+	result := &AutoCloseResult{}
+
+	if err := a.store.ResetAutoCloseWarnings(); err != nil {
+		return result, err
+	}
+
+	warned, err := a.store.GetAutoCloseWarned(staleBefore, now, a.cfg.Priorities)
+	if err != nil {
+		return result, err
+	}
+	for _, todo := range warned {
+		if err := a.close(&todo); err != nil {
+			var blocked *db.ErrIncompleteSubtasks
+			if errors.As(err, &blocked) {
+				log.Printf("Warning: skipping auto-close of todo %d: %v", todo.ID, err)
+				continue
+			}
+			return result, err
+		}
+		a.notify(ctx, "auto_closed", autoCloseMessage(todo, a.cfg.Action), todo, now)
+		result.Closed = append(result.Closed, todo)
+	}
+
+	candidates, err := a.store.GetAutoCloseCandidates(staleBefore, a.cfg.Priorities)
+	if err != nil {
+		return result, err
+	}
+	for _, todo := range candidates {
+		if err := a.store.MarkAutoCloseWarned(todo.ID, now); err != nil {
+			return result, err
+		}
+		a.notify(ctx, "auto_close_warning", autoCloseWarningMessage(todo, a.cfg.Action, now), todo, now)
+		result.Warned = append(result.Warned, todo)
+	}
+
+	return result, nil
+}
+
+func (a *AutoCloser) close(todo *db.Todo) error {
+	if a.cfg.Action == "delete" {
+		return a.store.DeleteTodo(todo.ID, db.DeleteReparent)
+	}
+	todo.Status = "completed"
+	todo.Source = db.SourceAutoClose
+	return a.store.UpdateTodo(todo)
+}
+
+func (a *AutoCloser) notify(ctx context.Context, kind, msg string, todo db.Todo, now time.Time) {
+	err := a.notifier.Notify(ctx, notify.Notification{
+		Kind:    kind,
+		Message: msg,
+		Data:    todo,
+		Time:    now,
+	})
+	if err != nil {
+		log.Printf("Warning: failed to deliver %s for todo %d: %v", kind, todo.ID, err)
+	}
+}
+
+func autoCloseVerb(action string) string {
+	if action == "delete" {
+		return "删除"
+	}
+	return "标记为已完成"
+}
+
+func autoCloseWarningMessage(todo db.Todo, action string, now time.Time) string {
+	days := int(now.Sub(todo.LastUpdated).Hours() / 24)
+	return fmt.Sprintf("⚠️ 任务「%s」(ID: %d) 已有%d天未更新，若仍不处理将在下次检查时自动%s", todo.Title, todo.ID, days, autoCloseVerb(action))
+}
+
+func autoCloseMessage(todo db.Todo, action string) string {
+	return fmt.Sprintf("🗑️ 任务「%s」(ID: %d) 长期未更新，已自动%s", todo.Title, todo.ID, autoCloseVerb(action))
+}
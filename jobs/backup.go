@@ -0,0 +1,101 @@
+package jobs
+
+import (
+	"context"
+	"fmt"
+	"fydeos/config"
+	"fydeos/db"
+	"log"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+)
+
+const (
+	backupPrefix = "todos-"
+	backupSuffix = ".db"
+)
+
+// Backuper 定期备份SQLite数据库并按保留数量清理旧备份
+type Backuper struct {
+	store *db.SQLiteDatabase
+	cfg   config.BackupConfig
+	// Now 返回当前时间，便于替换时钟
+	Now func() time.Time
+}
+
+func NewBackuper(store *db.SQLiteDatabase, cfg config.BackupConfig) *Backuper {
+	return &Backuper{
+		store: store,
+		cfg:   cfg,
+		Now:   time.Now,
+	}
+}
+
+// Start 按配置的间隔循环备份，直到ctx结束
+func (b *Backuper) Start(ctx context.Context) {
+	ticker := time.NewTicker(b.cfg.Interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+
+		if path, err := b.RunOnce(); err != nil {
+			log.Printf("Warning: database backup failed: %v", err)
+		} else {
+			log.Printf("Database backed up to %s", path)
+		}
+	}
+}
+
+// RunOnce 写入一个带时间戳的备份文件并清理超出保留数量的旧备份，返回备份路径
+func (b *Backuper) RunOnce() (string, error) {
+	if err := os.MkdirAll(b.cfg.Dir, 0o755); err != nil {
+		return "", fmt.Errorf("failed to create backup directory: %v", err)
+	}
+
+	name := backupPrefix + b.Now().Format("20060102-150405") + backupSuffix
+	path := filepath.Join(b.cfg.Dir, name)
+	if err := b.store.BackupTo(path); err != nil {
+		return "", err
+	}
+
+	if err := pruneBackups(b.cfg.Dir, b.cfg.Retention); err != nil {
+		return path, err
+	}
+	return path, nil
+}
+
+// pruneBackups 只保留最新的retention个备份文件
+func pruneBackups(dir string, retention int) error {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return fmt.Errorf("failed to list backups: %v", err)
+	}
+
+	var backups []string
+	for _, entry := range entries {
+		name := entry.Name()
+		if !entry.IsDir() && strings.HasPrefix(name, backupPrefix) && strings.HasSuffix(name, backupSuffix) {
+			backups = append(backups, name)
+		}
+	}
+	if len(backups) <= retention {
+		return nil
+	}
+
+	// 文件名中的时间戳保证按名称排序即按时间排序
+	sort.Strings(backups)
+	for _, name := range backups[:len(backups)-retention] {
+		if err := os.Remove(filepath.Join(dir, name)); err != nil {
+			return fmt.Errorf("failed to remove old backup %s: %v", name, err)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,142 @@
+package jobs
+
+import (
+	"context"
+	"fmt"
+	"fydeos/config"
+	"fydeos/db"
+	"fydeos/notify"
+	"log"
+	"time"
+)
+
+// Agenda 每日议程
+type Agenda struct {
+	Date       string    `json:"date"`
+	Timezone   string    `json:"timezone"`
+	DueToday   []db.Todo `json:"due_today"`
+	Overdue    []db.Todo `json:"overdue"`
+	InProgress []db.Todo `json:"in_progress"`
+}
+
+// BuildAgenda 按now所在时区的日期整理当天议程，过期判定使用grace宽限期
+func BuildAgenda(todos []db.Todo, now time.Time, grace time.Duration) Agenda {
+	today := now.Format("2006-01-02")
+	agenda := Agenda{
+		Date:       today,
+		Timezone:   now.Location().String(),
+		DueToday:   []db.Todo{},
+		Overdue:    []db.Todo{},
+		InProgress: []db.Todo{},
+	}
+
+	for _, todo := range todos {
+		if todo.Status == "completed" {
+			continue
+		}
+
+		if todo.DueDate != nil {
+			if todo.DueDate.In(now.Location()).Format("2006-01-02") == today {
+				agenda.DueToday = append(agenda.DueToday, todo)
+				continue
+			}
+			if db.IsOverdue(todo, now, grace) {
+				agenda.Overdue = append(agenda.Overdue, todo)
+				continue
+			}
+		}
+
+		if todo.Status == "in_progress" {
+			agenda.InProgress = append(agenda.InProgress, todo)
+		}
+	}
+
+	return agenda
+}
+
+// DailyDigest 在每个工作日的指定本地时间推送当天议程
+type DailyDigest struct {
+	store    *db.SQLiteDatabase
+	cfg      config.DigestConfig
+	notifier notify.Notifier
+	// Now 返回当前时间，便于替换时钟
+	Now func() time.Time
+
+	lastSent string // 最近一次推送的本地日期
+}
+
+// NewDailyDigest 创建议程推送任务；配置了DigestConfig.WebhookURL时额外推送到该地址
+func NewDailyDigest(store *db.SQLiteDatabase, cfg config.DigestConfig, notifier notify.Notifier) *DailyDigest {
+	if cfg.WebhookURL != "" {
+		notifier = notify.Multi{notifier, notify.NewWebhookNotifier(cfg.WebhookURL)}
+	}
+	return &DailyDigest{
+		store:    store,
+		cfg:      cfg,
+		notifier: notifier,
+		Now:      time.Now,
+	}
+}
+
+// Start 按配置的间隔检查是否到达推送时间，直到ctx结束
+func (d *DailyDigest) Start(ctx context.Context) {
+	ticker := time.NewTicker(d.cfg.Interval)
+	defer ticker.Stop()
+
+	for {
+		if _, err := d.RunOnce(ctx); err != nil {
+			log.Printf("Warning: daily digest failed: %v", err)
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// RunOnce 若今天是工作日、已到推送时间且尚未推送，则推送议程并返回true
+func (d *DailyDigest) RunOnce(ctx context.Context) (bool, error) {
+	profile := d.store.LoadProfileOrDefault()
+
+	now := d.Now().In(profile.Location())
+	today := now.Format("2006-01-02")
+	if d.lastSent == today || !profile.WorkSchedule.IsWorkDay(now) {
+		return false, nil
+	}
+
+	clock := d.cfg.Time
+	if clock == "" {
+		clock = profile.WorkSchedule.StartTime
+	}
+	sendAt, err := db.ClockOn(now, clock)
+	if err != nil {
+		return false, err
+	}
+	if now.Before(sendAt) {
+		return false, nil
+	}
+
+	todos, err := d.store.GetAllTodos()
+	if err != nil {
+		return false, err
+	}
+
+	if err := d.deliver(ctx, BuildAgenda(todos, now, config.Cfg.Overdue.Grace), now); err != nil {
+		return false, err
+	}
+
+	d.lastSent = today
+	return true, nil
+}
+
+func (d *DailyDigest) deliver(ctx context.Context, agenda Agenda, now time.Time) error {
+	return d.notifier.Notify(ctx, notify.Notification{
+		Kind: "digest",
+		Message: fmt.Sprintf("📅 今日议程 %s: 今日到期%d项，已过期%d项，进行中%d项",
+			agenda.Date, len(agenda.DueToday), len(agenda.Overdue), len(agenda.InProgress)),
+		Data: agenda,
+		Time: now,
+	})
+}
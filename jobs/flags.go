@@ -0,0 +1,50 @@
+package jobs
+
+import (
+	"context"
+	"fydeos/config"
+	"fydeos/db"
+	"log"
+	"time"
+)
+
+// FlagRefresher 定期重新计算待办事项的is_overdue和is_stale冗余标记，使按标记筛选随时间推移仍然准确
+type FlagRefresher struct {
+	store *db.SQLiteDatabase
+	cfg   config.FlagsConfig
+	// Now 返回当前时间，便于替换时钟
+	Now func() time.Time
+}
+
+func NewFlagRefresher(store *db.SQLiteDatabase, cfg config.FlagsConfig) *FlagRefresher {
+	return &FlagRefresher{
+		store: store,
+		cfg:   cfg,
+		Now:   time.Now,
+	}
+}
+
+// Start 启动时立即刷新一次，之后按配置的间隔循环执行，直到ctx结束
+func (f *FlagRefresher) Start(ctx context.Context) {
+	ticker := time.NewTicker(f.cfg.Interval)
+	defer ticker.Stop()
+
+	for {
+		if _, err := f.RunOnce(); err != nil {
+			log.Printf("Warning: flag refresh failed: %v", err)
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// RunOnce 执行一次刷新，全天任务按用户时区判定过期，返回标记发生变化的任务数
+func (f *FlagRefresher) RunOnce() (int, error) {
+	profile := f.store.LoadProfileOrDefault()
+	now := f.Now().In(profile.Location())
+	return f.store.RecomputeFlags(now, config.Cfg.Overdue.Grace, config.Cfg.StaleNudge.Threshold)
+}
@@ -0,0 +1,103 @@
+package jobs
+
+import (
+	"context"
+	"fmt"
+	"fydeos/config"
+	"fydeos/db"
+	"fydeos/notify"
+	"log"
+	"time"
+)
+
+// 优先级降级顺序，low已是最低级
+var lowerPriority = map[string]string{
+	"urgent": "high",
+	"high":   "medium",
+	"medium": "low",
+	"low":    "low",
+}
+
+// StaleNudger 定期扫描陈旧任务并提醒，可选降低其优先级
+type StaleNudger struct {
+	store    *db.SQLiteDatabase
+	cfg      config.StaleNudgeConfig
+	notifier notify.Notifier
+	// Now 返回当前时间，便于替换时钟
+	Now func() time.Time
+}
+
+func NewStaleNudger(store *db.SQLiteDatabase, cfg config.StaleNudgeConfig, notifier notify.Notifier) *StaleNudger {
+	return &StaleNudger{
+		store:    store,
+		cfg:      cfg,
+		notifier: notifier,
+		Now:      time.Now,
+	}
+}
+
+// Start 按配置的间隔循环执行，直到ctx结束
+func (n *StaleNudger) Start(ctx context.Context) {
+	ticker := time.NewTicker(n.cfg.Interval)
+	defer ticker.Stop()
+
+	for {
+		if _, err := n.RunOnce(ctx); err != nil {
+			log.Printf("Warning: stale nudge failed: %v", err)
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// RunOnce 执行一次扫描，返回本次提醒的任务
+func (n *StaleNudger) RunOnce(ctx context.Context) ([]db.Todo, error) {
+	now := n.Now()
+
+	todos, err := n.store.GetNudgeCandidates(now.Add(-n.cfg.Threshold), now.Add(-n.cfg.Cooldown))
+	if err != nil {
+		return nil, err
+	}
+
+	var nudged []db.Todo
+	for _, todo := range todos {
+		priority := todo.Priority
+		if n.cfg.Action == "lower_priority" {
+			if lower, ok := lowerPriority[priority]; ok {
+				priority = lower
+			}
+		}
+
+		if err := n.store.MarkNudged(todo.ID, priority, now); err != nil {
+			return nudged, err
+		}
+
+		msg := nudgeMessage(todo, priority, now)
+		todo.Priority = priority
+		err := n.notifier.Notify(ctx, notify.Notification{
+			Kind:    "nudge",
+			Message: msg,
+			Data:    todo,
+			Time:    now,
+		})
+		if err != nil {
+			log.Printf("Warning: failed to deliver nudge for todo %d: %v", todo.ID, err)
+		}
+		nudged = append(nudged, todo)
+	}
+
+	return nudged, nil
+}
+
+func nudgeMessage(todo db.Todo, priority string, now time.Time) string {
+	days := int(now.Sub(todo.LastUpdated).Hours() / 24)
+	msg := fmt.Sprintf("⏰ 任务「%s」(ID: %d) 已有%d天未更新，请确认是否仍需处理", todo.Title, todo.ID, days)
+	if priority != todo.Priority {
+		msg += fmt.Sprintf("，优先级已由%s调整为%s", todo.Priority, priority)
+	}
+	return msg
+}
@@ -0,0 +1,71 @@
+package jobs
+
+import (
+	"context"
+	"errors"
+	"fydeos/config"
+	"fydeos/db"
+	"log"
+	"time"
+)
+
+// RecurrenceRegenerator 定期为已完成的重复待办事项生成下一次实例，每个实例只生成一次
+type RecurrenceRegenerator struct {
+	store *db.SQLiteDatabase
+	cfg   config.RecurrenceConfig
+	// Now 返回当前时间，便于替换时钟
+	Now func() time.Time
+}
+
+func NewRecurrenceRegenerator(store *db.SQLiteDatabase, cfg config.RecurrenceConfig) *RecurrenceRegenerator {
+	return &RecurrenceRegenerator{
+		store: store,
+		cfg:   cfg,
+		Now:   time.Now,
+	}
+}
+
+// Start 按配置的间隔循环执行，直到ctx结束
+func (r *RecurrenceRegenerator) Start(ctx context.Context) {
+	ticker := time.NewTicker(r.cfg.Interval)
+	defer ticker.Stop()
+
+	for {
+		if _, err := r.RunOnce(); err != nil {
+			log.Printf("Warning: recurring todo regeneration failed: %v", err)
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// RunOnce 执行一次扫描，按用户时区计算下一次截止日期，返回新建的实例
+func (r *RecurrenceRegenerator) RunOnce() ([]db.Todo, error) {
+	profile := r.store.LoadProfileOrDefault()
+
+	todos, err := r.store.GetRecurringToRegenerate(r.Now().Add(-r.cfg.Lookback))
+	if err != nil {
+		return nil, err
+	}
+
+	created := []db.Todo{}
+	for _, todo := range todos {
+		next, err := db.NextInstance(todo, profile.Location())
+		if err != nil {
+			log.Printf("Warning: skipping recurring todo %d: %v", todo.ID, err)
+			continue
+		}
+		if err := r.store.CreateNextInstance(todo, &next); err != nil {
+			if errors.Is(err, db.ErrAlreadyRegenerated) {
+				continue
+			}
+			return created, err
+		}
+		created = append(created, next)
+	}
+	return created, nil
+}
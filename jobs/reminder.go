@@ -0,0 +1,91 @@
+package jobs
+
+import (
+	"context"
+	"fydeos/config"
+	"fydeos/db"
+	"fydeos/notify"
+	"log"
+	"time"
+)
+
+// Reminder 定期扫描设置了提醒的待办事项，在每次（重复任务的每次发生）到期前按设定时间提醒；
+// 未设置提醒的按优先级使用默认提前量
+type Reminder struct {
+	store    *db.SQLiteDatabase
+	cfg      config.ReminderConfig
+	notifier notify.Notifier
+	// Now 返回当前时间，便于替换时钟
+	Now func() time.Time
+}
+
+func NewReminder(store *db.SQLiteDatabase, cfg config.ReminderConfig, notifier notify.Notifier) *Reminder {
+	return &Reminder{
+		store:    store,
+		cfg:      cfg,
+		notifier: notifier,
+		Now:      time.Now,
+	}
+}
+
+// Start 按配置的间隔循环执行，直到ctx结束
+func (r *Reminder) Start(ctx context.Context) {
+	ticker := time.NewTicker(r.cfg.Interval)
+	defer ticker.Stop()
+
+	for {
+		if _, err := r.RunOnce(ctx); err != nil {
+			log.Printf("Warning: reminder scan failed: %v", err)
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// RunOnce 执行一次扫描，发送到达提醒时刻的提醒，返回本次提醒的任务
+func (r *Reminder) RunOnce(ctx context.Context) ([]db.Todo, error) {
+	profile := r.store.LoadProfileOrDefault()
+	loc := profile.Location()
+	now := r.Now().In(loc)
+
+	var priorities []string
+	for priority := range r.cfg.DefaultOffsets {
+		priorities = append(priorities, priority)
+	}
+	todos, reminded, err := r.store.GetReminderCandidates(priorities)
+	if err != nil {
+		return nil, err
+	}
+
+	var sent []db.Todo
+	for _, todo := range todos {
+		db.ApplyDefaultReminder(&todo, r.cfg.DefaultOffsets)
+		var last *time.Time
+		if t, ok := reminded[todo.ID]; ok {
+			last = &t
+		}
+		occurrence, at, ok := db.NextReminder(todo, last, now, loc)
+		if !ok || at.After(now) {
+			continue
+		}
+
+		if err := r.store.MarkReminded(todo.ID, occurrence); err != nil {
+			return sent, err
+		}
+		err := r.notifier.Notify(ctx, notify.Notification{
+			Kind:    "reminder",
+			Message: db.RenderReminder(todo, occurrence, now),
+			Data:    todo,
+			Time:    now,
+		})
+		if err != nil {
+			log.Printf("Warning: failed to deliver reminder for todo %d: %v", todo.ID, err)
+		}
+		sent = append(sent, todo)
+	}
+	return sent, nil
+}
@@ -1,10 +1,15 @@
 package main
 
 import (
+	"context"
 	"fmt"
 	"fydeos/api"
+	"fydeos/config"
 	"fydeos/db"
+	"fydeos/jobs"
 	"fydeos/mcp"
+	"fydeos/notify"
+	"fydeos/tracing"
 	"github.com/gorilla/mux"
 	"github.com/rs/cors"
 	"log"
@@ -12,26 +17,124 @@ import (
 )
 
 func main() {
+	// 加载配置
+	cfg := config.Load()
+
 	// 初始化数据库
 	if _, err := db.NewSQLiteDatabase(); err != nil {
 		log.Fatalf("Failed to initialize SQLite database: %v", err)
 	}
 	defer db.DB.Close()
 
+	// 按配置导入初始数据，失败时继续启动
+	if cfg.Import.OnStart != "" {
+		result, err := db.DB.ImportFromSource(cfg.Import.OnStart)
+		if err != nil {
+			log.Printf("Warning: Failed to import data from %s: %v", cfg.Import.OnStart, err)
+		} else {
+			log.Printf("Imported data from %s (%d imported, %d skipped)", cfg.Import.OnStart, result.Imported, result.Skipped)
+		}
+	}
+
+	// 初始化链路追踪，未配置OTLP导出器时为no-op
+	shutdownTracing, err := tracing.Init(context.Background())
+	if err != nil {
+		log.Printf("Warning: Failed to initialize tracing: %v", err)
+	}
+	defer shutdownTracing(context.Background())
+
+	// 通知渠道，提醒和议程共用
+	notifier, sse := notify.New(cfg.Notify)
+
+	// 后台任务
+	if cfg.StaleNudge.Enabled {
+		go jobs.NewStaleNudger(db.DB, cfg.StaleNudge, notifier).Start(context.Background())
+	}
+	if cfg.Digest.Enabled {
+		go jobs.NewDailyDigest(db.DB, cfg.Digest, notifier).Start(context.Background())
+	}
+	if cfg.Backup.Enabled {
+		go jobs.NewBackuper(db.DB, cfg.Backup).Start(context.Background())
+	}
+	if cfg.Reminder.Enabled {
+		go jobs.NewReminder(db.DB, cfg.Reminder, notifier).Start(context.Background())
+	}
+	go jobs.NewFlagRefresher(db.DB, cfg.Flags).Start(context.Background())
+	if cfg.ProjectArchive.Enabled {
+		go jobs.NewProjectArchiver(db.DB, cfg.ProjectArchive).Start(context.Background())
+	}
+	if cfg.Recurrence.Enabled {
+		go jobs.NewRecurrenceRegenerator(db.DB, cfg.Recurrence).Start(context.Background())
+	}
+	if cfg.AutoClose.Enabled {
+		go jobs.NewAutoCloser(db.DB, cfg.AutoClose, notifier).Start(context.Background())
+	}
+
 	// init MCP Server
-	mcp.InitMCP()
+	mcp.InitMCP(cfg.MCP)
 
 	r := mux.NewRouter()
+	r.Use(tracing.Middleware)
 	// API routes
 	r.HandleFunc("/api/todos", api.GetTodos).Methods("GET")
 	r.HandleFunc("/api/todos", api.CreateTodo).Methods("POST")
+	r.HandleFunc("/api/todos/validate", api.ValidateTodo).Methods("POST")
+	r.HandleFunc("/api/todos/diff", api.DiffTodos).Methods("POST")
+	r.HandleFunc("/api/todos/merge", api.MergeTodos).Methods("POST")
+	r.HandleFunc("/api/todos/recategorize", api.RecategorizeTodos).Methods("POST")
+	r.HandleFunc("/api/todos/completed", api.GetCompletedTodos).Methods("GET")
+	r.HandleFunc("/api/todos/search", api.SearchTodos).Methods("GET")
+	r.HandleFunc("/api/todos/delegated", api.GetDelegatedTodos).Methods("GET")
+	r.HandleFunc("/api/todos/incomplete-metadata", api.GetIncompleteMetadata).Methods("GET")
+	r.HandleFunc("/api/todos/date-issues", api.GetDateIssues).Methods("GET")
+	r.HandleFunc("/api/todos/inbox", api.GetInbox).Methods("GET")
+	r.HandleFunc("/api/todos/graph", api.GetTodoGraph).Methods("GET")
+	r.HandleFunc("/api/todos/now", api.GetCurrentWork).Methods("GET")
+	r.HandleFunc("/api/todos/transition", api.BulkTransition).Methods("POST")
+	r.HandleFunc("/api/todos/complete", api.CompleteByFilter).Methods("POST")
+	r.HandleFunc("/api/todos/{id:[0-9]+}.md", api.GetTodoMarkdown).Methods("GET")
+	r.HandleFunc("/api/todos/{id}", api.GetTodo).Methods("GET")
 	r.HandleFunc("/api/todos/{id}", api.UpdateTodo).Methods("PUT")
+	r.HandleFunc("/api/todos/{id}", api.PatchTodo).Methods("PATCH")
 	r.HandleFunc("/api/todos/{id}", api.DeleteTodo).Methods("DELETE")
+	r.HandleFunc("/api/todos/{id}/current", api.GetCurrentTodo).Methods("GET")
+	r.HandleFunc("/api/todos/{id}/checklist", api.AddChecklistItem).Methods("POST")
+	r.HandleFunc("/api/todos/{id}/checklist", api.ToggleChecklistItem).Methods("PATCH")
+	r.HandleFunc("/api/todos/{id}/checklist", api.RemoveChecklistItem).Methods("DELETE")
+	r.HandleFunc("/api/todos/{id}/schedule", api.ScheduleTodo).Methods("POST")
+	r.HandleFunc("/api/todos/{id}/history/{field}", api.GetFieldHistory).Methods("GET")
+	r.HandleFunc("/api/todos/{id}/occurrences", api.GetOccurrences).Methods("GET")
+	r.HandleFunc("/api/todos/{id}/dependencies", api.GetDependencies).Methods("GET")
+	r.HandleFunc("/api/todos/{id}/dependencies", api.SetDependencies).Methods("PUT")
+	r.HandleFunc("/api/recurrence/preview", api.PreviewRecurrence).Methods("POST")
+	r.HandleFunc("/api/export", api.ExportTodos).Methods("GET")
+	r.HandleFunc("/api/categories/rename", api.RenameCategory).Methods("POST")
+	r.HandleFunc("/api/projects", api.GetProjects).Methods("GET")
+	r.HandleFunc("/api/projects/{id}/archive", api.ArchiveProject).Methods("POST")
+	r.HandleFunc("/api/projects/{id}/restore", api.RestoreProject).Methods("POST")
 	r.HandleFunc("/api/ai/analyze", api.AiAnalyzeTasks).Methods("GET")
 	r.HandleFunc("/api/ai/optimize", api.AiOptimizeSchedule).Methods("GET")
+	r.HandleFunc("/api/analytics/report", api.GetAnalyticsReport).Methods("GET")
+	r.HandleFunc("/api/analytics/age-distribution", api.GetAgeDistribution).Methods("GET")
+	r.HandleFunc("/api/analytics/heatmap", api.GetCompletionHeatmap).Methods("GET")
+	r.HandleFunc("/api/analytics/streak", api.GetCompletionStreak).Methods("GET")
+	r.HandleFunc("/api/analytics/procrastinated", api.GetProcrastinated).Methods("GET")
 
 	// User profile route
 	r.HandleFunc("/api/profile", api.GetUserProfile).Methods("GET")
+	r.HandleFunc("/api/stats", api.GetStats).Methods("GET")
+	r.HandleFunc("/api/workload", api.GetWorkload).Methods("GET")
+	r.HandleFunc("/api/agenda/day", api.GetDayAgenda).Methods("GET")
+	r.HandleFunc("/api/focus", api.GetFocus).Methods("GET")
+	r.HandleFunc("/api/focus", api.SetFocus).Methods("PUT")
+	r.HandleFunc("/api/focus", api.ClearFocus).Methods("DELETE")
+	r.HandleFunc("/healthz", api.Healthz).Methods("GET")
+	r.HandleFunc("/api/mcp/usage", api.GetMCPUsage).Methods("GET")
+	r.HandleFunc("/api/admin/backup", api.BackupDatabase).Methods("POST")
+	r.HandleFunc("/api/admin/import", api.ImportData).Methods("POST")
+	if sse != nil {
+		r.Handle("/api/notifications/stream", sse).Methods("GET")
+	}
 
 	// Serve static files
 	r.PathPrefix("/").Handler(http.FileServer(http.Dir("./static/")))
@@ -39,8 +142,9 @@ func main() {
 	// Enable CORS
 	c := cors.New(cors.Options{
 		AllowedOrigins: []string{"*"},
-		AllowedMethods: []string{"GET", "POST", "PUT", "DELETE", "OPTIONS"},
+		AllowedMethods: []string{"GET", "POST", "PUT", "PATCH", "DELETE", "OPTIONS"},
 		AllowedHeaders: []string{"*"},
+		ExposedHeaders: []string{api.ResultWarningHeader, api.TotalCountHeader, "ETag"},
 	})
 
 	handler := c.Handler(r)
@@ -0,0 +1,39 @@
+package mcp
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/mark3labs/mcp-go/server"
+)
+
+// disabledTools 按配置禁用的工具：从tools/list中隐藏，直接调用时返回错误
+type disabledTools map[string]bool
+
+func newDisabledTools(names []string) disabledTools {
+	d := disabledTools{}
+	for _, name := range names {
+		d[name] = true
+	}
+	return d
+}
+
+func (d disabledTools) filter(ctx context.Context, tools []mcp.Tool) []mcp.Tool {
+	enabled := make([]mcp.Tool, 0, len(tools))
+	for _, tool := range tools {
+		if !d[tool.Name] {
+			enabled = append(enabled, tool)
+		}
+	}
+	return enabled
+}
+
+func (d disabledTools) middleware(next server.ToolHandlerFunc) server.ToolHandlerFunc {
+	return func(ctx context.Context, req mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		if d[req.Params.Name] {
+			return nil, fmt.Errorf("tool %q is disabled on this server", req.Params.Name)
+		}
+		return next(ctx, req)
+	}
+}
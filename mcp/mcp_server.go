@@ -2,26 +2,34 @@ package mcp
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"fydeos/config"
 	"fydeos/db"
+	"fydeos/tracing"
+	"strings"
 	"time"
 
 	"github.com/mark3labs/mcp-go/mcp"
 	"github.com/mark3labs/mcp-go/server"
 )
 
-func InitMCP() {
+func InitMCP(cfg config.MCPConfig) {
+	disabled := newDisabledTools(cfg.DisabledTools)
 	s := server.NewMCPServer(
 		"go-mcp-todo-list",
 		"1.0.0",
 		server.WithLogging(),
 		server.WithRecovery(),
+		server.WithToolHandlerMiddleware(tracing.ToolMiddleware),
+		server.WithToolHandlerMiddleware(usageMiddleware),
+		server.WithToolHandlerMiddleware(disabled.middleware),
+		server.WithToolFilter(disabled.filter),
 	)
 
 	RegisterTodoTools(s, db.DB)
 
-	srv := server.NewSSEServer(s)
-	go srv.Start("localhost:8082")
+	serveSSE(server.NewSSEServer(s), cfg)
 }
 
 // 注册所有相关工具
@@ -30,10 +38,59 @@ func RegisterTodoTools(s *server.MCPServer, sqlite *db.SQLiteDatabase) {
 	s.AddTool(mcp.NewTool(
 		"list_todos",
 		mcp.WithDescription("列出所有待办事项，支持过滤"),
-		// 无需参数定义
+		mcp.WithString("source",
+			mcp.Description("只列出最近一次由该来源写入的待办事项"),
+			mcp.Enum(db.SourceAPI, db.SourceMCP, db.SourceImport),
+		),
+		mcp.WithString("preset",
+			mcp.Description("快捷筛选：active为pending和in_progress；attention为未完成且已过期、urgent或今天到期；done_recently为最近7天内完成"),
+			mcp.Enum(string(db.PresetActive), string(db.PresetAttention), string(db.PresetDoneRecently)),
+		),
+		mcp.WithString("tag",
+			mcp.Description("只列出带有该标签（含按类别、项目自动添加的标签）的待办事项"),
+		),
+		mcp.WithNumber("min_minutes",
+			mcp.Description("预计耗时下限（分钟，含），用于按可用时间挑选任务"),
+		),
+		mcp.WithNumber("max_minutes",
+			mcp.Description("预计耗时上限（分钟，含）"),
+		),
+		mcp.WithBoolean("include_unestimated",
+			mcp.Description("按预计耗时筛选时保留没有预计耗时的任务"),
+		),
+		mcp.WithString("fields",
+			mcp.Description("逗号分隔的返回字段（JSON字段名，如id,title,status），默认返回全部字段"),
+		),
 	), func(ctx context.Context, req mcp.CallToolRequest) (*mcp.CallToolResult, error) {
-		todo, _ := sqlite.GetAllTodos()
-		return mcp.NewToolResultStructuredOnly(todo), nil
+		sqlite := sqlite.WithContext(ctx)
+		preset, err := db.ParsePreset(req.GetString("preset", ""))
+		if err != nil {
+			return nil, err
+		}
+		fields, err := db.ParseFields(req.GetString("fields", ""))
+		if err != nil {
+			return nil, err
+		}
+		durationRange, err := db.NewDurationRange(int(req.GetFloat("min_minutes", 0)), int(req.GetFloat("max_minutes", 0)), req.GetBool("include_unestimated", false))
+		if err != nil {
+			return nil, err
+		}
+		todo, err := sqlite.GetAllTodos()
+		if err != nil {
+			return mcp.NewToolResultError(err.Error()), nil
+		}
+		todo = db.FilterBySource(todo, req.GetString("source", ""))
+		todo = db.FilterByTag(todo, req.GetString("tag", ""))
+		todo = db.FilterByDuration(todo, durationRange)
+		if preset != db.PresetNone {
+			profile := sqlite.LoadProfileOrDefault()
+			todo = db.FilterByPreset(todo, preset, time.Now().In(profile.Location()), config.Cfg.Overdue.Grace)
+		}
+		result, err := db.ProjectFields(todo, fields)
+		if err != nil {
+			return nil, err
+		}
+		return mcp.NewToolResultStructuredOnly(result), nil
 	})
 
 	// create_todo
@@ -57,7 +114,50 @@ func RegisterTodoTools(s *server.MCPServer, sqlite *db.SQLiteDatabase) {
 		mcp.WithString("estimated_duration",
 			mcp.Description("预计耗时"),
 		),
+		mcp.WithString("due_date",
+			mcp.Description("截止日期（YYYY-MM-DD或RFC3339），设置recurrence时必填"),
+		),
+		mcp.WithNumber("parent_id",
+			mcp.Description("父任务ID，用于创建子任务"),
+		),
+		mcp.WithString("waiting_on",
+			mcp.Description("已委派时等待的人"),
+		),
+		mcp.WithString("recurrence",
+			mcp.Description("重复规则"),
+			mcp.Enum("daily", "weekdays", "weekly", "monthly", "yearly"),
+		),
+		mcp.WithString("raw_input",
+			mcp.Description("用户的原始自然语言输入，保存后可用reparse_todo重新解析"),
+		),
+		mcp.WithNumber("remind_before_minutes",
+			mcp.Description("截止前多少分钟提醒；重复任务在每次发生前提醒"),
+		),
+		mcp.WithString("reminder_message",
+			mcp.Description("自定义提醒消息模板，可用占位符{title}、{id}、{description}、{priority}、{category}、{due}、{time}"),
+		),
+		mcp.WithArray("tags",
+			mcp.Description("标签"),
+			mcp.WithStringItems(),
+		),
+		mcp.WithString("energy_level",
+			mcp.Description("所需精力，plan_day将高精力任务排在上午、低精力任务排在下午"),
+			mcp.Enum("high", "medium", "low"),
+		),
+		mcp.WithNumber("effort_points",
+			mcp.Description("工作量点数（故事点），与预计耗时相互独立"),
+		),
+		mcp.WithString("external_system",
+			mcp.Description("关联的外部问题跟踪系统，如github、jira"),
+		),
+		mcp.WithString("external_id",
+			mcp.Description("外部系统中的编号，如owner/repo#123、PROJ-42"),
+		),
+		mcp.WithString("external_url",
+			mcp.Description("外部条目的链接"),
+		),
 	), func(ctx context.Context, req mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		sqlite := sqlite.WithContext(ctx)
 		todo := &db.Todo{
 			Title:             req.GetString("title", ""),
 			Description:       req.GetString("description", ""),
@@ -67,6 +167,36 @@ func RegisterTodoTools(s *server.MCPServer, sqlite *db.SQLiteDatabase) {
 			CreatedDate:       time.Now(),
 			LastUpdated:       time.Now(),
 			EstimatedDuration: req.GetString("estimated_duration", ""),
+			WaitingOn:         req.GetString("waiting_on", ""),
+			Source:            db.SourceMCP,
+			RawInput:          req.GetString("raw_input", ""),
+			ReminderMessage:   req.GetString("reminder_message", ""),
+			ExternalRef:       externalRefArg(req),
+			Tags:              req.GetStringSlice("tags", nil),
+			EnergyLevel:       req.GetString("energy_level", ""),
+			EffortPoints:      int(req.GetFloat("effort_points", 0)),
+		}
+		recurrence, err := db.ParseRecurrence(req.GetString("recurrence", ""))
+		if err != nil {
+			return nil, err
+		}
+		todo.Recurrence = recurrence
+		if todo.DueDate, err = dueDateArg(sqlite, req); err != nil {
+			return nil, err
+		}
+		if v, ok := req.GetArguments()["remind_before_minutes"].(float64); ok && v >= 0 {
+			minutes := int(v)
+			todo.RemindBefore = &minutes
+		}
+		if parentID := int(req.GetFloat("parent_id", 0)); parentID > 0 {
+			if _, err := sqlite.GetTodoByID(parentID); err != nil {
+				return nil, err
+			}
+			todo.ParentID = &parentID
+		}
+		db.NormalizeTodo(todo)
+		if db.IsQuickCapture(*todo) {
+			todo.Category = db.InboxCategory()
 		}
 		if todo.Priority == "" {
 			todo.Priority = "medium"
@@ -74,6 +204,9 @@ func RegisterTodoTools(s *server.MCPServer, sqlite *db.SQLiteDatabase) {
 		if todo.Category == "" {
 			todo.Category = "personal"
 		}
+		if err := db.ValidateTodo(*todo, time.Now()).Err(); err != nil {
+			return nil, err
+		}
 
 		if err := sqlite.CreateTodo(todo); err != nil {
 			return nil, err
@@ -81,10 +214,162 @@ func RegisterTodoTools(s *server.MCPServer, sqlite *db.SQLiteDatabase) {
 		return mcp.NewToolResultText(fmt.Sprintf("Created todo: %s (ID: %d)", todo.Title, todo.ID)), nil
 	})
 
+	// set_recurrence
+	s.AddTool(mcp.NewTool(
+		"set_recurrence",
+		mcp.WithDescription("设置或取消待办事项的重复规则。重复任务必须有截止日期，完成后后台会按规则生成下一次实例"),
+		mcp.WithNumber("id",
+			mcp.Required(),
+			mcp.Description("待办事项ID"),
+		),
+		mcp.WithString("recurrence",
+			mcp.Required(),
+			mcp.Description("重复规则，传空字符串表示取消重复"),
+			mcp.Enum("", "daily", "weekdays", "weekly", "monthly", "yearly"),
+		),
+		mcp.WithString("due_date",
+			mcp.Description("同时设置截止日期（YYYY-MM-DD或RFC3339），待办事项没有截止日期时必填"),
+		),
+	), func(ctx context.Context, req mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		sqlite := sqlite.WithContext(ctx)
+		id := int(req.GetFloat("id", 0))
+		todo, err := sqlite.GetTodoByID(id)
+		if err != nil {
+			return nil, err
+		}
+		recurrence, err := db.ParseRecurrence(req.GetString("recurrence", ""))
+		if err != nil {
+			return nil, err
+		}
+		todo.Recurrence = recurrence
+		due, err := dueDateArg(sqlite, req)
+		if err != nil {
+			return nil, err
+		}
+		if due != nil {
+			todo.DueDate = due
+		}
+		if err := db.ValidateTodo(*todo, time.Now()).Err(); err != nil {
+			return nil, err
+		}
+
+		todo.LastUpdated = time.Now()
+		todo.Source = db.SourceMCP
+		if err := sqlite.UpdateTodo(todo); err != nil {
+			return nil, err
+		}
+		return mcp.NewToolResultStructuredOnly(todo), nil
+	})
+
+	// create_subtask
+	s.AddTool(mcp.NewTool(
+		"create_subtask",
+		mcp.WithDescription("在父任务下创建子任务，未指定的优先级和类别继承父任务"),
+		mcp.WithNumber("parent_id",
+			mcp.Required(),
+			mcp.Description("父任务ID"),
+		),
+		mcp.WithString("title",
+			mcp.Required(),
+			mcp.Description("标题"),
+		),
+		mcp.WithString("description",
+			mcp.Description("描述"),
+		),
+		mcp.WithString("priority",
+			mcp.Description("优先级（urgent/high/medium/low），默认与父任务相同"),
+			mcp.Enum("urgent", "high", "medium", "low"),
+		),
+		mcp.WithString("category",
+			mcp.Description("类别，默认与父任务相同"),
+		),
+		mcp.WithString("estimated_duration",
+			mcp.Description("预计耗时"),
+		),
+	), func(ctx context.Context, req mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		sqlite := sqlite.WithContext(ctx)
+		parent, err := sqlite.GetTodoByID(int(req.GetFloat("parent_id", 0)))
+		if err != nil {
+			return nil, err
+		}
+		todo := &db.Todo{
+			Title:             req.GetString("title", ""),
+			Description:       req.GetString("description", ""),
+			Priority:          req.GetString("priority", parent.Priority),
+			Category:          req.GetString("category", parent.Category),
+			Status:            "pending",
+			EstimatedDuration: req.GetString("estimated_duration", ""),
+			ParentID:          &parent.ID,
+			Source:            db.SourceMCP,
+		}
+		db.NormalizeTodo(todo)
+		if err := db.ValidateTodo(*todo, time.Now()).Err(); err != nil {
+			return nil, err
+		}
+
+		if err := sqlite.CreateTodo(todo); err != nil {
+			return nil, err
+		}
+		return mcp.NewToolResultText(fmt.Sprintf("Created subtask: %s (ID: %d, parent: %d)", todo.Title, todo.ID, parent.ID)), nil
+	})
+
+	// break_down_task
+	s.AddTool(mcp.NewTool(
+		"break_down_task",
+		mcp.WithDescription("将待办事项按阶段拆分为子任务，count较小时为粗粒度拆分、较大时为细粒度拆分；父任务有预计耗时时按阶段比例分配，子任务耗时之和等于父任务的预计耗时。默认只返回建议，persist为true时保存为子任务"),
+		mcp.WithNumber("id",
+			mcp.Required(),
+			mcp.Description("要拆分的待办事项ID"),
+		),
+		mcp.WithNumber("count",
+			mcp.Description(fmt.Sprintf("子任务数量（%d-%d），默认%d", db.MinBreakdownSubtasks, db.MaxBreakdownSubtasks, db.DefaultBreakdownSubtasks)),
+		),
+		mcp.WithBoolean("persist",
+			mcp.Description("为true时在一个事务中保存为子任务并返回其ID，任一条失败时全部回滚"),
+		),
+	), func(ctx context.Context, req mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		sqlite := sqlite.WithContext(ctx)
+		id := int(req.GetFloat("id", 0))
+		count := int(req.GetFloat("count", db.DefaultBreakdownSubtasks))
+
+		parent, err := sqlite.GetTodoByID(id)
+		if err != nil {
+			return nil, err
+		}
+		subtasks, err := db.BreakDownTodo(*parent, count)
+		if err != nil {
+			return nil, err
+		}
+		if !req.GetBool("persist", false) {
+			return mcp.NewToolResultStructuredOnly(map[string]interface{}{
+				"parent_id": parent.ID,
+				"persisted": false,
+				"subtasks":  subtasks,
+			}), nil
+		}
+
+		for i := range subtasks {
+			subtasks[i].Source = db.SourceMCP
+		}
+		if err := sqlite.CreateTodos(subtasks); err != nil {
+			return nil, err
+		}
+		ids := make([]int, len(subtasks))
+		for i, subtask := range subtasks {
+			ids[i] = subtask.ID
+		}
+		return mcp.NewToolResultStructuredOnly(map[string]interface{}{
+			"parent_id":   parent.ID,
+			"persisted":   true,
+			"subtask_ids": ids,
+			"subtasks":    subtasks,
+		}), nil
+	})
+
 	// update_todo
 	s.AddTool(mcp.NewTool(
 		"update_todo",
-		mcp.WithDescription("更新现有待办事项"),
+		mcp.WithDescription("更新现有待办事项，只修改提供的字段"),
 		mcp.WithNumber("id",
 			mcp.Required(),
 			mcp.Description("待办事项ID"),
@@ -103,22 +388,130 @@ func RegisterTodoTools(s *server.MCPServer, sqlite *db.SQLiteDatabase) {
 			mcp.Description("状态"),
 			mcp.Enum("pending", "in_progress", "completed"),
 		),
+		mcp.WithString("waiting_on",
+			mcp.Description("已委派时等待的人，传空字符串表示收回委派"),
+		),
+		mcp.WithString("recurrence",
+			mcp.Description("重复规则，传空字符串表示取消重复"),
+			mcp.Enum("", "daily", "weekdays", "weekly", "monthly", "yearly"),
+		),
+		mcp.WithArray("tags",
+			mcp.Description("替换手动标签，传空数组表示清空"),
+			mcp.WithStringItems(),
+		),
+		mcp.WithString("energy_level",
+			mcp.Description("所需精力，传空字符串表示取消"),
+			mcp.Enum("", "high", "medium", "low"),
+		),
+		mcp.WithNumber("effort_points",
+			mcp.Description("工作量点数，传0表示取消"),
+		),
+		mcp.WithString("external_system",
+			mcp.Description("关联的外部问题跟踪系统，传空字符串表示取消关联"),
+		),
+		mcp.WithString("external_id",
+			mcp.Description("外部系统中的编号"),
+		),
+		mcp.WithString("external_url",
+			mcp.Description("外部条目的链接"),
+		),
 	), func(ctx context.Context, req mcp.CallToolRequest) (*mcp.CallToolResult, error) {
-		id := req.GetFloat("id", 0)
-		todo, err := sqlite.GetTodoByID(int(id))
+		sqlite := sqlite.WithContext(ctx)
+		id := int(req.GetFloat("id", 0))
+		todo, err := sqlite.GetTodoByID(id)
 		if err != nil {
 			return nil, fmt.Errorf("todo with ID %d not found", id)
 		}
-		todo.Title = req.GetString("title", "")
-		todo.Description = req.GetString("description", "")
-		todo.Priority = req.GetString("priority", "")
-		todo.Status = req.GetString("status", "")
+		// 只覆盖请求中实际提供的字段，省略的字段保持原值
+		if v, ok := req.GetArguments()["title"].(string); ok {
+			todo.Title = v
+		}
+		if v, ok := req.GetArguments()["description"].(string); ok {
+			todo.Description = v
+		}
+		if v, ok := req.GetArguments()["priority"].(string); ok {
+			todo.Priority = v
+		}
+		if v, ok := req.GetArguments()["status"].(string); ok {
+			todo.Status = v
+		}
+		if waitingOn, ok := req.GetArguments()["waiting_on"].(string); ok {
+			todo.WaitingOn = waitingOn
+		}
+		if v, ok := req.GetArguments()["recurrence"].(string); ok {
+			recurrence, err := db.ParseRecurrence(v)
+			if err != nil {
+				return nil, err
+			}
+			todo.Recurrence = recurrence
+		}
+		if _, ok := req.GetArguments()["external_system"].(string); ok {
+			todo.ExternalRef = externalRefArg(req)
+		}
+		if _, ok := req.GetArguments()["tags"]; ok {
+			todo.Tags = req.GetStringSlice("tags", nil)
+		}
+		if v, ok := req.GetArguments()["energy_level"].(string); ok {
+			todo.EnergyLevel = v
+		}
+		if v, ok := req.GetArguments()["effort_points"].(float64); ok {
+			todo.EffortPoints = int(v)
+		}
+
+		db.NormalizeTodo(todo)
+		if err := db.ValidateTodo(*todo, time.Now()).Err(); err != nil {
+			return nil, err
+		}
+
+		todo.LastUpdated = time.Now()
+		todo.Source = db.SourceMCP
+		if err := sqlite.UpdateTodo(todo); err != nil {
+			return nil, err
+		}
+		message := fmt.Sprintf("Updated todo: %s (ID: %d)", todo.Title, todo.ID)
+		if warning := sqlite.SubtaskWarning(todo); warning != "" {
+			message += "\nWarning: " + warning
+		}
+		return mcp.NewToolResultText(message), nil
+	})
+
+	// reparse_todo
+	s.AddTool(mcp.NewTool(
+		"reparse_todo",
+		mcp.WithDescription("重新解析待办事项保存的原始自然语言输入，刷新截止日期和优先级"),
+		mcp.WithNumber("id",
+			mcp.Required(),
+			mcp.Description("待办事项ID"),
+		),
+	), func(ctx context.Context, req mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		sqlite := sqlite.WithContext(ctx)
+		id := int(req.GetFloat("id", 0))
+		todo, err := sqlite.GetTodoByID(id)
+		if err != nil {
+			return nil, err
+		}
+		if strings.TrimSpace(todo.RawInput) == "" {
+			return nil, fmt.Errorf("todo with ID %d has no raw input to reparse", id)
+		}
+
+		profile := sqlite.LoadProfileOrDefault()
+		parsed := db.ParseRawInput(todo.RawInput, time.Now().In(profile.Location()), profile.WorkSchedule)
+		if parsed.DueDate != nil {
+			todo.DueDate = parsed.DueDate
+		}
+		if parsed.Priority != "" {
+			todo.Priority = parsed.Priority
+		}
 
 		todo.LastUpdated = time.Now()
+		todo.Source = db.SourceMCP
 		if err := sqlite.UpdateTodo(todo); err != nil {
 			return nil, err
 		}
-		return mcp.NewToolResultText(fmt.Sprintf("Updated todo: %s (ID: %d)", todo.Title, todo.ID)), nil
+		return mcp.NewToolResultStructuredOnly(map[string]interface{}{
+			"todo":   todo,
+			"parsed": parsed,
+		}), nil
 	})
 
 	// delete_todo
@@ -128,16 +521,897 @@ func RegisterTodoTools(s *server.MCPServer, sqlite *db.SQLiteDatabase) {
 		mcp.WithNumber("id",
 			mcp.Required(),
 			mcp.Description("待办事项ID"),
+		),
+		mcp.WithString("mode",
+			mcp.Description("存在子任务时的处理方式：block拒绝删除（默认），cascade删除子任务，reparent将子任务挂到上级任务"),
+			mcp.Enum("block", "cascade", "reparent"),
+		),
+		mcp.WithBoolean("dry_run",
+			mcp.Description("仅返回将受影响的待办事项，不做修改"),
 		)), func(ctx context.Context, req mcp.CallToolRequest) (*mcp.CallToolResult, error) {
 		idFloat := req.GetFloat("id", 0)
 		id := int(idFloat)
+		mode, err := db.ParseDeleteMode(req.GetString("mode", ""))
+		if err != nil {
+			return nil, err
+		}
+		if req.GetBool("dry_run", false) {
+			preview, err := sqlite.PreviewDeleteTodo(id, mode)
+			if err != nil {
+				return nil, err
+			}
+			return mcp.NewToolResultStructuredOnly(preview), nil
+		}
 		todo, err := sqlite.GetTodoByID(id)
 		if err != nil {
 			return nil, fmt.Errorf("todo with ID %d not found", id)
 		}
-		if err := sqlite.DeleteTodo(id); err != nil {
+		if err := sqlite.DeleteTodo(id, mode); err != nil {
 			return nil, err
 		}
 		return mcp.NewToolResultText(fmt.Sprintf("Deleted todo: %s (ID: %d)", todo.Title, todo.ID)), nil
 	})
+
+	// recategorize
+	s.AddTool(mcp.NewTool(
+		"recategorize",
+		mcp.WithDescription("批量修改类别：使用from/to重命名整个类别，或使用ids/category修改指定待办事项"),
+		mcp.WithString("from",
+			mcp.Description("原类别"),
+		),
+		mcp.WithString("to",
+			mcp.Description("新类别"),
+		),
+		mcp.WithArray("ids",
+			mcp.Description("待办事项ID列表"),
+			mcp.WithNumberItems(),
+		),
+		mcp.WithString("category",
+			mcp.Description("ids对应待办事项的目标类别"),
+		),
+	), func(ctx context.Context, req mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		sqlite := sqlite.WithContext(ctx)
+		ids := req.GetIntSlice("ids", nil)
+		from := req.GetString("from", "")
+		to := req.GetString("to", "")
+		category := req.GetString("category", "")
+
+		var changed int64
+		var err error
+		switch {
+		case len(ids) > 0:
+			if category == "" {
+				return nil, fmt.Errorf("category is required when ids are given")
+			}
+			changed, err = sqlite.RecategorizeByIDs(ids, category, db.SourceMCP)
+		case from != "" && to != "":
+			changed, err = sqlite.RenameCategory(from, to, db.SourceMCP)
+		default:
+			return nil, fmt.Errorf("either {from, to} or {ids, category} is required")
+		}
+		if err != nil {
+			return nil, err
+		}
+		return mcp.NewToolResultText(fmt.Sprintf("Recategorized %d todos", changed)), nil
+	})
+
+	// rename_category
+	s.AddTool(mcp.NewTool(
+		"rename_category",
+		mcp.WithDescription("在一个事务中将某个类别下的所有待办事项改为新类别；新类别已存在时报错，除非merge为true"),
+		mcp.WithString("from",
+			mcp.Required(),
+			mcp.Description("原类别"),
+		),
+		mcp.WithString("to",
+			mcp.Required(),
+			mcp.Description("新类别"),
+		),
+		mcp.WithBoolean("merge",
+			mcp.Description("新类别已存在时合并到该类别"),
+		),
+	), func(ctx context.Context, req mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		sqlite := sqlite.WithContext(ctx)
+		from := strings.TrimSpace(req.GetString("from", ""))
+		to := strings.TrimSpace(req.GetString("to", ""))
+		if from == "" || to == "" {
+			return nil, fmt.Errorf("both from and to are required")
+		}
+		if from == to {
+			return nil, fmt.Errorf("from and to must differ")
+		}
+		changed, err := sqlite.RenameCategoryStrict(from, to, req.GetBool("merge", false), db.SourceMCP)
+		if err != nil {
+			return nil, err
+		}
+		return mcp.NewToolResultText(fmt.Sprintf("Renamed category %q to %q on %d todos", from, to, changed)), nil
+	})
+
+	// migrate_category_to_project
+	s.AddTool(mcp.NewTool(
+		"migrate_category_to_project",
+		mcp.WithDescription("将某个类别下的所有待办事项归属到项目，项目不存在时自动创建"),
+		mcp.WithString("category",
+			mcp.Required(),
+			mcp.Description("要迁移的类别"),
+		),
+		mcp.WithString("project_name",
+			mcp.Required(),
+			mcp.Description("目标项目名称"),
+		),
+	), func(ctx context.Context, req mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		sqlite := sqlite.WithContext(ctx)
+		category := req.GetString("category", "")
+		projectName := strings.TrimSpace(req.GetString("project_name", ""))
+		if category == "" || projectName == "" {
+			return nil, fmt.Errorf("category and project_name are required")
+		}
+
+		result, err := sqlite.MigrateCategoryToProject(category, projectName, db.SourceMCP)
+		if err != nil {
+			return nil, err
+		}
+		return mcp.NewToolResultStructuredOnly(result), nil
+	})
+
+	// detect_conflicts
+	s.AddTool(mcp.NewTool(
+		"detect_conflicts",
+		mcp.WithDescription("检测时间窗口重叠的已排期待办事项（status=scheduled），按截止时间和预计耗时计算"),
+	), func(ctx context.Context, req mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		sqlite := sqlite.WithContext(ctx)
+		todos, err := sqlite.GetAllTodos()
+		if err != nil {
+			return nil, err
+		}
+		return mcp.NewToolResultStructuredOnly(db.DetectConflicts(todos)), nil
+	})
+
+	// list_completed
+	s.AddTool(mcp.NewTool(
+		"list_completed",
+		mcp.WithDescription("列出完成时间在指定范围内的待办事项，并汇总预计耗时"),
+		mcp.WithString("from",
+			mcp.Description("开始时间（YYYY-MM-DD或RFC3339），默认今天零点"),
+		),
+		mcp.WithString("to",
+			mcp.Description("结束时间（YYYY-MM-DD时包含当天，或RFC3339），默认当前时间"),
+		),
+	), func(ctx context.Context, req mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		sqlite := sqlite.WithContext(ctx)
+		profile := sqlite.LoadProfileOrDefault()
+		from, to, err := db.ParseDateRange(req.GetString("from", ""), req.GetString("to", ""), time.Now().In(profile.Location()))
+		if err != nil {
+			return nil, err
+		}
+		todos, err := sqlite.GetCompletedTodos(from, to)
+		if err != nil {
+			return nil, err
+		}
+		return mcp.NewToolResultStructuredOnly(db.SummarizeCompleted(todos, from, to)), nil
+	})
+
+	// burndown
+	s.AddTool(mcp.NewTool(
+		"burndown",
+		mcp.WithDescription("按天计算项目、类别或标签在时间范围内剩余的预计工作量，用于绘制燃尽图"),
+		mcp.WithNumber("project_id",
+			mcp.Description("项目ID，与category、tag三选一"),
+		),
+		mcp.WithString("category",
+			mcp.Description("类别，与project_id、tag三选一"),
+		),
+		mcp.WithString("tag",
+			mcp.Description("标签（手动或自动标签），与project_id、category三选一"),
+		),
+		mcp.WithString("from",
+			mcp.Description("开始日期（YYYY-MM-DD或RFC3339），默认13天前"),
+		),
+		mcp.WithString("to",
+			mcp.Description("结束日期（YYYY-MM-DD时包含当天，或RFC3339），默认当前时间"),
+		),
+	), func(ctx context.Context, req mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		sqlite := sqlite.WithContext(ctx)
+		projectID := int(req.GetFloat("project_id", 0))
+		category := req.GetString("category", "")
+		tag := req.GetString("tag", "")
+		scopes := 0
+		for _, set := range []bool{projectID > 0, category != "", tag != ""} {
+			if set {
+				scopes++
+			}
+		}
+		if scopes != 1 {
+			return nil, fmt.Errorf("exactly one of project_id, category or tag is required")
+		}
+
+		profile := sqlite.LoadProfileOrDefault()
+		now := time.Now().In(profile.Location())
+		from := req.GetString("from", now.AddDate(0, 0, -13).Format("2006-01-02"))
+		start, end, err := db.ParseDateRange(from, req.GetString("to", ""), now)
+		if err != nil {
+			return nil, err
+		}
+
+		todos, err := sqlite.GetAllTodos()
+		if err != nil {
+			return nil, err
+		}
+		var scoped []db.Todo
+		if tag != "" {
+			scoped = db.FilterByTag(todos, tag)
+		} else {
+			for _, todo := range todos {
+				if (projectID > 0 && todo.ProjectID != nil && *todo.ProjectID == projectID) ||
+					(category != "" && todo.Category == category) {
+					scoped = append(scoped, todo)
+				}
+			}
+		}
+		return mcp.NewToolResultStructuredOnly(db.ComputeBurndown(scoped, start, end)), nil
+	})
+
+	// usage_stats
+	s.AddTool(mcp.NewTool(
+		"usage_stats",
+		mcp.WithDescription("各MCP工具自服务启动以来的调用次数、失败次数和最近调用时间"),
+	), func(ctx context.Context, req mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		return mcp.NewToolResultStructuredOnly(Usage()), nil
+	})
+
+	// account_stats
+	s.AddTool(mcp.NewTool(
+		"account_stats",
+		mcp.WithDescription("账户汇总统计：总数、按状态/优先级分布、过期数、本周完成数、平均完成耗时、最久未完成任务"),
+	), func(ctx context.Context, req mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		sqlite := sqlite.WithContext(ctx)
+		profile := sqlite.LoadProfileOrDefault()
+		stats, err := sqlite.GetAccountStats(time.Now().In(profile.Location()), config.Cfg.Overdue.Grace)
+		if err != nil {
+			return nil, err
+		}
+		return mcp.NewToolResultStructuredOnly(stats), nil
+	})
+
+	// checklist_add
+	s.AddTool(mcp.NewTool(
+		"checklist_add",
+		mcp.WithDescription("为待办事项添加清单项"),
+		mcp.WithNumber("id",
+			mcp.Required(),
+			mcp.Description("待办事项ID"),
+		),
+		mcp.WithString("text",
+			mcp.Required(),
+			mcp.Description("清单项内容"),
+		),
+	), func(ctx context.Context, req mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		sqlite := sqlite.WithContext(ctx)
+		todo, err := sqlite.AddChecklistItem(int(req.GetFloat("id", 0)), req.GetString("text", ""), db.SourceMCP)
+		if err != nil {
+			return nil, err
+		}
+		return mcp.NewToolResultStructuredOnly(todo), nil
+	})
+
+	// checklist_toggle
+	s.AddTool(mcp.NewTool(
+		"checklist_toggle",
+		mcp.WithDescription("切换或设置清单项的完成状态"),
+		mcp.WithNumber("id",
+			mcp.Required(),
+			mcp.Description("待办事项ID"),
+		),
+		mcp.WithNumber("index",
+			mcp.Required(),
+			mcp.Description("清单项序号（从0开始）"),
+		),
+		mcp.WithBoolean("done",
+			mcp.Description("完成状态，不填时切换当前状态"),
+		),
+	), func(ctx context.Context, req mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		sqlite := sqlite.WithContext(ctx)
+		var done *bool
+		if v, ok := req.GetArguments()["done"].(bool); ok {
+			done = &v
+		}
+		todo, err := sqlite.SetChecklistItemDone(int(req.GetFloat("id", 0)), int(req.GetFloat("index", 0)), done, db.SourceMCP)
+		if err != nil {
+			return nil, err
+		}
+		return mcp.NewToolResultStructuredOnly(todo), nil
+	})
+
+	// checklist_remove
+	s.AddTool(mcp.NewTool(
+		"checklist_remove",
+		mcp.WithDescription("删除清单项"),
+		mcp.WithNumber("id",
+			mcp.Required(),
+			mcp.Description("待办事项ID"),
+		),
+		mcp.WithNumber("index",
+			mcp.Required(),
+			mcp.Description("清单项序号（从0开始）"),
+		),
+	), func(ctx context.Context, req mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		sqlite := sqlite.WithContext(ctx)
+		todo, err := sqlite.RemoveChecklistItem(int(req.GetFloat("id", 0)), int(req.GetFloat("index", 0)), db.SourceMCP)
+		if err != nil {
+			return nil, err
+		}
+		return mcp.NewToolResultStructuredOnly(todo), nil
+	})
+
+	// search_todos
+	s.AddTool(mcp.NewTool(
+		"search_todos",
+		mcp.WithDescription("按关键词搜索待办事项的标题和描述，fuzzy模式容忍拼写错误"),
+		mcp.WithString("query",
+			mcp.Required(),
+			mcp.Description("搜索关键词"),
+		),
+		mcp.WithBoolean("fuzzy",
+			mcp.Description("是否启用模糊搜索"),
+		),
+		mcp.WithNumber("max_distance",
+			mcp.Description("模糊搜索允许的最大编辑距离"),
+		),
+	), func(ctx context.Context, req mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		sqlite := sqlite.WithContext(ctx)
+		query := req.GetString("query", "")
+		if query == "" {
+			return nil, fmt.Errorf("query is required")
+		}
+		if !req.GetBool("fuzzy", false) {
+			todos, err := sqlite.SearchTodos(query)
+			if err != nil {
+				return nil, err
+			}
+			return mcp.NewToolResultStructuredOnly(todos), nil
+		}
+
+		maxDistance := int(req.GetFloat("max_distance", float64(config.Cfg.Search.MaxDistance)))
+		results, err := sqlite.FuzzySearchTodos(query, maxDistance)
+		if err != nil {
+			return nil, err
+		}
+		return mcp.NewToolResultStructuredOnly(results), nil
+	})
+
+	// suggest_due_dates
+	s.AddTool(mcp.NewTool(
+		"suggest_due_dates",
+		mcp.WithDescription("按优先级和每日工作时长，为没有截止日期的待办事项建议截止日期；默认仅返回建议"),
+		mcp.WithBoolean("apply",
+			mcp.Description("是否将建议的截止日期写入待办事项"),
+		),
+	), func(ctx context.Context, req mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		sqlite := sqlite.WithContext(ctx)
+		profile := sqlite.LoadProfileOrDefault()
+		todos, err := sqlite.GetAllTodos()
+		if err != nil {
+			return nil, err
+		}
+
+		proposals, err := db.SuggestDueDates(todos, profile.WorkSchedule, time.Now().In(profile.Location()))
+		if err != nil {
+			return nil, err
+		}
+
+		if req.GetBool("apply", false) {
+			if err := sqlite.ApplyDueDates(proposals, db.SourceMCP); err != nil {
+				return nil, err
+			}
+		}
+		return mcp.NewToolResultStructuredOnly(proposals), nil
+	})
+
+	// spread_due_dates
+	s.AddTool(mcp.NewTool(
+		"spread_due_dates",
+		mcp.WithDescription("按优先级把没有截止日期的待办事项从指定日期起分配到工作日，每天固定数量，跳过周末和节假日；默认仅返回分配结果"),
+		mcp.WithString("start",
+			mcp.Description("开始日期（YYYY-MM-DD），默认今天"),
+		),
+		mcp.WithNumber("tasks_per_day",
+			mcp.Required(),
+			mcp.Description("每个工作日分配的任务数"),
+		),
+		mcp.WithBoolean("apply",
+			mcp.Description("是否将分配的截止日期写入待办事项"),
+		),
+	), func(ctx context.Context, req mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		sqlite := sqlite.WithContext(ctx)
+		profile := sqlite.LoadProfileOrDefault()
+		now := time.Now().In(profile.Location())
+		start := now
+		var err error
+		if v := req.GetString("start", ""); v != "" {
+			if start, err = time.ParseInLocation("2006-01-02", v, profile.Location()); err != nil {
+				return nil, fmt.Errorf("invalid start %q, expected YYYY-MM-DD", v)
+			}
+		}
+		holidays := map[string]bool{}
+		for _, day := range config.Cfg.Holidays {
+			holidays[day] = true
+		}
+
+		todos, err := sqlite.GetAllTodos()
+		if err != nil {
+			return nil, err
+		}
+		proposals, err := db.SpreadDueDates(todos, profile.WorkSchedule, holidays, start, now, int(req.GetFloat("tasks_per_day", 0)))
+		if err != nil {
+			return nil, err
+		}
+
+		if req.GetBool("apply", false) {
+			if err := sqlite.ApplyDueDates(proposals, db.SourceMCP); err != nil {
+				return nil, err
+			}
+		}
+		return mcp.NewToolResultStructuredOnly(proposals), nil
+	})
+
+	// age_distribution
+	s.AddTool(mcp.NewTool(
+		"age_distribution",
+		mcp.WithDescription("统计未完成待办事项按创建时长的分布（<1d、1-7d、7-30d、30-90d、>90d）并返回最早创建的一项，用于发现被搁置的任务"),
+	), func(ctx context.Context, req mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		sqlite := sqlite.WithContext(ctx)
+		todos, err := sqlite.GetAllTodos()
+		if err != nil {
+			return nil, err
+		}
+		return mcp.NewToolResultStructuredOnly(db.ComputeAgeDistribution(todos, time.Now())), nil
+	})
+
+	// completion_heatmap
+	s.AddTool(mcp.NewTool(
+		"completion_heatmap",
+		mcp.WithDescription("按星期几和小时（用户时区）统计任务完成次数，返回7x24矩阵（第一行为周一），用于了解自己通常在什么时间完成任务"),
+	), func(ctx context.Context, req mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		sqlite := sqlite.WithContext(ctx)
+		todos, err := sqlite.GetAllTodos()
+		if err != nil {
+			return nil, err
+		}
+		profile := sqlite.LoadProfileOrDefault()
+		return mcp.NewToolResultStructuredOnly(db.BuildCompletionHeatmap(todos, profile.Location())), nil
+	})
+
+	// get_streak
+	s.AddTool(mcp.NewTool(
+		"get_streak",
+		mcp.WithDescription("计算按用户时区的自然日连续有任务完成的天数：当前连续天数（今天还没有完成时从昨天算起）和历史最长连续天数"),
+	), func(ctx context.Context, req mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		sqlite := sqlite.WithContext(ctx)
+		todos, err := sqlite.GetAllTodos()
+		if err != nil {
+			return nil, err
+		}
+		profile := sqlite.LoadProfileOrDefault()
+		return mcp.NewToolResultStructuredOnly(db.ComputeCompletionStreak(todos, time.Now(), profile.Location())), nil
+	})
+
+	// weekly_breakdown
+	s.AddTool(mcp.NewTool(
+		"weekly_breakdown",
+		mcp.WithDescription("按ISO周汇总未来若干周内到期的未完成待办事项数量和预计耗时（小时），已过期的任务单独结转"),
+		mcp.WithNumber("weeks",
+			mcp.Description("汇总的周数（含本周），默认4"),
+		),
+	), func(ctx context.Context, req mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		sqlite := sqlite.WithContext(ctx)
+		profile := sqlite.LoadProfileOrDefault()
+		todos, err := sqlite.GetAllTodos()
+		if err != nil {
+			return nil, err
+		}
+		breakdown, err := db.BuildWeeklyBreakdown(todos, time.Now().In(profile.Location()), int(req.GetFloat("weeks", 4)), config.Cfg.Overdue.Grace)
+		if err != nil {
+			return nil, err
+		}
+		return mcp.NewToolResultStructuredOnly(breakdown), nil
+	})
+
+	// find_by_external_ref
+	s.AddTool(mcp.NewTool(
+		"find_by_external_ref",
+		mcp.WithDescription("查找关联到外部问题跟踪系统条目（如GitHub issue、Jira ticket）的待办事项"),
+		mcp.WithString("system",
+			mcp.Required(),
+			mcp.Description("外部系统名称，不区分大小写"),
+		),
+		mcp.WithString("id",
+			mcp.Required(),
+			mcp.Description("外部系统中的编号"),
+		),
+	), func(ctx context.Context, req mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		sqlite := sqlite.WithContext(ctx)
+		todos, err := sqlite.FindByExternalRef(req.GetString("system", ""), req.GetString("id", ""))
+		if err != nil {
+			return nil, err
+		}
+		return mcp.NewToolResultStructuredOnly(todos), nil
+	})
+
+	// list_delegated
+	s.AddTool(mcp.NewTool(
+		"list_delegated",
+		mcp.WithDescription("列出已委派给他人、正在等待的未完成待办事项"),
+		mcp.WithString("waiting_on",
+			mcp.Description("只列出等待此人的任务"),
+		),
+	), func(ctx context.Context, req mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		sqlite := sqlite.WithContext(ctx)
+		todos, err := sqlite.GetDelegatedTodos(req.GetString("waiting_on", ""))
+		if err != nil {
+			return nil, err
+		}
+		return mcp.NewToolResultStructuredOnly(todos), nil
+	})
+
+	// list_incomplete_metadata
+	s.AddTool(mcp.NewTool(
+		"list_incomplete_metadata",
+		mcp.WithDescription("列出缺少截止日期、预计耗时等元数据的未完成待办事项，便于补全"),
+		mcp.WithArray("fields",
+			mcp.Description("检查的字段，默认使用INCOMPLETE_METADATA_FIELDS"),
+			mcp.WithStringEnumItems(db.MetadataFields),
+		),
+	), func(ctx context.Context, req mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		sqlite := sqlite.WithContext(ctx)
+		fields, err := db.ParseMetadataFields(strings.Join(req.GetStringSlice("fields", nil), ","))
+		if err != nil {
+			return nil, err
+		}
+		todos, err := sqlite.GetAllTodos()
+		if err != nil {
+			return nil, err
+		}
+		return mcp.NewToolResultStructuredOnly(db.FindIncompleteMetadata(todos, fields)), nil
+	})
+
+	// process_inbox
+	s.AddTool(mcp.NewTool(
+		"process_inbox",
+		mcp.WithDescription("整理收件箱：为收件箱中的待办事项建议类别（参考标题相似的任务）、优先级和截止日期（从原始输入或标题解析）；默认仅返回建议"),
+		mcp.WithBoolean("apply",
+			mcp.Description("是否将建议写入待办事项，写入后任务移出收件箱"),
+		),
+	), func(ctx context.Context, req mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		sqlite := sqlite.WithContext(ctx)
+		profile := sqlite.LoadProfileOrDefault()
+		todos, err := sqlite.GetAllTodos()
+		if err != nil {
+			return nil, err
+		}
+
+		suggestions := db.SuggestInboxTriage(todos, time.Now().In(profile.Location()), profile.WorkSchedule)
+		if req.GetBool("apply", false) {
+			if err := sqlite.ApplyInboxSuggestions(suggestions, db.SourceMCP); err != nil {
+				return nil, err
+			}
+		}
+		return mcp.NewToolResultStructuredOnly(suggestions), nil
+	})
+
+	// current_work
+	s.AddTool(mcp.NewTool(
+		"current_work",
+		mcp.WithDescription("列出当前工作时段（按用户的工作时间和时区）内排期的待办事项，即工作时间内现在应该处理的任务；不在工作时间内时返回空列表"),
+		mcp.WithBoolean("any_time",
+			mcp.Description("不在工作时间内时仍返回当天工作时段内的排期"),
+		),
+	), func(ctx context.Context, req mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		sqlite := sqlite.WithContext(ctx)
+		profile := sqlite.LoadProfileOrDefault()
+		todos, err := sqlite.GetAllTodos()
+		if err != nil {
+			return nil, err
+		}
+		work, err := db.FindCurrentWork(todos, profile.WorkSchedule, time.Now().In(profile.Location()), req.GetBool("any_time", false))
+		if err != nil {
+			return nil, err
+		}
+		return mcp.NewToolResultStructuredOnly(work), nil
+	})
+
+	// plan_day
+	s.AddTool(mcp.NewTool(
+		"plan_day",
+		mcp.WithDescription("按优先级和所需精力生成一天的计划：高精力任务排在上午、低精力任务排在下午，按用户工作时间安排并穿插休息，返回时间线；只返回计划，不修改待办事项"),
+		mcp.WithString("date",
+			mcp.Description("计划日期（YYYY-MM-DD），默认今天；今天从当前时间开始安排"),
+		),
+	), func(ctx context.Context, req mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		sqlite := sqlite.WithContext(ctx)
+		profile := sqlite.LoadProfileOrDefault()
+		now := time.Now().In(profile.Location())
+		day := now
+		var err error
+		if v := req.GetString("date", ""); v != "" {
+			if day, err = time.ParseInLocation("2006-01-02", v, profile.Location()); err != nil {
+				return nil, fmt.Errorf("invalid date %q, expected YYYY-MM-DD", v)
+			}
+		}
+
+		todos, err := sqlite.GetAllTodos()
+		if err != nil {
+			return nil, err
+		}
+		plan, err := db.PlanDay(todos, profile.WorkSchedule, day, now, config.Cfg.Plan)
+		if err != nil {
+			return nil, err
+		}
+		return mcp.NewToolResultStructuredOnly(plan), nil
+	})
+
+	// find_gaps
+	s.AddTool(mcp.NewTool(
+		"find_gaps",
+		mcp.WithDescription("找出某天工作时段内已排期任务之间的空闲时间段，便于安排快速完成的小任务；只返回结果，不修改待办事项"),
+		mcp.WithString("date",
+			mcp.Description("日期（YYYY-MM-DD），默认今天"),
+		),
+		mcp.WithNumber("min_minutes",
+			mcp.Description("只返回不短于该分钟数的空闲，默认15"),
+		),
+		mcp.WithNumber("buffer_minutes",
+			mcp.Description("每个排期任务结束后预留的休息分钟数，默认0"),
+		),
+	), func(ctx context.Context, req mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		sqlite := sqlite.WithContext(ctx)
+		profile := sqlite.LoadProfileOrDefault()
+		now := time.Now().In(profile.Location())
+		day := now
+		var err error
+		if v := req.GetString("date", ""); v != "" {
+			if day, err = time.ParseInLocation("2006-01-02", v, profile.Location()); err != nil {
+				return nil, fmt.Errorf("invalid date %q, expected YYYY-MM-DD", v)
+			}
+		}
+		minGap := time.Duration(req.GetFloat("min_minutes", 15)) * time.Minute
+		buffer := time.Duration(req.GetFloat("buffer_minutes", 0)) * time.Minute
+		if minGap < 0 || buffer < 0 {
+			return nil, fmt.Errorf("min_minutes and buffer_minutes must not be negative")
+		}
+
+		todos, err := sqlite.GetAllTodos()
+		if err != nil {
+			return nil, err
+		}
+		gaps, err := db.FindGaps(todos, profile.WorkSchedule, day, now, minGap, buffer)
+		if err != nil {
+			return nil, err
+		}
+		return mcp.NewToolResultStructuredOnly(gaps), nil
+	})
+
+	// set_focus / get_focus
+	s.AddTool(mcp.NewTool(
+		"set_focus",
+		mcp.WithDescription("将未完成的待办事项设为当前焦点（替换之前的焦点），任务完成或删除时自动清除；id为0时清除焦点"),
+		mcp.WithNumber("id",
+			mcp.Required(),
+			mcp.Description("待办事项ID，0表示清除焦点"),
+		),
+	), func(ctx context.Context, req mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		sqlite := sqlite.WithContext(ctx)
+		id := int(req.GetFloat("id", 0))
+		if id == 0 {
+			if err := sqlite.ClearFocus(); err != nil {
+				return nil, err
+			}
+			return mcp.NewToolResultStructuredOnly(&db.Focus{}), nil
+		}
+		focus, err := sqlite.SetFocus(id)
+		if err != nil {
+			return nil, err
+		}
+		return mcp.NewToolResultStructuredOnly(focus), nil
+	})
+	s.AddTool(mcp.NewTool(
+		"get_focus",
+		mcp.WithDescription("获取当前焦点待办事项，没有焦点时todo为null"),
+	), func(ctx context.Context, req mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		sqlite := sqlite.WithContext(ctx)
+		focus, err := sqlite.GetFocus()
+		if err != nil {
+			return nil, err
+		}
+		return mcp.NewToolResultStructuredOnly(focus), nil
+	})
+
+	// list_procrastinated
+	s.AddTool(mcp.NewTool(
+		"list_procrastinated",
+		mcp.WithDescription("列出截止日期或排期被推后次数最多的未完成待办事项，推迟较多的附带建议：break_down拆分，低优先级的delete删除"),
+		mcp.WithNumber("limit",
+			mcp.Description("最多返回的数量，默认10"),
+		),
+	), func(ctx context.Context, req mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		sqlite := sqlite.WithContext(ctx)
+		limit := int(req.GetFloat("limit", 10))
+		if limit <= 0 {
+			return nil, fmt.Errorf("limit must be a positive integer")
+		}
+		todos, err := sqlite.GetAllTodos()
+		if err != nil {
+			return nil, err
+		}
+		counts, err := sqlite.GetPostponeCounts()
+		if err != nil {
+			return nil, err
+		}
+		return mcp.NewToolResultStructuredOnly(db.RankProcrastinated(todos, counts, limit)), nil
+	})
+
+	// explain_schedule
+	s.AddTool(mcp.NewTool(
+		"explain_schedule",
+		mcp.WithDescription("说明日程优化（GET /api/ai/optimize）的结果：每个选入的任务为何排在当前位置（优先级、截止日期远近、与前一项的比较），未选入的任务为何被排除（数量上限），以及哪些任务依赖尚未完成的任务"),
+		mcp.WithNumber("limit",
+			mcp.Description("最多选出的任务数，默认OPTIMIZE_SCHEDULE_LIMIT"),
+		),
+		mcp.WithString("sort",
+			mcp.Description("排序策略，默认SORT_STRATEGY"),
+			mcp.Enum("priority_first", "due_first"),
+		),
+	), func(ctx context.Context, req mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		sqlite := sqlite.WithContext(ctx)
+		limit := int(req.GetFloat("limit", float64(config.Cfg.Optimize.Limit)))
+		if limit <= 0 {
+			return nil, fmt.Errorf("limit must be a positive integer")
+		}
+		strategy, err := db.ParseSortStrategy(req.GetString("sort", ""))
+		if err != nil {
+			return nil, err
+		}
+		profile := sqlite.LoadProfileOrDefault()
+		now := time.Now().In(profile.Location())
+
+		todos, err := sqlite.GetAllTodos()
+		if err != nil {
+			return nil, err
+		}
+		edges, err := sqlite.GetAllDependencies()
+		if err != nil {
+			return nil, err
+		}
+		grace := config.Cfg.Overdue.Grace
+		result := db.OptimizeSchedule(todos, now, limit, grace, strategy)
+		return mcp.NewToolResultStructuredOnly(db.ExplainSchedule(result, todos, edges, now, grace)), nil
+	})
+
+	// schedule_todo
+	s.AddTool(mcp.NewTool(
+		"schedule_todo",
+		mcp.WithDescription("将待办事项排期到指定时间段（状态设为scheduled，结束时间按预计耗时计算），与其他排期冲突时报错"),
+		mcp.WithNumber("id",
+			mcp.Required(),
+			mcp.Description("待办事项ID"),
+		),
+		mcp.WithString("start",
+			mcp.Required(),
+			mcp.Description("开始时间（RFC3339）"),
+		),
+		mcp.WithBoolean("force",
+			mcp.Description("存在冲突时仍然排期"),
+		),
+	), func(ctx context.Context, req mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		sqlite := sqlite.WithContext(ctx)
+		start, err := time.Parse(time.RFC3339, req.GetString("start", ""))
+		if err != nil {
+			return nil, fmt.Errorf("invalid start, expected RFC3339: %v", err)
+		}
+		todo, err := sqlite.ScheduleTodo(int(req.GetFloat("id", 0)), start, req.GetBool("force", false), db.SourceMCP)
+		var conflict *db.ErrScheduleConflict
+		if errors.As(err, &conflict) {
+			return mcp.NewToolResultStructured(conflict.Conflicts, conflict.Error()), nil
+		}
+		if err != nil {
+			return nil, err
+		}
+		return mcp.NewToolResultStructuredOnly(todo), nil
+	})
+
+	// bulk_transition
+	s.AddTool(mcp.NewTool(
+		"bulk_transition",
+		mcp.WithDescription("批量修改待办事项状态，逐项检查状态转换规则并返回每项结果"),
+		mcp.WithArray("ids",
+			mcp.Required(),
+			mcp.Description("待办事项ID列表"),
+			mcp.WithNumberItems(),
+		),
+		mcp.WithString("to_status",
+			mcp.Required(),
+			mcp.Description("目标状态"),
+			mcp.Enum("pending", "in_progress", "scheduled", "completed"),
+		),
+	), func(ctx context.Context, req mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		sqlite := sqlite.WithContext(ctx)
+		ids := req.GetIntSlice("ids", nil)
+		status := req.GetString("to_status", "")
+		if len(ids) == 0 || status == "" {
+			return nil, fmt.Errorf("ids and to_status are required")
+		}
+		return mcp.NewToolResultStructuredOnly(sqlite.BulkTransition(ids, status, db.SourceMCP)), nil
+	})
+
+	// estimate_duration
+	s.AddTool(mcp.NewTool(
+		"estimate_duration",
+		mcp.WithDescription("根据同类别已完成任务的历史耗时，为新任务建议预计耗时"),
+		mcp.WithString("title",
+			mcp.Description("任务标题，用于匹配相似任务"),
+		),
+		mcp.WithString("category",
+			mcp.Required(),
+			mcp.Description("类别"),
+		),
+	), func(ctx context.Context, req mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		sqlite := sqlite.WithContext(ctx)
+		todos, err := sqlite.GetAllTodos()
+		if err != nil {
+			return nil, err
+		}
+		return mcp.NewToolResultStructuredOnly(db.EstimateDuration(todos, req.GetString("title", ""), req.GetString("category", ""))), nil
+	})
+
+	// star_todo / unstar_todo
+	for _, starred := range []bool{true, false} {
+		name, desc := "star_todo", "为待办事项添加星标，星标任务在列表中置顶"
+		if !starred {
+			name, desc = "unstar_todo", "取消待办事项的星标"
+		}
+		s.AddTool(mcp.NewTool(
+			name,
+			mcp.WithDescription(desc),
+			mcp.WithNumber("id",
+				mcp.Required(),
+				mcp.Description("待办事项ID"),
+			),
+		), func(ctx context.Context, req mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+			sqlite := sqlite.WithContext(ctx)
+			todo, err := sqlite.SetStarred(int(req.GetFloat("id", 0)), starred, db.SourceMCP)
+			if err != nil {
+				return nil, err
+			}
+			return mcp.NewToolResultStructuredOnly(todo), nil
+		})
+	}
+}
+
+// dueDateArg 解析due_date参数，支持"YYYY-MM-DD"（用户时区零点）和RFC3339，未提供时返回nil
+func dueDateArg(sqlite *db.SQLiteDatabase, req mcp.CallToolRequest) (*time.Time, error) {
+	v := req.GetString("due_date", "")
+	if v == "" {
+		return nil, nil
+	}
+	profile := sqlite.LoadProfileOrDefault()
+	if due, err := time.ParseInLocation("2006-01-02", v, profile.Location()); err == nil {
+		return &due, nil
+	}
+	due, err := time.Parse(time.RFC3339, v)
+	if err != nil {
+		return nil, fmt.Errorf("invalid due_date %q, expected YYYY-MM-DD or RFC3339", v)
+	}
+	return &due, nil
+}
+
+// externalRefArg 从external_system、external_id、external_url参数构造外部引用，external_system为空时返回nil
+func externalRefArg(req mcp.CallToolRequest) *db.ExternalRef {
+	system := req.GetString("external_system", "")
+	if system == "" {
+		return nil
+	}
+	return &db.ExternalRef{
+		System: system,
+		ID:     req.GetString("external_id", ""),
+		URL:    req.GetString("external_url", ""),
+	}
 }
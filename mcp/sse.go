@@ -0,0 +1,82 @@
+package mcp
+
+import (
+	"fmt"
+	"fydeos/config"
+	"log"
+	"net"
+	"net/http"
+	"strconv"
+	"sync"
+
+	"github.com/mark3labs/mcp-go/server"
+)
+
+// SSEStatus MCP SSE服务器的运行状态
+type SSEStatus struct {
+	Running bool   `json:"running"`
+	Addr    string `json:"addr,omitempty"`
+	Error   string `json:"error,omitempty"`
+}
+
+var (
+	sseMu     sync.RWMutex
+	sseStatus SSEStatus
+)
+
+// Status 返回SSE服务器当前状态
+func Status() SSEStatus {
+	sseMu.RLock()
+	defer sseMu.RUnlock()
+	return sseStatus
+}
+
+func setStatus(status SSEStatus) {
+	sseMu.Lock()
+	sseStatus = status
+	sseMu.Unlock()
+}
+
+// serveSSE 监听配置的地址并在后台提供SSE服务。
+// 端口被占用时按PortFallback依次尝试后续端口；全部失败时只记录警告，不影响REST服务
+func serveSSE(srv *server.SSEServer, cfg config.MCPConfig) {
+	ln, err := listenWithFallback(cfg.Addr, cfg.PortFallback)
+	if err != nil {
+		log.Printf("Warning: MCP SSE server disabled: %v", err)
+		setStatus(SSEStatus{Error: err.Error()})
+		return
+	}
+
+	addr := ln.Addr().String()
+	log.Printf("MCP SSE server listening on %s", addr)
+	setStatus(SSEStatus{Running: true, Addr: addr})
+
+	go func() {
+		if err := http.Serve(ln, srv); err != nil {
+			log.Printf("Warning: MCP SSE server stopped: %v", err)
+			setStatus(SSEStatus{Addr: addr, Error: err.Error()})
+		}
+	}()
+}
+
+// listenWithFallback 监听addr，失败时依次尝试之后的fallback个端口
+func listenWithFallback(addr string, fallback int) (net.Listener, error) {
+	host, portStr, err := net.SplitHostPort(addr)
+	if err != nil {
+		return nil, fmt.Errorf("invalid address %q: %v", addr, err)
+	}
+	port, err := strconv.Atoi(portStr)
+	if err != nil {
+		return nil, fmt.Errorf("invalid port in %q: %v", addr, err)
+	}
+
+	var lastErr error
+	for i := 0; i <= fallback; i++ {
+		ln, err := net.Listen("tcp", net.JoinHostPort(host, strconv.Itoa(port+i)))
+		if err == nil {
+			return ln, nil
+		}
+		lastErr = err
+	}
+	return nil, lastErr
+}
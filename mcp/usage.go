@@ -0,0 +1,64 @@
+package mcp
+
+import (
+	"context"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/mark3labs/mcp-go/server"
+)
+
+// ToolUsage 单个MCP工具的调用统计，仅保存在内存中，重启后清零
+type ToolUsage struct {
+	Tool         string    `json:"tool"`
+	Calls        int       `json:"calls"`
+	Errors       int       `json:"errors"`
+	LastCalledAt time.Time `json:"last_called_at"`
+}
+
+var (
+	usageMu sync.Mutex
+	usage   = map[string]*ToolUsage{}
+)
+
+// Usage 返回各工具的调用统计，按工具名排序
+func Usage() []ToolUsage {
+	usageMu.Lock()
+	defer usageMu.Unlock()
+
+	result := make([]ToolUsage, 0, len(usage))
+	for _, u := range usage {
+		result = append(result, *u)
+	}
+	sort.Slice(result, func(i, j int) bool {
+		return result[i].Tool < result[j].Tool
+	})
+	return result
+}
+
+func recordUsage(tool string, at time.Time, failed bool) {
+	usageMu.Lock()
+	defer usageMu.Unlock()
+
+	u, ok := usage[tool]
+	if !ok {
+		u = &ToolUsage{Tool: tool}
+		usage[tool] = u
+	}
+	u.Calls++
+	if failed {
+		u.Errors++
+	}
+	u.LastCalledAt = at
+}
+
+// usageMiddleware 记录每次工具调用，工具返回错误或错误结果时同时计入Errors
+func usageMiddleware(next server.ToolHandlerFunc) server.ToolHandlerFunc {
+	return func(ctx context.Context, req mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		result, err := next(ctx, req)
+		recordUsage(req.Params.Name, time.Now(), err != nil || (result != nil && result.IsError))
+		return result, err
+	}
+}
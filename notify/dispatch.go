@@ -0,0 +1,73 @@
+package notify
+
+import (
+	"context"
+	"errors"
+	"log"
+)
+
+// ErrQueueFull 队列已满且溢出策略为drop时返回
+var ErrQueueFull = errors.New("notification queue is full, dropping notification")
+
+type dispatchJob struct {
+	ctx context.Context
+	n   Notification
+}
+
+// Dispatcher 用固定数量的worker异步投递通知，限制同时进行的投递数。
+// 队列满时按overflow丢弃新通知（drop）或阻塞调用方直到有空位（block）
+type Dispatcher struct {
+	next     Notifier
+	queue    chan dispatchJob
+	overflow string
+}
+
+// NewDispatcher 启动workers个worker向next投递通知，workers和queueSize至少为1
+func NewDispatcher(next Notifier, workers, queueSize int, overflow string) *Dispatcher {
+	if workers < 1 {
+		workers = 1
+	}
+	if queueSize < 1 {
+		queueSize = 1
+	}
+
+	d := &Dispatcher{
+		next:     next,
+		queue:    make(chan dispatchJob, queueSize),
+		overflow: overflow,
+	}
+	for i := 0; i < workers; i++ {
+		go d.work()
+	}
+	return d
+}
+
+func (d *Dispatcher) work() {
+	for job := range d.queue {
+		if err := d.next.Notify(job.ctx, job.n); err != nil {
+			log.Printf("Warning: failed to deliver %s notification: %v", job.n.Kind, err)
+		}
+	}
+}
+
+// Notify 将通知放入队列后立即返回，投递失败只记录日志
+func (d *Dispatcher) Notify(ctx context.Context, n Notification) error {
+	// 投递在调用返回后进行，不能随调用方的ctx一起取消
+	job := dispatchJob{ctx: context.WithoutCancel(ctx), n: n}
+
+	if d.overflow == "block" {
+		select {
+		case d.queue <- job:
+			return nil
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+
+	select {
+	case d.queue <- job:
+		return nil
+	default:
+		return ErrQueueFull
+	}
+}
@@ -0,0 +1,109 @@
+package notify
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"fydeos/config"
+	"log"
+	"net/http"
+	"time"
+)
+
+// Notification 一条提醒、议程等通知
+type Notification struct {
+	Kind    string      `json:"kind"` // nudge, digest, reminder...
+	Message string      `json:"message"`
+	Data    interface{} `json:"data,omitempty"`
+	Time    time.Time   `json:"time"`
+}
+
+// Notifier 通知投递渠道
+type Notifier interface {
+	Notify(ctx context.Context, n Notification) error
+}
+
+// Multi 依次投递到所有渠道，单个渠道失败不影响其他渠道
+type Multi []Notifier
+
+func (m Multi) Notify(ctx context.Context, n Notification) error {
+	var errs []error
+	for _, notifier := range m {
+		if err := notifier.Notify(ctx, n); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// LogNotifier 将通知写入日志
+type LogNotifier struct{}
+
+func (LogNotifier) Notify(ctx context.Context, n Notification) error {
+	log.Println(n.Message)
+	return nil
+}
+
+// WebhookNotifier 以JSON POST通知到URL
+type WebhookNotifier struct {
+	URL    string
+	Client *http.Client
+}
+
+func NewWebhookNotifier(url string) *WebhookNotifier {
+	return &WebhookNotifier{
+		URL:    url,
+		Client: &http.Client{Timeout: 10 * time.Second},
+	}
+}
+
+func (w *WebhookNotifier) Notify(ctx context.Context, n Notification) error {
+	body, err := json.Marshal(n)
+	if err != nil {
+		return fmt.Errorf("failed to marshal notification: %v", err)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, w.URL, bytes.NewReader(body))
+	if err != nil {
+		return fmt.Errorf("failed to build webhook request: %v", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := w.Client.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to post notification: %v", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode >= 300 {
+		return fmt.Errorf("notification webhook returned status %d", resp.StatusCode)
+	}
+	return nil
+}
+
+// New 按配置创建通知渠道；启用sse时同时返回SSE渠道，用于注册HTTP推送端点
+func New(cfg config.NotifyConfig) (Notifier, *SSENotifier) {
+	var notifiers Multi
+	var sse *SSENotifier
+
+	for _, channel := range cfg.Channels {
+		switch channel {
+		case "log":
+			notifiers = append(notifiers, LogNotifier{})
+		case "webhook":
+			if cfg.WebhookURL == "" {
+				log.Printf("Warning: webhook notifier enabled without NOTIFY_WEBHOOK_URL, skipping")
+				continue
+			}
+			webhook := NewWebhookNotifier(cfg.WebhookURL)
+			notifiers = append(notifiers, NewDispatcher(webhook, cfg.WebhookWorkers, cfg.WebhookQueueSize, cfg.WebhookOverflow))
+		case "sse":
+			sse = NewSSENotifier()
+			notifiers = append(notifiers, sse)
+		}
+	}
+
+	return notifiers, sse
+}
@@ -0,0 +1,75 @@
+package notify
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"sync"
+)
+
+// 每个SSE连接缓冲的通知数，客户端过慢时丢弃新通知
+const sseBuffer = 16
+
+// SSENotifier 通过Server-Sent Events向已连接的客户端推送通知
+type SSENotifier struct {
+	mu      sync.Mutex
+	clients map[chan Notification]struct{}
+}
+
+func NewSSENotifier() *SSENotifier {
+	return &SSENotifier{clients: map[chan Notification]struct{}{}}
+}
+
+func (s *SSENotifier) Notify(ctx context.Context, n Notification) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for ch := range s.clients {
+		select {
+		case ch <- n:
+		default:
+		}
+	}
+	return nil
+}
+
+// ServeHTTP 保持连接并持续推送通知，直到客户端断开
+func (s *SSENotifier) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	rc := http.NewResponseController(w)
+
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.Header().Set("Connection", "keep-alive")
+	w.WriteHeader(http.StatusOK)
+	if err := rc.Flush(); err != nil {
+		return
+	}
+
+	ch := make(chan Notification, sseBuffer)
+	s.mu.Lock()
+	s.clients[ch] = struct{}{}
+	s.mu.Unlock()
+
+	defer func() {
+		s.mu.Lock()
+		delete(s.clients, ch)
+		s.mu.Unlock()
+	}()
+
+	for {
+		select {
+		case <-r.Context().Done():
+			return
+		case n := <-ch:
+			data, err := json.Marshal(n)
+			if err != nil {
+				continue
+			}
+			fmt.Fprintf(w, "event: %s\ndata: %s\n\n", n.Kind, data)
+			if err := rc.Flush(); err != nil {
+				return
+			}
+		}
+	}
+}
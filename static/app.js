@@ -39,8 +39,19 @@ createApp({
 
         async fetchTodos() {
             try {
-                const response = await axios.get('/api/todos');
-                this.todos = response.data || [];
+                // 列表接口分页返回，按X-Total-Count逐页获取全部任务
+                const perPage = 500;
+                let todos = [];
+                for (let page = 1; ; page++) {
+                    const response = await axios.get('/api/todos', { params: { page, per_page: perPage } });
+                    const items = response.data || [];
+                    todos = todos.concat(items);
+                    const total = parseInt(response.headers['x-total-count'], 10);
+                    if (items.length < perPage || isNaN(total) || todos.length >= total) {
+                        break;
+                    }
+                }
+                this.todos = todos;
             } catch (error) {
                 console.error('获取任务失败:', error);
                 this.showNotification('获取任务失败', 'error');
@@ -125,7 +136,7 @@ createApp({
                             <h4>建议任务顺序:</h4>
                             <ul>
                                 ${optimization.optimized_tasks.map(task => 
-                                    `<li>${task.title} (${this.getPriorityText(task.priority)})</li>`
+                                    `<li>${task.todo.title} (${this.getPriorityText(task.todo.priority)}) - ${task.reasons.join('，')}</li>`
                                 ).join('')}
                             </ul>
                             <h4>优化提示:</h4>
@@ -299,9 +310,16 @@ createApp({
             return statusMap[status] || status;
         },
 
-        formatDate(dateString) {
+        formatDate(dateString, allDay = false) {
             if (!dateString) return '';
             const date = new Date(dateString);
+            if (allDay) {
+                return date.toLocaleDateString('zh-CN', {
+                    year: 'numeric',
+                    month: '2-digit',
+                    day: '2-digit'
+                });
+            }
             return date.toLocaleString('zh-CN', {
                 year: 'numeric',
                 month: '2-digit',